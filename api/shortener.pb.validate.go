@@ -573,6 +573,8 @@ func (m *DeleteUrlsResponse) validate(all bool) error {
 
 	// no validation rules for Error
 
+	// no validation rules for Result
+
 	if len(errors) > 0 {
 		return DeleteUrlsResponseMultiError(errors)
 	}
@@ -1490,3 +1492,215 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = SaveShortUrlResponseValidationError{}
+
+// Validate checks the field values on GetUrlStatsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetUrlStatsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlStatsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetUrlStatsRequestMultiError, or nil if none found.
+func (m *GetUrlStatsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlStatsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for UserId
+
+	if len(errors) > 0 {
+		return GetUrlStatsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlStatsRequestMultiError is an error wrapping multiple validation errors
+// returned by GetUrlStatsRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetUrlStatsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlStatsRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlStatsRequestMultiError) AllErrors() []error { return m }
+
+// GetUrlStatsRequestValidationError is the validation error returned by
+// GetUrlStatsRequest.Validate if the designated constraints aren't met.
+type GetUrlStatsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlStatsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlStatsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlStatsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlStatsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlStatsRequestValidationError) ErrorName() string {
+	return "GetUrlStatsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetUrlStatsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlStatsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlStatsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlStatsRequestValidationError{}
+
+// Validate checks the field values on GetUrlStatsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetUrlStatsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlStatsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetUrlStatsResponseMultiError, or nil if none found.
+func (m *GetUrlStatsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlStatsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Stats
+
+	// no validation rules for Error
+
+	if len(errors) > 0 {
+		return GetUrlStatsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlStatsResponseMultiError is an error wrapping multiple validation
+// errors returned by GetUrlStatsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type GetUrlStatsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlStatsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlStatsResponseMultiError) AllErrors() []error { return m }
+
+// GetUrlStatsResponseValidationError is the validation error returned by
+// GetUrlStatsResponse.Validate if the designated constraints aren't met.
+type GetUrlStatsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlStatsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlStatsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlStatsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlStatsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlStatsResponseValidationError) ErrorName() string {
+	return "GetUrlStatsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetUrlStatsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlStatsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlStatsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlStatsResponseValidationError{}
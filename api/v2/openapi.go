@@ -0,0 +1,8 @@
+package apiv2
+
+import _ "embed"
+
+// OpenAPI REST gateway description generated from shortener.proto
+//
+//go:embed shortener.swagger.json
+var OpenAPI []byte
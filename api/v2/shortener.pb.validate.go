@@ -0,0 +1,4479 @@
+// Code generated by protoc-gen-validate. DO NOT EDIT.
+// source: api/v2/shortener.proto
+
+package apiv2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ensure the imports are used
+var (
+	_ = bytes.MinRead
+	_ = errors.New("")
+	_ = fmt.Print
+	_ = utf8.UTFMax
+	_ = (*regexp.Regexp)(nil)
+	_ = (*strings.Reader)(nil)
+	_ = net.IPv4len
+	_ = time.Duration(0)
+	_ = (*url.URL)(nil)
+	_ = (*mail.Address)(nil)
+	_ = anypb.Any{}
+	_ = sort.Sort
+)
+
+// Validate checks the field values on PingRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *PingRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on PingRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in PingRequestMultiError, or
+// nil if none found.
+func (m *PingRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *PingRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return PingRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// PingRequestMultiError is an error wrapping multiple validation errors
+// returned by PingRequest.ValidateAll() if the designated constraints aren't met.
+type PingRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m PingRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m PingRequestMultiError) AllErrors() []error { return m }
+
+// PingRequestValidationError is the validation error returned by
+// PingRequest.Validate if the designated constraints aren't met.
+type PingRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e PingRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e PingRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e PingRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e PingRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e PingRequestValidationError) ErrorName() string { return "PingRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e PingRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sPingRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = PingRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = PingRequestValidationError{}
+
+// Validate checks the field values on PingResponse with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *PingResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on PingResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in PingResponseMultiError, or
+// nil if none found.
+func (m *PingResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *PingResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return PingResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// PingResponseMultiError is an error wrapping multiple validation errors
+// returned by PingResponse.ValidateAll() if the designated constraints aren't met.
+type PingResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m PingResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m PingResponseMultiError) AllErrors() []error { return m }
+
+// PingResponseValidationError is the validation error returned by
+// PingResponse.Validate if the designated constraints aren't met.
+type PingResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e PingResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e PingResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e PingResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e PingResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e PingResponseValidationError) ErrorName() string { return "PingResponseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e PingResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sPingResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = PingResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = PingResponseValidationError{}
+
+// Validate checks the field values on ShortenRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *ShortenRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ShortenRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in ShortenRequestMultiError,
+// or nil if none found.
+func (m *ShortenRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ShortenRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetUrl()) > 2048 {
+		err := ShortenRequestValidationError{
+			field:  "Url",
+			reason: "value length must be at most 2048 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if uri, err := url.Parse(m.GetUrl()); err != nil {
+		err = ShortenRequestValidationError{
+			field:  "Url",
+			reason: "value must be a valid URI",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	} else if !uri.IsAbs() {
+		err := ShortenRequestValidationError{
+			field:  "Url",
+			reason: "value must be absolute",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetAlias() != "" {
+
+		if !_ShortenRequest_Alias_Pattern.MatchString(m.GetAlias()) {
+			err := ShortenRequestValidationError{
+				field:  "Alias",
+				reason: "value does not match regex pattern \"^[A-Za-z0-9_-]{3,50}$\"",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if m.GetTtl() < 0 {
+		err := ShortenRequestValidationError{
+			field:  "Ttl",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, ShortenRequestValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, ShortenRequestValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return ShortenRequestValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return ShortenRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ShortenRequestMultiError is an error wrapping multiple validation errors
+// returned by ShortenRequest.ValidateAll() if the designated constraints
+// aren't met.
+type ShortenRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ShortenRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ShortenRequestMultiError) AllErrors() []error { return m }
+
+// ShortenRequestValidationError is the validation error returned by
+// ShortenRequest.Validate if the designated constraints aren't met.
+type ShortenRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ShortenRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ShortenRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ShortenRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ShortenRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ShortenRequestValidationError) ErrorName() string { return "ShortenRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e ShortenRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sShortenRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ShortenRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ShortenRequestValidationError{}
+
+var _ShortenRequest_Alias_Pattern = regexp.MustCompile("^[A-Za-z0-9_-]{3,50}$")
+
+// Validate checks the field values on ShortenResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *ShortenResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ShortenResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ShortenResponseMultiError, or nil if none found.
+func (m *ShortenResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ShortenResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ShortUrl
+
+	if len(errors) > 0 {
+		return ShortenResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ShortenResponseMultiError is an error wrapping multiple validation errors
+// returned by ShortenResponse.ValidateAll() if the designated constraints
+// aren't met.
+type ShortenResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ShortenResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ShortenResponseMultiError) AllErrors() []error { return m }
+
+// ShortenResponseValidationError is the validation error returned by
+// ShortenResponse.Validate if the designated constraints aren't met.
+type ShortenResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ShortenResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ShortenResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ShortenResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ShortenResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ShortenResponseValidationError) ErrorName() string { return "ShortenResponseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e ShortenResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sShortenResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ShortenResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ShortenResponseValidationError{}
+
+// Validate checks the field values on BatchItem with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *BatchItem) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchItem with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in BatchItemMultiError, or nil
+// if none found.
+func (m *BatchItem) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchItem) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetCorrelationId()) < 1 {
+		err := BatchItemValidationError{
+			field:  "CorrelationId",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetOriginalUrl()) > 2048 {
+		err := BatchItemValidationError{
+			field:  "OriginalUrl",
+			reason: "value length must be at most 2048 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if uri, err := url.Parse(m.GetOriginalUrl()); err != nil {
+		err = BatchItemValidationError{
+			field:  "OriginalUrl",
+			reason: "value must be a valid URI",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	} else if !uri.IsAbs() {
+		err := BatchItemValidationError{
+			field:  "OriginalUrl",
+			reason: "value must be absolute",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetAlias() != "" {
+
+		if !_BatchItem_Alias_Pattern.MatchString(m.GetAlias()) {
+			err := BatchItemValidationError{
+				field:  "Alias",
+				reason: "value does not match regex pattern \"^[A-Za-z0-9_-]{3,50}$\"",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if m.GetTtl() < 0 {
+		err := BatchItemValidationError{
+			field:  "Ttl",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetExpiresAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, BatchItemValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, BatchItemValidationError{
+					field:  "ExpiresAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetExpiresAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return BatchItemValidationError{
+				field:  "ExpiresAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return BatchItemMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchItemMultiError is an error wrapping multiple validation errors returned
+// by BatchItem.ValidateAll() if the designated constraints aren't met.
+type BatchItemMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchItemMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchItemMultiError) AllErrors() []error { return m }
+
+// BatchItemValidationError is the validation error returned by
+// BatchItem.Validate if the designated constraints aren't met.
+type BatchItemValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchItemValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchItemValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchItemValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchItemValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchItemValidationError) ErrorName() string { return "BatchItemValidationError" }
+
+// Error satisfies the builtin error interface
+func (e BatchItemValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchItem.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchItemValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchItemValidationError{}
+
+var _BatchItem_Alias_Pattern = regexp.MustCompile("^[A-Za-z0-9_-]{3,50}$")
+
+// Validate checks the field values on BatchShortenRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BatchShortenRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchShortenRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BatchShortenRequestMultiError, or nil if none found.
+func (m *BatchShortenRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchShortenRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if l := len(m.GetItems()); l < 1 || l > 1000 {
+		err := BatchShortenRequestValidationError{
+			field:  "Items",
+			reason: "value must contain between 1 and 1000 items, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, BatchShortenRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, BatchShortenRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return BatchShortenRequestValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return BatchShortenRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchShortenRequestMultiError is an error wrapping multiple validation
+// errors returned by BatchShortenRequest.ValidateAll() if the designated
+// constraints aren't met.
+type BatchShortenRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchShortenRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchShortenRequestMultiError) AllErrors() []error { return m }
+
+// BatchShortenRequestValidationError is the validation error returned by
+// BatchShortenRequest.Validate if the designated constraints aren't met.
+type BatchShortenRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchShortenRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchShortenRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchShortenRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchShortenRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchShortenRequestValidationError) ErrorName() string {
+	return "BatchShortenRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BatchShortenRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchShortenRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchShortenRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchShortenRequestValidationError{}
+
+// Validate checks the field values on BatchResult with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *BatchResult) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchResult with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in BatchResultMultiError, or
+// nil if none found.
+func (m *BatchResult) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchResult) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for CorrelationId
+
+	// no validation rules for ShortUrl
+
+	if len(errors) > 0 {
+		return BatchResultMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchResultMultiError is an error wrapping multiple validation errors
+// returned by BatchResult.ValidateAll() if the designated constraints aren't met.
+type BatchResultMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchResultMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchResultMultiError) AllErrors() []error { return m }
+
+// BatchResultValidationError is the validation error returned by
+// BatchResult.Validate if the designated constraints aren't met.
+type BatchResultValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchResultValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchResultValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchResultValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchResultValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchResultValidationError) ErrorName() string { return "BatchResultValidationError" }
+
+// Error satisfies the builtin error interface
+func (e BatchResultValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchResult.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchResultValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchResultValidationError{}
+
+// Validate checks the field values on BatchShortenResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BatchShortenResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchShortenResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BatchShortenResponseMultiError, or nil if none found.
+func (m *BatchShortenResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchShortenResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, BatchShortenResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, BatchShortenResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return BatchShortenResponseValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return BatchShortenResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchShortenResponseMultiError is an error wrapping multiple validation
+// errors returned by BatchShortenResponse.ValidateAll() if the designated
+// constraints aren't met.
+type BatchShortenResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchShortenResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchShortenResponseMultiError) AllErrors() []error { return m }
+
+// BatchShortenResponseValidationError is the validation error returned by
+// BatchShortenResponse.Validate if the designated constraints aren't met.
+type BatchShortenResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchShortenResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchShortenResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchShortenResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchShortenResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchShortenResponseValidationError) ErrorName() string {
+	return "BatchShortenResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BatchShortenResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchShortenResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchShortenResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchShortenResponseValidationError{}
+
+// Validate checks the field values on GetUrlRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *GetUrlRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in GetUrlRequestMultiError, or
+// nil if none found.
+func (m *GetUrlRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetUrlRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetUrlRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlRequestMultiError is an error wrapping multiple validation errors
+// returned by GetUrlRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetUrlRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlRequestMultiError) AllErrors() []error { return m }
+
+// GetUrlRequestValidationError is the validation error returned by
+// GetUrlRequest.Validate if the designated constraints aren't met.
+type GetUrlRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlRequestValidationError) ErrorName() string { return "GetUrlRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e GetUrlRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlRequestValidationError{}
+
+// Validate checks the field values on GetUrlResponse with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *GetUrlResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in GetUrlResponseMultiError,
+// or nil if none found.
+func (m *GetUrlResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for OriginalUrl
+
+	if len(errors) > 0 {
+		return GetUrlResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlResponseMultiError is an error wrapping multiple validation errors
+// returned by GetUrlResponse.ValidateAll() if the designated constraints
+// aren't met.
+type GetUrlResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlResponseMultiError) AllErrors() []error { return m }
+
+// GetUrlResponseValidationError is the validation error returned by
+// GetUrlResponse.Validate if the designated constraints aren't met.
+type GetUrlResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlResponseValidationError) ErrorName() string { return "GetUrlResponseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e GetUrlResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlResponseValidationError{}
+
+// Validate checks the field values on ListUserUrlsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListUserUrlsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListUserUrlsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListUserUrlsRequestMultiError, or nil if none found.
+func (m *ListUserUrlsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListUserUrlsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if val := m.GetPageSize(); val < 0 || val > 1000 {
+		err := ListUserUrlsRequestValidationError{
+			field:  "PageSize",
+			reason: "value must be inside range [0, 1000]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Cursor
+
+	if m.GetSort() != "" {
+
+		if _, ok := _ListUserUrlsRequest_Sort_InLookup[m.GetSort()]; !ok {
+			err := ListUserUrlsRequestValidationError{
+				field:  "Sort",
+				reason: "value must be in list [created_at -created_at clicks -clicks]",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if utf8.RuneCountInString(m.GetSearch()) > 2048 {
+		err := ListUserUrlsRequestValidationError{
+			field:  "Search",
+			reason: "value length must be at most 2048 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetDomain()) > 253 {
+		err := ListUserUrlsRequestValidationError{
+			field:  "Domain",
+			reason: "value length must be at most 253 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ListUserUrlsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListUserUrlsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListUserUrlsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListUserUrlsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListUserUrlsRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListUserUrlsRequestMultiError) AllErrors() []error { return m }
+
+// ListUserUrlsRequestValidationError is the validation error returned by
+// ListUserUrlsRequest.Validate if the designated constraints aren't met.
+type ListUserUrlsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListUserUrlsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListUserUrlsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListUserUrlsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListUserUrlsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListUserUrlsRequestValidationError) ErrorName() string {
+	return "ListUserUrlsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListUserUrlsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListUserUrlsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListUserUrlsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListUserUrlsRequestValidationError{}
+
+var _ListUserUrlsRequest_Sort_InLookup = map[string]struct{}{
+	"created_at":  {},
+	"-created_at": {},
+	"clicks":      {},
+	"-clicks":     {},
+}
+
+// Validate checks the field values on UserUrl with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *UserUrl) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UserUrl with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in UserUrlMultiError, or nil if none found.
+func (m *UserUrl) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UserUrl) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ShortUrl
+
+	// no validation rules for OriginalUrl
+
+	if len(errors) > 0 {
+		return UserUrlMultiError(errors)
+	}
+
+	return nil
+}
+
+// UserUrlMultiError is an error wrapping multiple validation errors returned
+// by UserUrl.ValidateAll() if the designated constraints aren't met.
+type UserUrlMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UserUrlMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UserUrlMultiError) AllErrors() []error { return m }
+
+// UserUrlValidationError is the validation error returned by UserUrl.Validate
+// if the designated constraints aren't met.
+type UserUrlValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UserUrlValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UserUrlValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UserUrlValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UserUrlValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UserUrlValidationError) ErrorName() string { return "UserUrlValidationError" }
+
+// Error satisfies the builtin error interface
+func (e UserUrlValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUserUrl.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UserUrlValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UserUrlValidationError{}
+
+// Validate checks the field values on ListUserUrlsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListUserUrlsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListUserUrlsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListUserUrlsResponseMultiError, or nil if none found.
+func (m *ListUserUrlsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListUserUrlsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetUrls() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListUserUrlsResponseValidationError{
+						field:  fmt.Sprintf("Urls[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListUserUrlsResponseValidationError{
+						field:  fmt.Sprintf("Urls[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListUserUrlsResponseValidationError{
+					field:  fmt.Sprintf("Urls[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for NextCursor
+
+	// no validation rules for TotalCount
+
+	if len(errors) > 0 {
+		return ListUserUrlsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListUserUrlsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListUserUrlsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListUserUrlsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListUserUrlsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListUserUrlsResponseMultiError) AllErrors() []error { return m }
+
+// ListUserUrlsResponseValidationError is the validation error returned by
+// ListUserUrlsResponse.Validate if the designated constraints aren't met.
+type ListUserUrlsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListUserUrlsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListUserUrlsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListUserUrlsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListUserUrlsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListUserUrlsResponseValidationError) ErrorName() string {
+	return "ListUserUrlsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListUserUrlsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListUserUrlsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListUserUrlsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListUserUrlsResponseValidationError{}
+
+// Validate checks the field values on StreamUserUrlsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *StreamUserUrlsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StreamUserUrlsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// StreamUserUrlsRequestMultiError, or nil if none found.
+func (m *StreamUserUrlsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StreamUserUrlsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if val := m.GetPageSize(); val < 0 || val > 1000 {
+		err := StreamUserUrlsRequestValidationError{
+			field:  "PageSize",
+			reason: "value must be inside range [0, 1000]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Cursor
+
+	if len(errors) > 0 {
+		return StreamUserUrlsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// StreamUserUrlsRequestMultiError is an error wrapping multiple validation
+// errors returned by StreamUserUrlsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type StreamUserUrlsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StreamUserUrlsRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StreamUserUrlsRequestMultiError) AllErrors() []error { return m }
+
+// StreamUserUrlsRequestValidationError is the validation error returned by
+// StreamUserUrlsRequest.Validate if the designated constraints aren't met.
+type StreamUserUrlsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StreamUserUrlsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StreamUserUrlsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StreamUserUrlsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StreamUserUrlsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StreamUserUrlsRequestValidationError) ErrorName() string {
+	return "StreamUserUrlsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e StreamUserUrlsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStreamUserUrlsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StreamUserUrlsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StreamUserUrlsRequestValidationError{}
+
+// Validate checks the field values on StreamUserUrlsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *StreamUserUrlsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StreamUserUrlsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// StreamUserUrlsResponseMultiError, or nil if none found.
+func (m *StreamUserUrlsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StreamUserUrlsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetUrl()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, StreamUserUrlsResponseValidationError{
+					field:  "Url",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, StreamUserUrlsResponseValidationError{
+					field:  "Url",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUrl()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return StreamUserUrlsResponseValidationError{
+				field:  "Url",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for NextCursor
+
+	if len(errors) > 0 {
+		return StreamUserUrlsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// StreamUserUrlsResponseMultiError is an error wrapping multiple validation
+// errors returned by StreamUserUrlsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type StreamUserUrlsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StreamUserUrlsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StreamUserUrlsResponseMultiError) AllErrors() []error { return m }
+
+// StreamUserUrlsResponseValidationError is the validation error returned by
+// StreamUserUrlsResponse.Validate if the designated constraints aren't met.
+type StreamUserUrlsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StreamUserUrlsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StreamUserUrlsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StreamUserUrlsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StreamUserUrlsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StreamUserUrlsResponseValidationError) ErrorName() string {
+	return "StreamUserUrlsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e StreamUserUrlsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStreamUserUrlsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StreamUserUrlsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StreamUserUrlsResponseValidationError{}
+
+// Validate checks the field values on BulkShortenRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BulkShortenRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BulkShortenRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BulkShortenRequestMultiError, or nil if none found.
+func (m *BulkShortenRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BulkShortenRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for CorrelationId
+
+	if utf8.RuneCountInString(m.GetUrl()) > 2048 {
+		err := BulkShortenRequestValidationError{
+			field:  "Url",
+			reason: "value length must be at most 2048 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if uri, err := url.Parse(m.GetUrl()); err != nil {
+		err = BulkShortenRequestValidationError{
+			field:  "Url",
+			reason: "value must be a valid URI",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	} else if !uri.IsAbs() {
+		err := BulkShortenRequestValidationError{
+			field:  "Url",
+			reason: "value must be absolute",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetAlias() != "" {
+
+		if !_BulkShortenRequest_Alias_Pattern.MatchString(m.GetAlias()) {
+			err := BulkShortenRequestValidationError{
+				field:  "Alias",
+				reason: "value does not match regex pattern \"^[A-Za-z0-9_-]{3,50}$\"",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if m.GetTtl() < 0 {
+		err := BulkShortenRequestValidationError{
+			field:  "Ttl",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return BulkShortenRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// BulkShortenRequestMultiError is an error wrapping multiple validation errors
+// returned by BulkShortenRequest.ValidateAll() if the designated constraints
+// aren't met.
+type BulkShortenRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BulkShortenRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BulkShortenRequestMultiError) AllErrors() []error { return m }
+
+// BulkShortenRequestValidationError is the validation error returned by
+// BulkShortenRequest.Validate if the designated constraints aren't met.
+type BulkShortenRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BulkShortenRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BulkShortenRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BulkShortenRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BulkShortenRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BulkShortenRequestValidationError) ErrorName() string {
+	return "BulkShortenRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BulkShortenRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBulkShortenRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BulkShortenRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BulkShortenRequestValidationError{}
+
+var _BulkShortenRequest_Alias_Pattern = regexp.MustCompile("^[A-Za-z0-9_-]{3,50}$")
+
+// Validate checks the field values on BulkShortenResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BulkShortenResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BulkShortenResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BulkShortenResponseMultiError, or nil if none found.
+func (m *BulkShortenResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BulkShortenResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for CorrelationId
+
+	// no validation rules for ShortUrl
+
+	if len(errors) > 0 {
+		return BulkShortenResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// BulkShortenResponseMultiError is an error wrapping multiple validation
+// errors returned by BulkShortenResponse.ValidateAll() if the designated
+// constraints aren't met.
+type BulkShortenResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BulkShortenResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BulkShortenResponseMultiError) AllErrors() []error { return m }
+
+// BulkShortenResponseValidationError is the validation error returned by
+// BulkShortenResponse.Validate if the designated constraints aren't met.
+type BulkShortenResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BulkShortenResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BulkShortenResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BulkShortenResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BulkShortenResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BulkShortenResponseValidationError) ErrorName() string {
+	return "BulkShortenResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BulkShortenResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBulkShortenResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BulkShortenResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BulkShortenResponseValidationError{}
+
+// Validate checks the field values on DeleteUrlsRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *DeleteUrlsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteUrlsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteUrlsRequestMultiError, or nil if none found.
+func (m *DeleteUrlsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteUrlsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(m.GetIds()) < 1 {
+		err := DeleteUrlsRequestValidationError{
+			field:  "Ids",
+			reason: "value must contain at least 1 item(s)",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetIds() {
+		_, _ = idx, item
+
+		if utf8.RuneCountInString(item) < 1 {
+			err := DeleteUrlsRequestValidationError{
+				field:  fmt.Sprintf("Ids[%v]", idx),
+				reason: "value length must be at least 1 runes",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return DeleteUrlsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteUrlsRequestMultiError is an error wrapping multiple validation errors
+// returned by DeleteUrlsRequest.ValidateAll() if the designated constraints
+// aren't met.
+type DeleteUrlsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteUrlsRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteUrlsRequestMultiError) AllErrors() []error { return m }
+
+// DeleteUrlsRequestValidationError is the validation error returned by
+// DeleteUrlsRequest.Validate if the designated constraints aren't met.
+type DeleteUrlsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteUrlsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteUrlsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteUrlsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteUrlsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteUrlsRequestValidationError) ErrorName() string {
+	return "DeleteUrlsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteUrlsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteUrlsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteUrlsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteUrlsRequestValidationError{}
+
+// Validate checks the field values on DeleteJob with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *DeleteJob) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteJob with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in DeleteJobMultiError, or nil
+// if none found.
+func (m *DeleteJob) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteJob) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for Status
+
+	// no validation rules for Error
+
+	// no validation rules for Attempts
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, DeleteJobValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, DeleteJobValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DeleteJobValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetUpdatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, DeleteJobValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, DeleteJobValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUpdatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DeleteJobValidationError{
+				field:  "UpdatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return DeleteJobMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteJobMultiError is an error wrapping multiple validation errors returned
+// by DeleteJob.ValidateAll() if the designated constraints aren't met.
+type DeleteJobMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteJobMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteJobMultiError) AllErrors() []error { return m }
+
+// DeleteJobValidationError is the validation error returned by
+// DeleteJob.Validate if the designated constraints aren't met.
+type DeleteJobValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteJobValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteJobValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteJobValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteJobValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteJobValidationError) ErrorName() string { return "DeleteJobValidationError" }
+
+// Error satisfies the builtin error interface
+func (e DeleteJobValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteJob.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteJobValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteJobValidationError{}
+
+// Validate checks the field values on DeleteUrlsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteUrlsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteUrlsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteUrlsResponseMultiError, or nil if none found.
+func (m *DeleteUrlsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteUrlsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetJob()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, DeleteUrlsResponseValidationError{
+					field:  "Job",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, DeleteUrlsResponseValidationError{
+					field:  "Job",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetJob()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DeleteUrlsResponseValidationError{
+				field:  "Job",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return DeleteUrlsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteUrlsResponseMultiError is an error wrapping multiple validation errors
+// returned by DeleteUrlsResponse.ValidateAll() if the designated constraints
+// aren't met.
+type DeleteUrlsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteUrlsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteUrlsResponseMultiError) AllErrors() []error { return m }
+
+// DeleteUrlsResponseValidationError is the validation error returned by
+// DeleteUrlsResponse.Validate if the designated constraints aren't met.
+type DeleteUrlsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteUrlsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteUrlsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteUrlsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteUrlsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteUrlsResponseValidationError) ErrorName() string {
+	return "DeleteUrlsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteUrlsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteUrlsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteUrlsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteUrlsResponseValidationError{}
+
+// Validate checks the field values on GetDeleteJobRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetDeleteJobRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetDeleteJobRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetDeleteJobRequestMultiError, or nil if none found.
+func (m *GetDeleteJobRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetDeleteJobRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetDeleteJobRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetDeleteJobRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetDeleteJobRequestMultiError is an error wrapping multiple validation
+// errors returned by GetDeleteJobRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetDeleteJobRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetDeleteJobRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetDeleteJobRequestMultiError) AllErrors() []error { return m }
+
+// GetDeleteJobRequestValidationError is the validation error returned by
+// GetDeleteJobRequest.Validate if the designated constraints aren't met.
+type GetDeleteJobRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetDeleteJobRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetDeleteJobRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetDeleteJobRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetDeleteJobRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetDeleteJobRequestValidationError) ErrorName() string {
+	return "GetDeleteJobRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetDeleteJobRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetDeleteJobRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetDeleteJobRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetDeleteJobRequestValidationError{}
+
+// Validate checks the field values on GetDeleteJobResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetDeleteJobResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetDeleteJobResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetDeleteJobResponseMultiError, or nil if none found.
+func (m *GetDeleteJobResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetDeleteJobResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetJob()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetDeleteJobResponseValidationError{
+					field:  "Job",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetDeleteJobResponseValidationError{
+					field:  "Job",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetJob()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetDeleteJobResponseValidationError{
+				field:  "Job",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return GetDeleteJobResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetDeleteJobResponseMultiError is an error wrapping multiple validation
+// errors returned by GetDeleteJobResponse.ValidateAll() if the designated
+// constraints aren't met.
+type GetDeleteJobResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetDeleteJobResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetDeleteJobResponseMultiError) AllErrors() []error { return m }
+
+// GetDeleteJobResponseValidationError is the validation error returned by
+// GetDeleteJobResponse.Validate if the designated constraints aren't met.
+type GetDeleteJobResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetDeleteJobResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetDeleteJobResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetDeleteJobResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetDeleteJobResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetDeleteJobResponseValidationError) ErrorName() string {
+	return "GetDeleteJobResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetDeleteJobResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetDeleteJobResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetDeleteJobResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetDeleteJobResponseValidationError{}
+
+// Validate checks the field values on GetUrlStatsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetUrlStatsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlStatsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetUrlStatsRequestMultiError, or nil if none found.
+func (m *GetUrlStatsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlStatsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetUrlStatsRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetUrlStatsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlStatsRequestMultiError is an error wrapping multiple validation errors
+// returned by GetUrlStatsRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetUrlStatsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlStatsRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlStatsRequestMultiError) AllErrors() []error { return m }
+
+// GetUrlStatsRequestValidationError is the validation error returned by
+// GetUrlStatsRequest.Validate if the designated constraints aren't met.
+type GetUrlStatsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlStatsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlStatsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlStatsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlStatsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlStatsRequestValidationError) ErrorName() string {
+	return "GetUrlStatsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetUrlStatsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlStatsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlStatsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlStatsRequestValidationError{}
+
+// Validate checks the field values on StatsPoint with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *StatsPoint) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StatsPoint with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in StatsPointMultiError, or
+// nil if none found.
+func (m *StatsPoint) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StatsPoint) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetTime()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, StatsPointValidationError{
+					field:  "Time",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, StatsPointValidationError{
+					field:  "Time",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetTime()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return StatsPointValidationError{
+				field:  "Time",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Clicks
+
+	if len(errors) > 0 {
+		return StatsPointMultiError(errors)
+	}
+
+	return nil
+}
+
+// StatsPointMultiError is an error wrapping multiple validation errors
+// returned by StatsPoint.ValidateAll() if the designated constraints aren't met.
+type StatsPointMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StatsPointMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StatsPointMultiError) AllErrors() []error { return m }
+
+// StatsPointValidationError is the validation error returned by
+// StatsPoint.Validate if the designated constraints aren't met.
+type StatsPointValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StatsPointValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StatsPointValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StatsPointValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StatsPointValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StatsPointValidationError) ErrorName() string { return "StatsPointValidationError" }
+
+// Error satisfies the builtin error interface
+func (e StatsPointValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStatsPoint.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StatsPointValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StatsPointValidationError{}
+
+// Validate checks the field values on GetUrlStatsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetUrlStatsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlStatsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetUrlStatsResponseMultiError, or nil if none found.
+func (m *GetUrlStatsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlStatsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ShortUrl
+
+	// no validation rules for TotalClicks
+
+	// no validation rules for UniqueVisitors
+
+	for idx, item := range m.GetHourly() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetUrlStatsResponseValidationError{
+						field:  fmt.Sprintf("Hourly[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetUrlStatsResponseValidationError{
+						field:  fmt.Sprintf("Hourly[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetUrlStatsResponseValidationError{
+					field:  fmt.Sprintf("Hourly[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	for idx, item := range m.GetDaily() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetUrlStatsResponseValidationError{
+						field:  fmt.Sprintf("Daily[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetUrlStatsResponseValidationError{
+						field:  fmt.Sprintf("Daily[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetUrlStatsResponseValidationError{
+					field:  fmt.Sprintf("Daily[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return GetUrlStatsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlStatsResponseMultiError is an error wrapping multiple validation
+// errors returned by GetUrlStatsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type GetUrlStatsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlStatsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlStatsResponseMultiError) AllErrors() []error { return m }
+
+// GetUrlStatsResponseValidationError is the validation error returned by
+// GetUrlStatsResponse.Validate if the designated constraints aren't met.
+type GetUrlStatsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlStatsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlStatsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlStatsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlStatsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlStatsResponseValidationError) ErrorName() string {
+	return "GetUrlStatsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetUrlStatsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlStatsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlStatsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlStatsResponseValidationError{}
+
+// Validate checks the field values on UpdateUrlRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *UpdateUrlRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateUrlRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateUrlRequestMultiError, or nil if none found.
+func (m *UpdateUrlRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateUrlRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := UpdateUrlRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetOriginalUrl()) > 2048 {
+		err := UpdateUrlRequestValidationError{
+			field:  "OriginalUrl",
+			reason: "value length must be at most 2048 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if uri, err := url.Parse(m.GetOriginalUrl()); err != nil {
+		err = UpdateUrlRequestValidationError{
+			field:  "OriginalUrl",
+			reason: "value must be a valid URI",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	} else if !uri.IsAbs() {
+		err := UpdateUrlRequestValidationError{
+			field:  "OriginalUrl",
+			reason: "value must be absolute",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return UpdateUrlRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateUrlRequestMultiError is an error wrapping multiple validation errors
+// returned by UpdateUrlRequest.ValidateAll() if the designated constraints
+// aren't met.
+type UpdateUrlRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateUrlRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateUrlRequestMultiError) AllErrors() []error { return m }
+
+// UpdateUrlRequestValidationError is the validation error returned by
+// UpdateUrlRequest.Validate if the designated constraints aren't met.
+type UpdateUrlRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateUrlRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateUrlRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateUrlRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateUrlRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateUrlRequestValidationError) ErrorName() string { return "UpdateUrlRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e UpdateUrlRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateUrlRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateUrlRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateUrlRequestValidationError{}
+
+// Validate checks the field values on UrlChange with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *UrlChange) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UrlChange with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in UrlChangeMultiError, or nil
+// if none found.
+func (m *UrlChange) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UrlChange) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ShortUrl
+
+	// no validation rules for PreviousUrl
+
+	// no validation rules for OriginalUrl
+
+	if all {
+		switch v := interface{}(m.GetChangedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, UrlChangeValidationError{
+					field:  "ChangedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, UrlChangeValidationError{
+					field:  "ChangedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetChangedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return UrlChangeValidationError{
+				field:  "ChangedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return UrlChangeMultiError(errors)
+	}
+
+	return nil
+}
+
+// UrlChangeMultiError is an error wrapping multiple validation errors returned
+// by UrlChange.ValidateAll() if the designated constraints aren't met.
+type UrlChangeMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UrlChangeMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UrlChangeMultiError) AllErrors() []error { return m }
+
+// UrlChangeValidationError is the validation error returned by
+// UrlChange.Validate if the designated constraints aren't met.
+type UrlChangeValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UrlChangeValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UrlChangeValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UrlChangeValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UrlChangeValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UrlChangeValidationError) ErrorName() string { return "UrlChangeValidationError" }
+
+// Error satisfies the builtin error interface
+func (e UrlChangeValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUrlChange.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UrlChangeValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UrlChangeValidationError{}
+
+// Validate checks the field values on UpdateUrlResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *UpdateUrlResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateUrlResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateUrlResponseMultiError, or nil if none found.
+func (m *UpdateUrlResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateUrlResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetChange()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, UpdateUrlResponseValidationError{
+					field:  "Change",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, UpdateUrlResponseValidationError{
+					field:  "Change",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetChange()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return UpdateUrlResponseValidationError{
+				field:  "Change",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return UpdateUrlResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateUrlResponseMultiError is an error wrapping multiple validation errors
+// returned by UpdateUrlResponse.ValidateAll() if the designated constraints
+// aren't met.
+type UpdateUrlResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateUrlResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateUrlResponseMultiError) AllErrors() []error { return m }
+
+// UpdateUrlResponseValidationError is the validation error returned by
+// UpdateUrlResponse.Validate if the designated constraints aren't met.
+type UpdateUrlResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateUrlResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateUrlResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateUrlResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateUrlResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateUrlResponseValidationError) ErrorName() string {
+	return "UpdateUrlResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateUrlResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateUrlResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateUrlResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateUrlResponseValidationError{}
+
+// Validate checks the field values on RestoreUrlRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *RestoreUrlRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RestoreUrlRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RestoreUrlRequestMultiError, or nil if none found.
+func (m *RestoreUrlRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RestoreUrlRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := RestoreUrlRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return RestoreUrlRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// RestoreUrlRequestMultiError is an error wrapping multiple validation errors
+// returned by RestoreUrlRequest.ValidateAll() if the designated constraints
+// aren't met.
+type RestoreUrlRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RestoreUrlRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RestoreUrlRequestMultiError) AllErrors() []error { return m }
+
+// RestoreUrlRequestValidationError is the validation error returned by
+// RestoreUrlRequest.Validate if the designated constraints aren't met.
+type RestoreUrlRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RestoreUrlRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RestoreUrlRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RestoreUrlRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RestoreUrlRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RestoreUrlRequestValidationError) ErrorName() string {
+	return "RestoreUrlRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RestoreUrlRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRestoreUrlRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RestoreUrlRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RestoreUrlRequestValidationError{}
+
+// Validate checks the field values on RestoreUrlResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *RestoreUrlResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RestoreUrlResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// RestoreUrlResponseMultiError, or nil if none found.
+func (m *RestoreUrlResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RestoreUrlResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return RestoreUrlResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// RestoreUrlResponseMultiError is an error wrapping multiple validation errors
+// returned by RestoreUrlResponse.ValidateAll() if the designated constraints
+// aren't met.
+type RestoreUrlResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RestoreUrlResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RestoreUrlResponseMultiError) AllErrors() []error { return m }
+
+// RestoreUrlResponseValidationError is the validation error returned by
+// RestoreUrlResponse.Validate if the designated constraints aren't met.
+type RestoreUrlResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RestoreUrlResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RestoreUrlResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RestoreUrlResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RestoreUrlResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RestoreUrlResponseValidationError) ErrorName() string {
+	return "RestoreUrlResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e RestoreUrlResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRestoreUrlResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RestoreUrlResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RestoreUrlResponseValidationError{}
+
+// Validate checks the field values on TransferUrlRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *TransferUrlRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on TransferUrlRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// TransferUrlRequestMultiError, or nil if none found.
+func (m *TransferUrlRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *TransferUrlRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := TransferUrlRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if err := m._validateEmail(m.GetEmail()); err != nil {
+		err = TransferUrlRequestValidationError{
+			field:  "Email",
+			reason: "value must be a valid email address",
+			cause:  err,
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return TransferUrlRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+func (m *TransferUrlRequest) _validateHostname(host string) error {
+	s := strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if len(host) > 253 {
+		return errors.New("hostname cannot exceed 253 characters")
+	}
+
+	for _, part := range strings.Split(s, ".") {
+		if l := len(part); l == 0 || l > 63 {
+			return errors.New("hostname part must be non-empty and cannot exceed 63 characters")
+		}
+
+		if part[0] == '-' {
+			return errors.New("hostname parts cannot begin with hyphens")
+		}
+
+		if part[len(part)-1] == '-' {
+			return errors.New("hostname parts cannot end with hyphens")
+		}
+
+		for _, r := range part {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return fmt.Errorf("hostname parts can only contain alphanumeric characters or hyphens, got %q", string(r))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *TransferUrlRequest) _validateEmail(addr string) error {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return err
+	}
+	addr = a.Address
+
+	if len(addr) > 254 {
+		return errors.New("email addresses cannot exceed 254 characters")
+	}
+
+	parts := strings.SplitN(addr, "@", 2)
+
+	if len(parts[0]) > 64 {
+		return errors.New("email address local phrase cannot exceed 64 characters")
+	}
+
+	return m._validateHostname(parts[1])
+}
+
+// TransferUrlRequestMultiError is an error wrapping multiple validation errors
+// returned by TransferUrlRequest.ValidateAll() if the designated constraints
+// aren't met.
+type TransferUrlRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m TransferUrlRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m TransferUrlRequestMultiError) AllErrors() []error { return m }
+
+// TransferUrlRequestValidationError is the validation error returned by
+// TransferUrlRequest.Validate if the designated constraints aren't met.
+type TransferUrlRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e TransferUrlRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e TransferUrlRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e TransferUrlRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e TransferUrlRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e TransferUrlRequestValidationError) ErrorName() string {
+	return "TransferUrlRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e TransferUrlRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sTransferUrlRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = TransferUrlRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = TransferUrlRequestValidationError{}
+
+// Validate checks the field values on TransferUrlResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *TransferUrlResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on TransferUrlResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// TransferUrlResponseMultiError, or nil if none found.
+func (m *TransferUrlResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *TransferUrlResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return TransferUrlResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// TransferUrlResponseMultiError is an error wrapping multiple validation
+// errors returned by TransferUrlResponse.ValidateAll() if the designated
+// constraints aren't met.
+type TransferUrlResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m TransferUrlResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m TransferUrlResponseMultiError) AllErrors() []error { return m }
+
+// TransferUrlResponseValidationError is the validation error returned by
+// TransferUrlResponse.Validate if the designated constraints aren't met.
+type TransferUrlResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e TransferUrlResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e TransferUrlResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e TransferUrlResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e TransferUrlResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e TransferUrlResponseValidationError) ErrorName() string {
+	return "TransferUrlResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e TransferUrlResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sTransferUrlResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = TransferUrlResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = TransferUrlResponseValidationError{}
+
+// Validate checks the field values on GetUrlHistoryRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetUrlHistoryRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlHistoryRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetUrlHistoryRequestMultiError, or nil if none found.
+func (m *GetUrlHistoryRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlHistoryRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetUrlHistoryRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetUrlHistoryRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlHistoryRequestMultiError is an error wrapping multiple validation
+// errors returned by GetUrlHistoryRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetUrlHistoryRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlHistoryRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlHistoryRequestMultiError) AllErrors() []error { return m }
+
+// GetUrlHistoryRequestValidationError is the validation error returned by
+// GetUrlHistoryRequest.Validate if the designated constraints aren't met.
+type GetUrlHistoryRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlHistoryRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlHistoryRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlHistoryRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlHistoryRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlHistoryRequestValidationError) ErrorName() string {
+	return "GetUrlHistoryRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetUrlHistoryRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlHistoryRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlHistoryRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlHistoryRequestValidationError{}
+
+// Validate checks the field values on GetUrlHistoryResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetUrlHistoryResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetUrlHistoryResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetUrlHistoryResponseMultiError, or nil if none found.
+func (m *GetUrlHistoryResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetUrlHistoryResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetChanges() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetUrlHistoryResponseValidationError{
+						field:  fmt.Sprintf("Changes[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetUrlHistoryResponseValidationError{
+						field:  fmt.Sprintf("Changes[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetUrlHistoryResponseValidationError{
+					field:  fmt.Sprintf("Changes[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return GetUrlHistoryResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetUrlHistoryResponseMultiError is an error wrapping multiple validation
+// errors returned by GetUrlHistoryResponse.ValidateAll() if the designated
+// constraints aren't met.
+type GetUrlHistoryResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetUrlHistoryResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetUrlHistoryResponseMultiError) AllErrors() []error { return m }
+
+// GetUrlHistoryResponseValidationError is the validation error returned by
+// GetUrlHistoryResponse.Validate if the designated constraints aren't met.
+type GetUrlHistoryResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetUrlHistoryResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetUrlHistoryResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetUrlHistoryResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetUrlHistoryResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetUrlHistoryResponseValidationError) ErrorName() string {
+	return "GetUrlHistoryResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetUrlHistoryResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetUrlHistoryResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetUrlHistoryResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetUrlHistoryResponseValidationError{}
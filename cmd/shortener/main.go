@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/fd239/go_url_shortener/config"
 	"github.com/fd239/go_url_shortener/internal/app/server"
@@ -24,6 +25,13 @@ func main() {
 		log.Fatalf("Init config error: %s", err.Error())
 	}
 
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err = runMigrate(args[1:]); err != nil {
+			log.Fatalf("Migrate error: %s", err.Error())
+		}
+		return
+	}
+
 	s, err := server.NewServer(config.Cfg.ServerAddress, config.Cfg.BaseURL, config.Cfg.UseTLS)
 	if err != nil {
 		log.Fatalf("Server start error: %s", err.Error())
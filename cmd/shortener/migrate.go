@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/migrate"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"os"
+	"text/tabwriter"
+)
+
+var errMigrateUsage = errors.New("usage: shortener [flags] migrate up|down|status")
+
+// runMigrate apply, roll back or list schema migrations of configured database
+func runMigrate(args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	if config.Cfg.DatabaseDSN == "" {
+		return errors.New("database DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", config.Cfg.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied migrations: %d\n", applied)
+	case "down":
+		rolledBack, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if !rolledBack {
+			fmt.Println("No applied migrations")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errMigrateUsage
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"github.com/caarlos0/env/v6"
 	"io/ioutil"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +16,60 @@ type Config struct {
 	UseTLS          bool   `env:"USE_TLS" envDefault:"false"`
 	JSONCfgFilePath string `env:"CONFIG" envDefault:"./config/cfg.json"`
 	TrustedSubnet   string `env:"TRUSTED_SUBNET" envDefault:""`
+	GRPCAddress     string `env:"GRPC_ADDRESS" envDefault:":9000"`
+
+	ShutdownTimeout     time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"10s"`
+	HealthCheckInterval time.Duration `env:"HEALTH_CHECK_INTERVAL" envDefault:"5s"`
+
+	DatabaseAutoMigrate bool `env:"DATABASE_AUTO_MIGRATE" envDefault:"true"`
+
+	FileSyncPolicy      string        `env:"FILE_SYNC_POLICY" envDefault:"interval"`
+	FileSyncInterval    time.Duration `env:"FILE_SYNC_INTERVAL" envDefault:"1s"`
+	FileCompactInterval time.Duration `env:"FILE_COMPACT_INTERVAL" envDefault:"10m"`
+
+	ShortIDMode   string `env:"SHORT_ID_MODE" envDefault:"hash"`
+	ShortIDLength int    `env:"SHORT_ID_LENGTH" envDefault:"8"`
+
+	ExpirySweepInterval time.Duration `env:"EXPIRY_SWEEP_INTERVAL" envDefault:"1m"`
+
+	ClickBufferSize    int           `env:"CLICK_BUFFER_SIZE" envDefault:"10000"`
+	ClickFlushInterval time.Duration `env:"CLICK_FLUSH_INTERVAL" envDefault:"1s"`
+
+	DeleteQueueSize     int           `env:"DELETE_QUEUE_SIZE" envDefault:"1000"`
+	DeleteWorkers       int           `env:"DELETE_WORKERS" envDefault:"4"`
+	DeleteBatchSize     int           `env:"DELETE_BATCH_SIZE" envDefault:"100"`
+	DeleteFlushInterval time.Duration `env:"DELETE_FLUSH_INTERVAL" envDefault:"100ms"`
+	DeleteJobsPath      string        `env:"DELETE_JOBS_PATH"` // pending delete jobs journal, see server deleteJobsPath
+
+	SessionTTL   time.Duration `env:"SESSION_TTL" envDefault:"720h"`
+	CookieSecure *bool         `env:"COOKIE_SECURE"` // unset means secure cookies only with TLS, see SecureCookies
+
+	// session token keys, server does not start without them. Keys are shared by all instances and kept
+	// across restarts, otherwise issued tokens and urls of anonymous users are lost
+	SecretKeys       string `env:"SECRET_KEYS"` // comma separated id:base64key list
+	SecretKeysFile   string `env:"SECRET_KEYS_FILE"`
+	SecretKeyPrimary string `env:"SECRET_KEY_PRIMARY"`
+
+	// rates are requests per second, zero rate disables the limit
+	ShortenUserRate   float64 `env:"RATE_LIMIT_SHORTEN_USER" envDefault:"5"`
+	ShortenUserBurst  int     `env:"RATE_LIMIT_SHORTEN_USER_BURST" envDefault:"20"`
+	ShortenIPRate     float64 `env:"RATE_LIMIT_SHORTEN_IP" envDefault:"10"`
+	ShortenIPBurst    int     `env:"RATE_LIMIT_SHORTEN_IP_BURST" envDefault:"50"`
+	RedirectUserRate  float64 `env:"RATE_LIMIT_REDIRECT_USER" envDefault:"50"`
+	RedirectUserBurst int     `env:"RATE_LIMIT_REDIRECT_USER_BURST" envDefault:"100"`
+	RedirectIPRate    float64 `env:"RATE_LIMIT_REDIRECT_IP" envDefault:"100"`
+	RedirectIPBurst   int     `env:"RATE_LIMIT_REDIRECT_IP_BURST" envDefault:"200"`
+
+	// reverse proxies allowed to set X-Forwarded-For and X-Real-IP, CIDRs or single addresses
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+
+	BlocklistFile            string        `env:"BLOCKLIST_FILE"`
+	BlocklistReloadInterval  time.Duration `env:"BLOCKLIST_RELOAD_INTERVAL" envDefault:"30s"`
+	ShortenerDomains         []string      `env:"SHORTENER_DOMAINS" envSeparator:"," envDefault:"bit.ly,tinyurl.com,t.co,goo.gl,ow.ly,is.gd"`
+	ShortenerMaxDepth        int           `env:"SHORTENER_MAX_DEPTH" envDefault:"2"`
+	ShortenerResolveTimeout  time.Duration `env:"SHORTENER_RESOLVE_TIMEOUT" envDefault:"3s"`
+	ShortenerResolveCacheTTL time.Duration `env:"SHORTENER_RESOLVE_CACHE_TTL" envDefault:"10m"`
+	ShortenerMaxResolves     int           `env:"SHORTENER_MAX_RESOLVES" envDefault:"8"`
 }
 
 type JSONConfig struct {
@@ -24,11 +79,21 @@ type JSONConfig struct {
 	DatabaseDSN     string `json:"database-dsn"`
 	UseTLS          bool   `json:"use_tls"`
 	TrustedSubnet   string `json:"trusted_subnet"`
+	GRPCAddress     string `json:"grpc_address"`
+	DeleteJobsPath  string `json:"delete_jobs_path"`
 }
 
 var Cfg Config
 
-var SecretKey = []byte("passphrasewhichneedstobe32bytes!")
+// SecureCookies whether session cookies are sent with Secure flag. Clients never return secure cookies over
+// plain http, so unless configured explicitly cookies are secure only when server uses TLS
+func (c Config) SecureCookies() bool {
+	if c.CookieSecure != nil {
+		return *c.CookieSecure
+	}
+
+	return c.UseTLS
+}
 
 func InitConfig() error {
 
@@ -45,6 +110,7 @@ func InitConfig() error {
 	UseTLS := flag.Bool("s", Cfg.UseTLS, "TLS server")
 	JSONCfgFilePath := flag.String("c", Cfg.JSONCfgFilePath, "JSON config")
 	trustedSubnet := flag.String("t", Cfg.TrustedSubnet, "Trusted subnet")
+	grpcAddress := flag.String("g", Cfg.GRPCAddress, "gRPC server address")
 
 	flag.Parse()
 
@@ -55,6 +121,7 @@ func InitConfig() error {
 	Cfg.UseTLS = *UseTLS
 	Cfg.JSONCfgFilePath = *JSONCfgFilePath
 	Cfg.TrustedSubnet = *trustedSubnet
+	Cfg.GRPCAddress = *grpcAddress
 
 	if Cfg.JSONCfgFilePath == "" {
 		return nil
@@ -97,5 +164,13 @@ func InitConfig() error {
 		Cfg.TrustedSubnet = jsonCfg.TrustedSubnet
 	}
 
+	if Cfg.GRPCAddress == "" {
+		Cfg.GRPCAddress = jsonCfg.GRPCAddress
+	}
+
+	if Cfg.DeleteJobsPath == "" {
+		Cfg.DeleteJobsPath = jsonCfg.DeleteJobsPath
+	}
+
 	return nil
 }
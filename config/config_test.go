@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/caarlos0/env/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestConfig_SecureCookies(t *testing.T) {
+	tests := []struct {
+		name         string
+		useTLS       string
+		cookieSecure string
+		want         bool
+	}{
+		{name: "Default", want: false},
+		{name: "TLS", useTLS: "true", want: true},
+		{name: "Explicit without TLS", cookieSecure: "true", want: true},
+		{name: "Explicit insecure with TLS", useTLS: "true", cookieSecure: "false", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("USE_TLS", tt.useTLS)
+			t.Setenv("COOKIE_SECURE", tt.cookieSecure)
+
+			var cfg Config
+			require.NoError(t, env.Parse(&cfg))
+			assert.Equal(t, tt.want, cfg.SecureCookies())
+		})
+	}
+}
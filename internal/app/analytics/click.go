@@ -0,0 +1,117 @@
+package analytics
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ipv6PrefixBits kept part of IPv6 address
+const ipv6PrefixBits = 48
+
+// AnonymizeIP mask host part of address: last octet of IPv4, all but /48 prefix of IPv6.
+// Unparsable addresses give empty string
+func AnonymizeIP(addr string) string {
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil {
+		return ""
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return ip.Mask(net.CIDRMask(ipv6PrefixBits, 128)).String()
+}
+
+// TrustedProxies networks of reverse proxies whose forwarding headers are trusted.
+// Forwarding headers sent by other clients are ignored
+var TrustedProxies []*net.IPNet
+
+// ParseProxies parse trusted proxies list of CIDRs or single addresses
+func ParseProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// trustedProxy address belongs to trusted proxy
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range TrustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForwardedIP client address of X-Forwarded-For chain: the rightmost address which is not a trusted proxy.
+// Leftmost address is returned when whole chain is trusted
+func ForwardedIP(forwarded string) string {
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i > 0; i-- {
+		if hop := strings.TrimSpace(hops[i]); !trustedProxy(hop) {
+			return hop
+		}
+	}
+
+	return strings.TrimSpace(hops[0])
+}
+
+// ClientIP address of request client. Proxy headers are used only when connection comes from trusted proxy
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !trustedProxy(host) {
+		return host
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return ForwardedIP(forwarded)
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+
+	return host
+}
+
+// NewClick redirect of short url made by request, with anonymized client IP
+func NewClick(id string, r *http.Request) storage.Click {
+	return storage.Click{
+		ShortURL:  id,
+		Time:      time.Now().UTC(),
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+		IP:        AnonymizeIP(ClientIP(r)),
+	}
+}
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want string
+	}{
+		{name: "IPv4", addr: "192.168.10.42", want: "192.168.10.0"},
+		{name: "IPv6", addr: "2001:db8:85a3:8d3:1319:8a2e:370:7348", want: "2001:db8:85a3::"},
+		{name: "IPv4 mapped IPv6", addr: "::ffff:10.0.0.7", want: "10.0.0.0"},
+		{name: "Invalid", addr: "unknown", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AnonymizeIP(tt.addr))
+		})
+	}
+}
+
+func TestNewClick(t *testing.T) {
+	r := httptest.NewRequest("GET", "/id", nil)
+	r.RemoteAddr = "10.1.2.3:5555"
+	r.Header.Set("Referer", "https://example.com")
+	r.Header.Set("User-Agent", "test-agent")
+
+	click := NewClick("id", r)
+	assert.Equal(t, "id", click.ShortURL)
+	assert.Equal(t, "https://example.com", click.Referrer)
+	assert.Equal(t, "test-agent", click.UserAgent)
+	assert.Equal(t, "10.1.2.0", click.IP)
+	assert.False(t, click.Time.IsZero())
+
+	r.Header.Set("X-Forwarded-For", "172.16.5.4, 10.1.2.3")
+	assert.Equal(t, "10.1.2.0", NewClick("id", r).IP)
+
+	TrustedProxies, _ = ParseProxies([]string{"10.0.0.0/8"})
+	defer func() { TrustedProxies = nil }()
+	assert.Equal(t, "172.16.5.0", NewClick("id", r).IP)
+}
+
+func TestParseProxies(t *testing.T) {
+	nets, err := ParseProxies([]string{"10.0.0.0/8", " 192.168.1.1", "::1", ""})
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	assert.Equal(t, "10.0.0.0/8", nets[0].String())
+	assert.Equal(t, "192.168.1.1/32", nets[1].String())
+	assert.Equal(t, "::1/128", nets[2].String())
+
+	_, err = ParseProxies([]string{"not-a-network"})
+	assert.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	TrustedProxies, _ = ParseProxies([]string{"10.0.0.0/8"})
+	defer func() { TrustedProxies = nil }()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{name: "Direct client", remoteAddr: "203.0.113.7:5555", want: "203.0.113.7"},
+		{name: "Spoofed forwarded header", remoteAddr: "203.0.113.7:5555", forwarded: "1.2.3.4", want: "203.0.113.7"},
+		{name: "Spoofed real ip header", remoteAddr: "203.0.113.7:5555", realIP: "1.2.3.4", want: "203.0.113.7"},
+		{name: "Trusted proxy", remoteAddr: "10.0.0.1:5555", forwarded: "203.0.113.7", want: "203.0.113.7"},
+		{name: "Trusted proxy chain", remoteAddr: "10.0.0.1:5555", forwarded: "203.0.113.7, 10.0.0.2", want: "203.0.113.7"},
+		{name: "Client prepended address", remoteAddr: "10.0.0.1:5555", forwarded: "1.2.3.4, 203.0.113.7", want: "203.0.113.7"},
+		{name: "Trusted proxy real ip", remoteAddr: "10.0.0.1:5555", realIP: "203.0.113.7", want: "203.0.113.7"},
+		{name: "Trusted proxy without headers", remoteAddr: "10.0.0.1:5555", want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/id", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, ClientIP(r))
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultBufferSize clicks waiting to be saved before new ones are dropped
+	DefaultBufferSize = 10000
+	// DefaultBatchSize clicks saved to storage at once
+	DefaultBatchSize = 100
+	// DefaultFlushInterval longest time a click waits in a partial batch
+	DefaultFlushInterval = time.Second
+)
+
+// ClickSaver storage of recorded clicks
+type ClickSaver interface {
+	SaveClicks(clicks []storage.Click) error
+}
+
+// Options recorder buffering settings, zero values mean defaults
+type Options struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Recorder saves clicks in background so redirects never wait for storage
+type Recorder struct {
+	saver     ClickSaver
+	clicks    chan storage.Click
+	batchSize int
+	interval  time.Duration
+	dropped   uint64
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewRecorder create recorder and start background saving
+func NewRecorder(saver ClickSaver, opts Options) *Recorder {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+
+	r := &Recorder{
+		saver:     saver,
+		clicks:    make(chan storage.Click, opts.BufferSize),
+		batchSize: opts.BatchSize,
+		interval:  opts.FlushInterval,
+		done:      make(chan struct{}),
+	}
+	go r.run()
+
+	return r
+}
+
+// Record queue click without blocking. Click is dropped if buffer is full
+func (r *Recorder) Record(click storage.Click) {
+	select {
+	case r.clicks <- click:
+	default:
+		if atomic.AddUint64(&r.dropped, 1)%1000 == 1 {
+			log.Printf("Click buffer is full, clicks dropped: %d", atomic.LoadUint64(&r.dropped))
+		}
+	}
+}
+
+// Dropped number of clicks lost because buffer was full
+func (r *Recorder) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
+
+// run save clicks by batches until recorder is closed
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]storage.Click, 0, r.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.saver.SaveClicks(batch); err != nil {
+			log.Printf("Clicks save error, %d clicks lost: %v", len(batch), err)
+		}
+		batch = make([]storage.Click, 0, r.batchSize)
+	}
+
+	for {
+		select {
+		case click, ok := <-r.clicks:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close stop accepting clicks and save buffered ones. Record must not be called after Close
+func (r *Recorder) Close() {
+	r.closeOnce.Do(func() {
+		close(r.clicks)
+	})
+	<-r.done
+}
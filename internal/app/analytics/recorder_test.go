@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSaver struct {
+	mu      sync.Mutex
+	batches [][]storage.Click
+	block   chan struct{}
+}
+
+func (s *testSaver) SaveClicks(clicks []storage.Click) error {
+	if s.block != nil {
+		<-s.block
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, clicks)
+	return nil
+}
+
+func (s *testSaver) saved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, batch := range s.batches {
+		count += len(batch)
+	}
+	return count
+}
+
+func TestRecorder_CloseFlushes(t *testing.T) {
+	saver := &testSaver{}
+	r := NewRecorder(saver, Options{BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		r.Record(storage.Click{ShortURL: "id"})
+	}
+	r.Close()
+
+	assert.Equal(t, 5, saver.saved())
+	assert.Len(t, saver.batches, 3)
+	assert.Zero(t, r.Dropped())
+}
+
+func TestRecorder_FlushInterval(t *testing.T) {
+	saver := &testSaver{}
+	r := NewRecorder(saver, Options{FlushInterval: 10 * time.Millisecond})
+	defer r.Close()
+
+	r.Record(storage.Click{ShortURL: "id"})
+
+	assert.Eventually(t, func() bool {
+		return saver.saved() == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRecorder_DropsWhenFull(t *testing.T) {
+	saver := &testSaver{block: make(chan struct{})}
+	r := NewRecorder(saver, Options{BufferSize: 1, BatchSize: 1})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			r.Record(storage.Click{ShortURL: "id"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked on full buffer")
+	}
+	assert.NotZero(t, r.Dropped())
+
+	close(saver.block)
+	r.Close()
+	assert.Equal(t, uint64(10), uint64(saver.saved())+r.Dropped())
+}
@@ -0,0 +1,118 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"strings"
+	"time"
+)
+
+const (
+	// ScopeRead list user urls, jobs and stats
+	ScopeRead = "read"
+	// ScopeWrite shorten urls
+	ScopeWrite = "write"
+	// ScopeDelete delete user urls
+	ScopeDelete = "delete"
+)
+
+const (
+	// Prefix marks api keys, so leaked ones are easy to find in logs and code
+	Prefix = "usk_"
+	// displayLength characters of key kept in clear to tell keys apart in listings
+	displayLength = len(Prefix) + 6
+	// bearerScheme authorization header scheme carrying api key
+	bearerScheme = "Bearer "
+)
+
+// AllScopes scopes of key created without explicit scopes
+var AllScopes = []string{ScopeRead, ScopeWrite, ScopeDelete}
+
+// Hash key digest stored instead of the key. Keys are random, so plain SHA-256 is enough
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// New generate api key of user. Returned key is shown to the user once, only its hash is stored
+func New(userID string, name string, scopes []string, now time.Time) (storage.APIKey, string, error) {
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return storage.APIKey{}, "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return storage.APIKey{}, "", err
+	}
+	id := make([]byte, 8)
+	if _, err = rand.Read(id); err != nil {
+		return storage.APIKey{}, "", err
+	}
+
+	key := Prefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return storage.APIKey{
+		ID:        hex.EncodeToString(id),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:displayLength],
+		Hash:      Hash(key),
+		Scopes:    scopes,
+		CreatedAt: now,
+	}, key, nil
+}
+
+// NormalizeScopes check requested scopes and remove duplicates. No scopes means all of them
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string(nil), AllScopes...), nil
+	}
+
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeRead, ScopeWrite, ScopeDelete:
+			requested[scope] = true
+		default:
+			return nil, common.ErrInvalidScope
+		}
+	}
+
+	normalized := make([]string, 0, len(requested))
+	for _, scope := range AllScopes {
+		if requested[scope] {
+			normalized = append(normalized, scope)
+		}
+	}
+
+	return normalized, nil
+}
+
+// Allowed check scopes grant access to scope. Nil scopes belong to user session which may do anything
+func Allowed(scopes []string, scope string) bool {
+	if scopes == nil {
+		return true
+	}
+
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FromHeader api key of "Bearer" authorization header value
+func FromHeader(value string) (string, bool) {
+	if len(value) <= len(bearerScheme) || !strings.EqualFold(value[:len(bearerScheme)], bearerScheme) {
+		return "", false
+	}
+
+	return strings.TrimSpace(value[len(bearerScheme):]), true
+}
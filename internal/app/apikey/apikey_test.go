@@ -0,0 +1,81 @@
+package apikey
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	key, secret, err := New("user", "ci", nil, now)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, Prefix))
+	assert.True(t, strings.HasPrefix(secret, key.Prefix))
+	assert.Equal(t, Hash(secret), key.Hash)
+	assert.NotContains(t, key.Hash, secret)
+	assert.Equal(t, AllScopes, key.Scopes)
+	assert.Equal(t, now, key.CreatedAt)
+
+	other, otherSecret, err := New("user", "ci", nil, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, key.ID, other.ID)
+	assert.NotEqual(t, secret, otherSecret)
+
+	_, _, err = New("user", "ci", []string{"admin"}, now)
+	assert.ErrorIs(t, err, common.ErrInvalidScope)
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr error
+	}{
+		{name: "All by default", scopes: nil, want: AllScopes},
+		{name: "Ordered without duplicates", scopes: []string{ScopeDelete, ScopeRead, ScopeDelete}, want: []string{ScopeRead, ScopeDelete}},
+		{name: "Unknown scope", scopes: []string{ScopeRead, "admin"}, wantErr: common.ErrInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeScopes(tt.scopes)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	assert.True(t, Allowed(nil, ScopeDelete), "user session may do anything")
+	assert.True(t, Allowed([]string{ScopeRead, ScopeWrite}, ScopeWrite))
+	assert.False(t, Allowed([]string{ScopeRead}, ScopeDelete))
+	assert.False(t, Allowed([]string{ScopeRead}, ""))
+}
+
+func TestFromHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: "Bearer usk_abc", want: "usk_abc", ok: true},
+		{header: "bearer usk_abc ", want: "usk_abc", ok: true},
+		{header: "Basic dXNlcjpwYXNz"},
+		{header: "Bearer "},
+		{header: "usk_abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, ok := FromHeader(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -9,8 +9,38 @@ var (
 	ErrUserCookie          = errors.New("no user cookie")
 	ErrNoUserURLs          = errors.New("no user URLs")
 	ErrPing                = errors.New("database ping error")
-	ErrOriginalURLConflict = errors.New("original url postgresql save conflict")
+	ErrOriginalURLConflict = errors.New("original url is already shortened")
 	ErrResponseEncode      = errors.New("response encode error")
 	ErrGzipRead            = errors.New("gzip read error")
 	ErrURLDeleted          = errors.New("url deleted")
+	ErrShortIDCollision    = errors.New("unable to generate unique short url")
+	ErrInvalidAlias        = errors.New("alias must be 3-50 letters, digits, '-' or '_' and not a reserved word")
+	ErrAliasConflict       = errors.New("alias is already taken")
+	ErrInvalidExpiry       = errors.New("expiry must be either positive ttl or future expires_at")
+	ErrURLExpired          = errors.New("url expired")
+	ErrJobNotFound         = errors.New("job not found")
+	ErrInvalidCursor       = errors.New("invalid page cursor")
+	ErrInvalidSort         = errors.New("sort must be created_at or clicks, prefixed with '-' for descending order")
+	ErrInvalidPageSize     = errors.New("limit must be a number from 1 to 1000")
+	ErrInvalidAccount      = errors.New("email must be valid and password 8-72 characters long")
+	ErrAccountExists       = errors.New("account already exists")
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrAlreadyRegistered   = errors.New("user is already registered")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidAPIKey       = errors.New("invalid api key")
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidScope        = errors.New("api key scopes must be read, write or delete")
+	ErrScopeDenied         = errors.New("api key scopes do not allow this request")
+	ErrSessionRequired     = errors.New("request must be made by user session, not api key")
+	ErrRateLimited         = errors.New("too many requests, retry later")
+	ErrUntrustedNetwork    = errors.New("client is not in trusted subnet")
+	ErrRouteNotFound       = errors.New("route not found")
+	ErrMethodNotAllowed    = errors.New("method not allowed")
+	ErrInvalidRequest      = errors.New("invalid request")
+	ErrInvalidURL          = errors.New("url must be absolute http or https url with host")
+	ErrURLDisabled         = errors.New("url disabled by moderator")
+	ErrURLLegal            = errors.New("url unavailable for legal reasons")
+	ErrBlockedURL          = errors.New("url destination is blocked")
+	ErrSelfReference       = errors.New("url points to this shortener")
+	ErrRedirectChain       = errors.New("url redirects through too many shorteners")
 )
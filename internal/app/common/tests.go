@@ -3,6 +3,6 @@ package common
 const (
 	//TEST CONSTS
 	TestURL     = "http://cjdr17afeihmk.biz/kdni9/z9womotrbk"
-	TestShortID = "a7a40cddf446bc419af5737fc92f1757"
+	TestShortID = "5X2HT0AB"
 	TestDBName  = "TEST_DB.txt"
 )
@@ -3,69 +3,301 @@ package crypt
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/fd239/go_url_shortener/config"
+	"io/ioutil"
 	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 )
 
-var CryptURL *CipherCrypt
+const (
+	// tokenVersion prefix of tokens sealed with random nonce by key named in token
+	tokenVersion = "v1"
+	// LegacyKeyID key opening unversioned hex tokens sealed with nonce taken from the key tail
+	LegacyKeyID = "legacy"
+	// ephemeralKeyID random key used by tests which do not configure keys, tokens do not survive restart
+	ephemeralKeyID = "ephemeral"
+)
+
+var (
+	ErrMalformedToken = errors.New("malformed token")
+	ErrUnknownKey     = errors.New("token key is not configured")
+	ErrNoPrimaryKey   = errors.New("primary key is not configured")
+	ErrNoKeys         = errors.New("secret keys are not configured, set SECRET_KEYS or SECRET_KEYS_FILE")
+)
 
-// CipherCrypt struct for Decrypting
-type CipherCrypt struct {
-	nonce  []byte
-	aesGCM cipher.AEAD
+// keyIDPattern key IDs are sent in tokens, so they are limited to URL safe characters without dots
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Keyring AES-GCM keys by ID. Tokens are sealed by primary key, any key of the ring opens them,
+// so keys are rotated by adding new primary key and keeping the previous one until its tokens are refreshed
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+	legacy  []byte
 }
 
-func Decrypt(userID string) (string, error) {
-	err := initCrypt()
-	if err != nil {
-		return "", err
+// keysFile JSON file with base64 encoded keys by ID
+type keysFile struct {
+	Primary string            `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+var (
+	mu   sync.RWMutex
+	ring *Keyring
+)
+
+// NewKeyring create ring of 16, 24 or 32 bytes long AES keys. Legacy key is only used to open old tokens
+func NewKeyring(keys map[string][]byte, primary string) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		k.keys[id], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+
+		if id == LegacyKeyID {
+			k.legacy = key
+		}
 	}
-	b, err := hex.DecodeString(userID)
-	if err != nil {
-		log.Printf("Decrypt decode string error: %v", err)
+
+	if _, ok := k.keys[primary]; !ok || primary == LegacyKeyID {
+		return nil, ErrNoPrimaryKey
+	}
+
+	return k, nil
+}
+
+// Seal encrypt value by primary key with random nonce. Version and key ID are authenticated with the value
+func (k *Keyring) Seal(value []byte) (string, error) {
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
 		return "", err
 	}
 
-	decrypted, terr := CryptURL.aesGCM.Open(nil, CryptURL.nonce, b, nil)
+	header := tokenVersion + "." + k.primary
+	sealed := aead.Seal(nonce, nonce, value, []byte(header))
 
-	if terr != nil {
-		log.Printf("aesGCM open error: %v", terr)
+	return header + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypt token sealed by any key of the ring. Stale token is sealed by non-primary key or
+// in legacy format and should be sealed again
+func (k *Keyring) Open(token string) (value []byte, stale bool, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) == 1 {
+		value, err = k.openLegacy(token)
+		return value, true, err
+	}
+	if len(parts) != 3 || parts[0] != tokenVersion {
+		return nil, false, ErrMalformedToken
 	}
 
-	return string(decrypted), err
+	aead, ok := k.keys[parts[1]]
+	if !ok {
+		return nil, false, ErrUnknownKey
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, false, ErrMalformedToken
+	}
 
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	value, err = aead.Open(nil, nonce, ciphertext, []byte(parts[0]+"."+parts[1]))
+	if err != nil {
+		return nil, false, ErrMalformedToken
+	}
+
+	return value, parts[1] != k.primary, nil
 }
 
-func Encrypt(userID string) (string, error) {
-	err := initCrypt()
+// openLegacy decrypt unversioned hex token of the legacy key
+func (k *Keyring) openLegacy(token string) ([]byte, error) {
+	if k.legacy == nil {
+		return nil, ErrUnknownKey
+	}
+
+	ciphertext, err := hex.DecodeString(token)
 	if err != nil {
-		return "", err
+		return nil, ErrMalformedToken
+	}
+
+	aead := k.keys[LegacyKeyID]
+	value, err := aead.Open(nil, k.legacy[len(k.legacy)-aead.NonceSize():], ciphertext, nil)
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return value, nil
+}
+
+// LoadKeyring read keys from "id:base64key" comma separated list and from JSON keys file.
+// Primary key defaults to the primary of keys file or the first listed key
+func LoadKeyring(list string, path string, primary string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	var order []string
+
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var file keysFile
+		if err = json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("keys file: %w", err)
+		}
+
+		ids := make([]string, 0, len(file.Keys))
+		for id := range file.Keys {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if file.Primary != "" {
+			order = append(order, file.Primary)
+		}
+
+		for _, id := range ids {
+			if keys[id], err = base64.StdEncoding.DecodeString(file.Keys[id]); err != nil {
+				return nil, fmt.Errorf("key %s: %w", id, err)
+			}
+			order = append(order, id)
+		}
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("key %q must be in id:base64key form", entry)
+		}
+
+		id := parts[0]
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		keys[id] = key
+		order = append(order, id)
 	}
 
-	encrypted := CryptURL.aesGCM.Seal(nil, CryptURL.nonce, []byte(userID), nil)
-	return hex.EncodeToString(encrypted), nil
+	if primary == "" {
+		for _, id := range order {
+			if id != LegacyKeyID {
+				primary = id
+				break
+			}
+		}
+	}
 
+	return NewKeyring(keys, primary)
 }
 
-func initCrypt() error {
-	aesblock, err := aes.NewCipher(config.SecretKey)
+// Init load keys configured by env or keys file. Keys are required: random key would invalidate all tokens
+// on every restart and on every other instance, so anonymous users would lose their urls
+func Init() error {
+	if config.Cfg.SecretKeys == "" && config.Cfg.SecretKeysFile == "" {
+		return ErrNoKeys
+	}
+
+	k, err := load()
 	if err != nil {
 		return err
 	}
 
-	aesgcm, err := cipher.NewGCM(aesblock)
+	SetKeyring(k)
+	return nil
+}
+
+// load keyring by config. Without configured keys, which is only possible when Init is not called, random key is used
+func load() (*Keyring, error) {
+	if config.Cfg.SecretKeys != "" || config.Cfg.SecretKeysFile != "" {
+		return LoadKeyring(config.Cfg.SecretKeys, config.Cfg.SecretKeysFile, config.Cfg.SecretKeyPrimary)
+	}
+
+	log.Println("WARNING: no secret keys configured, tokens are sealed by random key and are invalid after restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return NewKeyring(map[string][]byte{ephemeralKeyID: key}, ephemeralKeyID)
+}
+
+// SetKeyring replace keys used by Encrypt and Decrypt
+func SetKeyring(k *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ring = k
+}
+
+// keyring current keys, loaded on first use
+func keyring() (*Keyring, error) {
+	mu.RLock()
+	k := ring
+	mu.RUnlock()
+	if k != nil {
+		return k, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if ring == nil {
+		var err error
+		if ring, err = load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ring, nil
+}
+
+// Encrypt seal value by primary key
+func Encrypt(value string) (string, error) {
+	k, err := keyring()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	nonce := config.SecretKey[len(config.SecretKey)-aesgcm.NonceSize():]
+	return k.Seal([]byte(value))
+}
 
-	CryptURL = &CipherCrypt{
-		aesGCM: aesgcm,
-		nonce:  nonce,
+// Decrypt open token sealed by any configured key. Stale token should be encrypted again
+func Decrypt(token string) (value string, stale bool, err error) {
+	k, err := keyring()
+	if err != nil {
+		return "", false, err
 	}
 
-	return nil
+	b, stale, err := k.Open(token)
+	if err != nil {
+		return "", false, err
+	}
 
+	return string(b), stale, nil
 }
@@ -0,0 +1,133 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	oldKey    = []byte("passphrasewhichneedstobe32bytes!")
+	newKey    = []byte("0123456789abcdef0123456789abcdef")
+	legacyKey = []byte("legacy-key-which-is-32-bytes-lon")
+)
+
+// legacyToken token in unversioned format sealed with nonce taken from the key tail
+func legacyToken(t *testing.T, key []byte, value string) string {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	return hex.EncodeToString(aead.Seal(nil, key[len(key)-aead.NonceSize():], []byte(value), nil))
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	old, err := NewKeyring(map[string][]byte{"k1": oldKey}, "k1")
+	require.NoError(t, err)
+	rotated, err := NewKeyring(map[string][]byte{"k1": oldKey, "k2": newKey, LegacyKeyID: legacyKey}, "k2")
+	require.NoError(t, err)
+	retired, err := NewKeyring(map[string][]byte{"k2": newKey}, "k2")
+	require.NoError(t, err)
+
+	first, err := old.Seal([]byte("user"))
+	require.NoError(t, err)
+	second, err := old.Seal([]byte("user"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "every token has its own nonce")
+	assert.True(t, strings.HasPrefix(first, "v1.k1."))
+
+	fresh, err := rotated.Seal([]byte("user"))
+	require.NoError(t, err)
+	parts := strings.Split(fresh, ".")
+
+	tests := []struct {
+		name      string
+		ring      *Keyring
+		token     string
+		wantStale bool
+		wantErr   error
+	}{
+		{name: "Primary key", ring: old, token: first},
+		{name: "Rotated key is stale", ring: rotated, token: first, wantStale: true},
+		{name: "Retired key", ring: retired, token: first, wantErr: ErrUnknownKey},
+		{name: "Legacy token is stale", ring: rotated, token: legacyToken(t, legacyKey, "user"), wantStale: true},
+		{name: "Legacy key not configured", ring: old, token: legacyToken(t, legacyKey, "user"), wantErr: ErrUnknownKey},
+		{name: "Key ID swapped", ring: rotated, token: "v1.k1." + parts[2], wantErr: ErrMalformedToken},
+		{name: "Unknown version", ring: rotated, token: "v2." + parts[1] + "." + parts[2], wantErr: ErrMalformedToken},
+		{name: "Truncated", ring: rotated, token: "v1.k2.AAAA", wantErr: ErrMalformedToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, stale, err := tt.ring.Open(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user", string(value))
+			assert.Equal(t, tt.wantStale, stale)
+		})
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	encode := base64.StdEncoding.EncodeToString
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"primary":"k2","keys":{"k1":"`+encode(oldKey)+`","k2":"`+encode(newKey)+`"}}`), 0600))
+
+	tests := []struct {
+		name        string
+		list        string
+		path        string
+		primary     string
+		wantPrimary string
+		wantErr     bool
+	}{
+		{name: "List", list: "legacy:" + encode(legacyKey) + ", k1:" + encode(oldKey), wantPrimary: "k1"},
+		{name: "List with primary", list: "k1:" + encode(oldKey) + ",k2:" + encode(newKey), primary: "k2", wantPrimary: "k2"},
+		{name: "File", path: path, wantPrimary: "k2"},
+		{name: "File and list", path: path, list: "k3:" + encode(newKey), primary: "k3", wantPrimary: "k3"},
+		{name: "Only legacy key", list: "legacy:" + encode(legacyKey), wantErr: true},
+		{name: "Unknown primary", list: "k1:" + encode(oldKey), primary: "k2", wantErr: true},
+		{name: "Short key", list: "k1:" + encode([]byte("short")), wantErr: true},
+		{name: "No key ID", list: encode(oldKey), wantErr: true},
+		{name: "Key ID with dot", list: "k.1:" + encode(oldKey), wantErr: true},
+		{name: "Missing file", path: path + ".missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := LoadKeyring(tt.list, tt.path, tt.primary)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPrimary, k.primary)
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	defer SetKeyring(nil)
+	defer func() { config.Cfg.SecretKeys = "" }()
+
+	config.Cfg.SecretKeys = ""
+	assert.ErrorIs(t, Init(), ErrNoKeys, "random key is never used by server")
+
+	config.Cfg.SecretKeys = "k1:" + base64.StdEncoding.EncodeToString(oldKey)
+	require.NoError(t, Init())
+	token, err := Encrypt("user")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, tokenVersion+".k1."))
+}
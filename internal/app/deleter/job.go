@@ -0,0 +1,178 @@
+package deleter
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"os"
+	"time"
+)
+
+// Job states
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Job user request to delete short urls, processed in background
+type Job struct {
+	ID        string                `json:"id"`
+	UserID    string                `json:"user_id"`
+	IDs       []string              `json:"ids"`
+	Status    string                `json:"status"`
+	Result    *storage.DeleteResult `json:"result,omitempty"`
+	Error     string                `json:"error,omitempty"`
+	Attempts  int                   `json:"attempts"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// Finished job is done or failed
+func (j Job) Finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
+
+// newJobID random job identifier
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// journalCompactMin journal lines written before it is compacted
+const journalCompactMin = 1000
+
+// journal append-only log of job states, synced to disk on every write. The latest line of a job wins on replay
+type journal struct {
+	path  string
+	file  *os.File
+	enc   *json.Encoder
+	lines int
+}
+
+// openJournal replay journal file, rewrite it with jobs still worth keeping and open it for appending.
+// Finished jobs older than retention are dropped
+func openJournal(path string, retention time.Duration, now time.Time) (*journal, []*Job, error) {
+	jobs, err := readJournal(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kept := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Finished() && now.Sub(job.UpdatedAt) > retention {
+			continue
+		}
+		kept = append(kept, job)
+	}
+
+	j := &journal{path: path}
+	if err = j.rewrite(kept); err != nil {
+		return nil, nil, err
+	}
+
+	return j, kept, nil
+}
+
+// rewrite replace journal content by job states and reopen it for appending. States are written to
+// temporary file first, so crash leaves either old or new journal
+func (j *journal) rewrite(jobs []*Job) error {
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	for _, job := range jobs {
+		if err = enc.Encode(job); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, j.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, j.enc, j.lines = file, json.NewEncoder(file), len(jobs)
+
+	return nil
+}
+
+// readJournal latest state of every job in order jobs were created. Missing file means no jobs
+func readJournal(path string) ([]*Job, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var order []string
+	byID := make(map[string]*Job)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		job := &Job{}
+		// torn tail line of a crashed process is skipped
+		if err = json.Unmarshal(scanner.Bytes(), job); err != nil || job.ID == "" {
+			continue
+		}
+		if _, ok := byID[job.ID]; !ok {
+			order = append(order, job.ID)
+		}
+		byID[job.ID] = job
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(order))
+	for _, id := range order {
+		jobs = append(jobs, byID[id])
+	}
+
+	return jobs, nil
+}
+
+// write append job states and sync them to disk
+func (j *journal) write(jobs ...Job) error {
+	for _, job := range jobs {
+		if err := j.enc.Encode(job); err != nil {
+			return err
+		}
+		j.lines++
+	}
+
+	return j.file.Sync()
+}
+
+// compactable journal is long enough and mostly holds outdated states of jobs
+func (j *journal) compactable(jobs int) bool {
+	return j.lines >= journalCompactMin && j.lines > 2*jobs
+}
+
+func (j *journal) close() error {
+	return j.file.Close()
+}
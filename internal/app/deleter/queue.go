@@ -0,0 +1,348 @@
+package deleter
+
+import (
+	"errors"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultQueueSize jobs waiting for workers before new ones are rejected
+	DefaultQueueSize = 1000
+	// DefaultWorkers number of workers draining the queue
+	DefaultWorkers = 4
+	// DefaultBatchSize jobs deleted by one storage call
+	DefaultBatchSize = 100
+	// DefaultFlushInterval longest time a job waits in a partial batch
+	DefaultFlushInterval = 100 * time.Millisecond
+	// DefaultMaxAttempts storage calls made for a batch before its jobs are failed
+	DefaultMaxAttempts = 5
+	// DefaultRetryBackoff delay before the first retry, doubled for every next one
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultRetention time finished jobs stay available for polling
+	DefaultRetention = 24 * time.Hour
+)
+
+var (
+	ErrQueueFull   = errors.New("delete queue is full")
+	ErrQueueClosed = errors.New("delete queue is closed")
+)
+
+// Deleter storage deleting items of several users at once
+type Deleter interface {
+	DeleteBatch(requests []storage.DeleteRequest) ([]storage.DeleteResult, error)
+}
+
+// Options queue settings, zero values mean defaults
+type Options struct {
+	QueueSize     int
+	Workers       int
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxAttempts   int
+	RetryBackoff  time.Duration
+	Retention     time.Duration
+}
+
+// Queue bounded queue of delete jobs drained by worker pool. Jobs of many users are deleted by batches.
+// With journal configured pending jobs survive restart, journal is compacted as batches finish
+type Queue struct {
+	deleter Deleter
+	opts    Options
+	queue   chan *Job
+	stop    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	journal *journal
+	closed  bool
+}
+
+// NewQueue create queue and start workers. Pending jobs found in journal are queued again.
+// Empty journal path keeps jobs in memory only
+func NewQueue(deleter Deleter, journalPath string, opts Options) (*Queue, error) {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+
+	q := &Queue{
+		deleter: deleter,
+		opts:    opts,
+		stop:    make(chan struct{}),
+		jobs:    make(map[string]*Job),
+	}
+
+	var restored []*Job
+	if journalPath != "" {
+		var err error
+		q.journal, restored, err = openJournal(journalPath, opts.Retention, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pending []*Job
+	for _, job := range restored {
+		q.jobs[job.ID] = job
+		if !job.Finished() {
+			pending = append(pending, job)
+		}
+	}
+
+	size := opts.QueueSize
+	if len(pending) > size {
+		size = len(pending)
+	}
+	q.queue = make(chan *Job, size)
+	for _, job := range pending {
+		q.queue <- job
+	}
+	if len(pending) > 0 {
+		log.Printf("Delete jobs restored from journal: %d", len(pending))
+	}
+
+	q.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go q.work()
+	}
+	go q.cleanup()
+
+	return q, nil
+}
+
+// Submit queue deletion of user short urls without blocking
+func (q *Queue) Submit(userID string, ids []string) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        id,
+		UserID:    userID,
+		IDs:       ids,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return Job{}, ErrQueueClosed
+	}
+
+	select {
+	case q.queue <- job:
+	default:
+		return Job{}, ErrQueueFull
+	}
+
+	q.jobs[job.ID] = job
+	q.persist(job)
+
+	return *job, nil
+}
+
+// Get job by ID. Jobs of other users are not found
+func (q *Queue) Get(id string, userID string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.UserID != userID {
+		return Job{}, false
+	}
+
+	return *job, true
+}
+
+// Close stop accepting jobs and process queued ones. Jobs waiting for retry are left pending in journal
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.queue)
+	close(q.stop)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+
+	if q.journal != nil {
+		return q.journal.close()
+	}
+
+	return nil
+}
+
+// work collect jobs into batches until queue is closed and drained
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for {
+		job, ok := <-q.queue
+		if !ok {
+			return
+		}
+
+		batch := []*Job{job}
+		timer := time.NewTimer(q.opts.FlushInterval)
+	collect:
+		for len(batch) < q.opts.BatchSize {
+			select {
+			case job, ok = <-q.queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, job)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		q.process(batch)
+	}
+}
+
+// process delete batch retrying storage failures with exponential backoff
+func (q *Queue) process(batch []*Job) {
+	requests := make([]storage.DeleteRequest, 0, len(batch))
+	for _, job := range batch {
+		requests = append(requests, storage.DeleteRequest{UserID: job.UserID, IDs: job.IDs})
+	}
+
+	backoff := q.opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		results, err := q.deleter.DeleteBatch(requests)
+		if err == nil && len(results) != len(batch) {
+			err = errors.New("delete results do not match requests")
+		}
+
+		q.mu.Lock()
+		for i, job := range batch {
+			job.Attempts++
+			job.UpdatedAt = time.Now()
+			if err == nil {
+				result := results[i]
+				job.Status = StatusDone
+				job.Result = &result
+			} else if attempt >= q.opts.MaxAttempts {
+				job.Status = StatusFailed
+				job.Error = err.Error()
+			}
+		}
+		q.persist(batch...)
+		if err == nil || attempt >= q.opts.MaxAttempts {
+			q.compact()
+		}
+		q.mu.Unlock()
+
+		if err == nil {
+			return
+		}
+		if attempt >= q.opts.MaxAttempts {
+			log.Printf("Delete batch of %d jobs failed after %d attempts: %v", len(batch), attempt, err)
+			return
+		}
+
+		log.Printf("Delete batch attempt %d error, retry in %v: %v", attempt, backoff, err)
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-q.stop:
+			log.Printf("Delete batch of %d jobs left pending on close", len(batch))
+			return
+		}
+	}
+}
+
+// persist write job states to journal. Must be called with mu held
+func (q *Queue) persist(jobs ...*Job) {
+	if q.journal == nil {
+		return
+	}
+
+	states := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		states = append(states, *job)
+	}
+	if err := q.journal.write(states...); err != nil {
+		log.Printf("Delete job journal write error: %v", err)
+	}
+}
+
+// compact rewrite journal with current states of known jobs once it is mostly outdated. Must be called with mu held
+func (q *Queue) compact() {
+	if q.journal == nil || !q.journal.compactable(len(q.jobs)) {
+		return
+	}
+
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	lines := q.journal.lines
+	if err := q.journal.rewrite(jobs); err != nil {
+		log.Printf("Delete job journal compaction error: %v", err)
+		return
+	}
+	log.Printf("Delete job journal compacted from %d to %d lines", lines, len(jobs))
+}
+
+// cleanup forget finished jobs older than retention until queue is closed
+func (q *Queue) cleanup() {
+	interval := q.opts.Retention / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			q.mu.Lock()
+			for id, job := range q.jobs {
+				if job.Finished() && now.Sub(job.UpdatedAt) > q.opts.Retention {
+					delete(q.jobs, id)
+				}
+			}
+			q.mu.Unlock()
+		case <-q.stop:
+			return
+		}
+	}
+}
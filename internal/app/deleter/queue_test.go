@@ -0,0 +1,205 @@
+package deleter
+
+import (
+	"errors"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testDeleter struct {
+	mu      sync.Mutex
+	batches [][]storage.DeleteRequest
+	fails   int
+	block   chan struct{}
+}
+
+func (d *testDeleter) DeleteBatch(requests []storage.DeleteRequest) ([]storage.DeleteResult, error) {
+	if d.block != nil {
+		<-d.block
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.batches = append(d.batches, requests)
+	if d.fails > 0 {
+		d.fails--
+		return nil, errors.New("connection reset")
+	}
+
+	results := make([]storage.DeleteResult, 0, len(requests))
+	for _, req := range requests {
+		results = append(results, storage.DeleteResult{Deleted: req.IDs, NotOwned: []string{}})
+	}
+	return results, nil
+}
+
+func (d *testDeleter) calls() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.batches)
+}
+
+func waitFinished(t *testing.T, q *Queue, id string, userID string) Job {
+	var job Job
+	assert.Eventually(t, func() bool {
+		var ok bool
+		job, ok = q.Get(id, userID)
+		return ok && job.Finished()
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestQueue_BatchesUsers(t *testing.T) {
+	d := &testDeleter{}
+	q, err := NewQueue(d, "", Options{Workers: 1, BatchSize: 10, FlushInterval: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	defer q.Close()
+
+	first, err := q.Submit("user1", []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPending, first.Status)
+	second, err := q.Submit("user2", []string{"c"})
+	assert.NoError(t, err)
+
+	job := waitFinished(t, q, first.ID, "user1")
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, []string{"a", "b"}, job.Result.Deleted)
+	job = waitFinished(t, q, second.ID, "user2")
+	assert.Equal(t, []string{"c"}, job.Result.Deleted)
+
+	assert.Equal(t, 1, d.calls())
+	assert.Len(t, d.batches[0], 2)
+
+	_, ok := q.Get(first.ID, "user2")
+	assert.False(t, ok)
+}
+
+func TestQueue_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		fails    int
+		status   string
+		attempts int
+	}{
+		{name: "Recovered", fails: 2, status: StatusDone, attempts: 3},
+		{name: "Failed", fails: 5, status: StatusFailed, attempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &testDeleter{fails: tt.fails}
+			q, err := NewQueue(d, "", Options{
+				FlushInterval: time.Millisecond,
+				MaxAttempts:   3,
+				RetryBackoff:  time.Millisecond,
+			})
+			assert.NoError(t, err)
+			defer q.Close()
+
+			submitted, err := q.Submit("user", []string{"a"})
+			assert.NoError(t, err)
+
+			job := waitFinished(t, q, submitted.ID, "user")
+			assert.Equal(t, tt.status, job.Status)
+			assert.Equal(t, tt.attempts, job.Attempts)
+			if tt.status == StatusFailed {
+				assert.Equal(t, "connection reset", job.Error)
+				assert.Nil(t, job.Result)
+			}
+		})
+	}
+}
+
+func TestQueue_Full(t *testing.T) {
+	d := &testDeleter{block: make(chan struct{})}
+	q, err := NewQueue(d, "", Options{QueueSize: 1, Workers: 1, BatchSize: 1})
+	assert.NoError(t, err)
+
+	// first job is taken by the blocked worker, second fills the queue
+	_, err = q.Submit("user", []string{"a"})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(q.queue) == 0 }, time.Second, time.Millisecond)
+	_, err = q.Submit("user", []string{"b"})
+	assert.NoError(t, err)
+
+	_, err = q.Submit("user", []string{"c"})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	close(d.block)
+	assert.NoError(t, q.Close())
+	assert.Equal(t, 2, d.calls())
+
+	_, err = q.Submit("user", []string{"d"})
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
+
+func TestQueue_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs")
+
+	failing := &testDeleter{fails: 100}
+	q, err := NewQueue(failing, path, Options{FlushInterval: time.Millisecond, RetryBackoff: time.Hour})
+	assert.NoError(t, err)
+
+	pending, err := q.Submit("user", []string{"a"})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return failing.calls() > 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, q.Close())
+
+	job, ok := q.Get(pending.ID, "user")
+	assert.True(t, ok)
+	assert.Equal(t, StatusPending, job.Status)
+
+	d := &testDeleter{}
+	q, err = NewQueue(d, path, Options{FlushInterval: time.Millisecond})
+	assert.NoError(t, err)
+
+	job = waitFinished(t, q, pending.ID, "user")
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.NoError(t, q.Close())
+
+	// finished jobs stay available for polling after restart and are not processed again
+	d = &testDeleter{}
+	q, err = NewQueue(d, path, Options{FlushInterval: time.Millisecond})
+	assert.NoError(t, err)
+	defer q.Close()
+
+	job, ok = q.Get(pending.ID, "user")
+	assert.True(t, ok)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, []string{"a"}, job.Result.Deleted)
+	assert.Zero(t, d.calls())
+}
+
+func TestQueue_JournalCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs")
+
+	q, err := NewQueue(&testDeleter{}, path, Options{FlushInterval: time.Millisecond})
+	assert.NoError(t, err)
+
+	submitted, err := q.Submit("user", []string{"a"})
+	assert.NoError(t, err)
+	waitFinished(t, q, submitted.ID, "user")
+
+	q.mu.Lock()
+	for i := 0; i < journalCompactMin; i++ {
+		q.persist(q.jobs[submitted.ID])
+	}
+	q.compact()
+	lines := q.journal.lines
+	q.mu.Unlock()
+	assert.Equal(t, 1, lines, "journal keeps the latest state of every job")
+	assert.NoError(t, q.Close())
+
+	jobs, err := readJournal(path)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, submitted.ID, jobs[0].ID)
+		assert.Equal(t, StatusDone, jobs[0].Status)
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/middleware"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/gorilla/context"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	// minPasswordLength shortest accepted account password
+	minPasswordLength = 8
+	// maxPasswordLength longest password bcrypt takes into account
+	maxPasswordLength = 72
+)
+
+// AccountRequest credentials of register and login requests
+type AccountRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// AccountResponse account of authenticated user. Merged is number of anonymous user urls moved to the account
+type AccountResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Merged int    `json:"merged_urls"`
+}
+
+// decodeAccountRequest read credentials with email normalized to lower case
+func decodeAccountRequest(r *http.Request) (AccountRequest, error) {
+	var req AccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return AccountRequest{}, common.ErrBodyReadError
+	}
+
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil || len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return AccountRequest{}, common.ErrInvalidAccount
+	}
+	req.Email = strings.ToLower(addr.Address)
+
+	return req, nil
+}
+
+// writeAccount start session of account user and respond with account
+func writeAccount(w http.ResponseWriter, status int, resp AccountResponse) {
+	if err := middleware.StartSession(w, resp.UserID); err != nil {
+		log.Printf("Account session start error: %v", err)
+		problem.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
+
+// Register create account of current anonymous user, so urls it has saved belong to the account
+func Register(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAccountRequest(r)
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	userID := fmt.Sprintf("%v", context.Get(r, "userID"))
+	if _, err = Store.GetUserAccount(userID); err == nil {
+		problem.Write(w, common.ErrAlreadyRegistered)
+		return
+	} else if !errors.Is(err, common.ErrAccountNotFound) {
+		problem.Write(w, err)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Password hash error: %v", err)
+		problem.Write(w, err)
+		return
+	}
+
+	err = Store.CreateAccount(storage.Account{
+		Email:        req.Email,
+		PasswordHash: string(hash),
+		UserID:       userID,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	writeAccount(w, http.StatusCreated, AccountResponse{UserID: userID, Email: req.Email})
+}
+
+// Login switch to account user. Urls saved by current anonymous user are merged into the account
+func Login(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAccountRequest(r)
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	account, err := Store.GetAccount(req.Email)
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(req.Password))
+	}
+	if err != nil {
+		if !errors.Is(err, common.ErrAccountNotFound) && !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			log.Printf("Login error: %v", err)
+		}
+		problem.Write(w, common.ErrInvalidCredentials)
+		return
+	}
+
+	resp := AccountResponse{UserID: account.UserID, Email: account.Email}
+	userID := fmt.Sprintf("%v", context.Get(r, "userID"))
+	if _, err = Store.GetUserAccount(userID); errors.Is(err, common.ErrAccountNotFound) {
+		resp.Merged, err = Store.MergeUser(userID, account.UserID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+	}
+
+	writeAccount(w, http.StatusOK, resp)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/apikey"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// APIKeyRequest new api key settings. No scopes means all of them
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// APIKeyResponse api key without its secret. Key is only set in response to key creation
+type APIKeyResponse struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Prefix  string    `json:"prefix"`
+	Scopes  []string  `json:"scopes"`
+	Created time.Time `json:"created_at"`
+	Key     string    `json:"key,omitempty"`
+}
+
+func newAPIKeyResponse(key storage.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:      key.ID,
+		Name:    key.Name,
+		Prefix:  key.Prefix,
+		Scopes:  key.Scopes,
+		Created: key.CreatedAt,
+	}
+}
+
+// CreateAPIKey issue api key of user. The key is returned once and can not be recovered later
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, common.ErrBodyReadError)
+		return
+	}
+
+	userID := context.Get(r, "userID")
+	key, secret, err := apikey.New(fmt.Sprintf("%v", userID), req.Name, req.Scopes, time.Now())
+	if err == nil {
+		err = Store.CreateAPIKey(key)
+	}
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	resp := newAPIKeyResponse(key)
+	resp.Key = secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
+
+// ListAPIKeys api keys of user
+func ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID := context.Get(r, "userID")
+	keys, err := Store.ListAPIKeys(fmt.Sprintf("%v", userID))
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	resp := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, newAPIKeyResponse(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
+
+// RevokeAPIKey delete api key of user, requests made with it are rejected from now on
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID := context.Get(r, "userID")
+	err := Store.RevokeAPIKey(chi.URLParam(r, "id"), fmt.Sprintf("%v", userID))
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
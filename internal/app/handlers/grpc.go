@@ -3,25 +3,30 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/fd239/go_url_shortener/api"
 	"github.com/fd239/go_url_shortener/config"
 	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/middleware"
 	"github.com/fd239/go_url_shortener/internal/app/storage"
 	"golang.org/x/sync/errgroup"
 	"log"
+	"time"
 )
 
 type consumer struct {
 	api.UnimplementedShortenerServer
 }
 
+// NewConsumer v1 gRPC API. User ID fields of requests are ignored, user is identified by auth interceptor
 func NewConsumer() *consumer {
 	return &consumer{}
 }
 
 // Ping short url microservice health check
 func (c *consumer) Ping(_ context.Context, _ *api.PingRequest) (resp *api.PingResponse, err error) {
+	resp = &api.PingResponse{}
 	err = Store.Ping()
 
 	if err != nil {
@@ -33,9 +38,10 @@ func (c *consumer) Ping(_ context.Context, _ *api.PingRequest) (resp *api.PingRe
 	return
 }
 
-func (c *consumer) GetUserUrls(_ context.Context, req *api.GetUserUrlRequest) (resp *api.GetUserUrlResponse, err error) {
-	userID := req.UserId
-	userURLs, err := Store.GetUserURL(fmt.Sprintf("%v", userID))
+// GetUserUrls all urls of authenticated user
+func (c *consumer) GetUserUrls(ctx context.Context, _ *api.GetUserUrlRequest) (resp *api.GetUserUrlResponse, err error) {
+	resp = &api.GetUserUrlResponse{}
+	userURLs, err := Store.GetUserURL(middleware.UserIDFromContext(ctx))
 
 	if err != nil {
 		resp.Error = err.Error()
@@ -43,7 +49,7 @@ func (c *consumer) GetUserUrls(_ context.Context, req *api.GetUserUrlRequest) (r
 	}
 
 	if len(userURLs) == 0 {
-		resp.Error = err.Error()
+		resp.Error = common.ErrNoUserURLs.Error()
 		return
 	}
 
@@ -63,7 +69,9 @@ func (c *consumer) GetUserUrls(_ context.Context, req *api.GetUserUrlRequest) (r
 	return
 }
 
+// DeleteUrls mark urls of authenticated user as deleted
 func (c *consumer) DeleteUrls(ctx context.Context, req *api.DeleteUrlsRequest) (resp *api.DeleteUrlsResponse, err error) {
+	resp = &api.DeleteUrlsResponse{}
 	var deleteIDs []string
 	err = json.Unmarshal([]byte(req.UrlsDelete), &deleteIDs)
 
@@ -73,10 +81,13 @@ func (c *consumer) DeleteUrls(ctx context.Context, req *api.DeleteUrlsRequest) (
 		return
 	}
 
+	var result storage.DeleteResult
 	g, _ := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		return Store.UpdateItems(deleteIDs)
+		var err error
+		result, err = Store.UpdateItems(deleteIDs, middleware.UserIDFromContext(ctx))
+		return err
 	})
 
 	if err = g.Wait(); err != nil {
@@ -84,10 +95,20 @@ func (c *consumer) DeleteUrls(ctx context.Context, req *api.DeleteUrlsRequest) (
 		return
 	}
 
+	b, err := json.Marshal(result)
+
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+
+	resp.Result = string(b)
 	return
 }
 
-func (c *consumer) BatchUrls(_ context.Context, req *api.BatchUrlsRequest) (resp *api.BatchUrlsResponse, err error) {
+// BatchUrls save several urls of authenticated user
+func (c *consumer) BatchUrls(ctx context.Context, req *api.BatchUrlsRequest) (resp *api.BatchUrlsResponse, err error) {
+	resp = &api.BatchUrlsResponse{}
 	var batchItems []storage.BatchItemRequest
 	err = json.Unmarshal([]byte(req.BatchItems), &batchItems)
 
@@ -97,7 +118,7 @@ func (c *consumer) BatchUrls(_ context.Context, req *api.BatchUrlsRequest) (resp
 		return
 	}
 
-	batchItemsResponse, batchErr := Store.CreateItems(batchItems, fmt.Sprintf("%v", req.UserId))
+	batchItemsResponse, batchErr := Store.CreateItems(batchItems, middleware.UserIDFromContext(ctx))
 
 	if batchErr != nil {
 		resp.Error = batchErr.Error()
@@ -116,7 +137,9 @@ func (c *consumer) BatchUrls(_ context.Context, req *api.BatchUrlsRequest) (resp
 	return
 }
 
-func (c *consumer) HandleUrl(_ context.Context, req *api.HandleUrlRequest) (resp *api.HandleUrlResponse, err error) {
+// HandleUrl save url of authenticated user from JSON shorten request
+func (c *consumer) HandleUrl(ctx context.Context, req *api.HandleUrlRequest) (resp *api.HandleUrlResponse, err error) {
+	resp = &api.HandleUrlResponse{}
 	var shorten ShortenRequest
 	err = json.Unmarshal([]byte(req.Url), &shorten)
 
@@ -126,7 +149,13 @@ func (c *consumer) HandleUrl(_ context.Context, req *api.HandleUrlRequest) (resp
 		return
 	}
 
-	shortURL, err := Store.Insert(shorten.URL, fmt.Sprintf("%v", req.UserId))
+	expiresAt, err := shorten.Deadline(time.Now())
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+
+	shortURL, err := Store.Insert(shorten.URL, middleware.UserIDFromContext(ctx), storage.InsertOptions{Alias: shorten.Alias, ExpiresAt: expiresAt})
 
 	if err != nil {
 		errString := fmt.Sprintf("Save short route error: %s", err.Error())
@@ -149,10 +178,22 @@ func (c *consumer) HandleUrl(_ context.Context, req *api.HandleUrlRequest) (resp
 	return
 }
 
+// unavailable error of short url which exists but can not be followed
+func unavailable(err error) bool {
+	return errors.Is(err, common.ErrURLExpired) || errors.Is(err, common.ErrURLDeleted) ||
+		errors.Is(err, common.ErrURLDisabled) || errors.Is(err, common.ErrURLLegal)
+}
+
+// GetUrl original url of short url
 func (c *consumer) GetUrl(_ context.Context, req *api.GetUrlRequest) (resp *api.GetUrlResponse, err error) {
+	resp = &api.GetUrlResponse{}
 	url, err := Store.Get(req.Id)
 
 	if err != nil {
+		if unavailable(err) {
+			resp.Error = err.Error()
+			return
+		}
 		log.Printf("Store GET error: %v\n", err)
 		resp.Error = common.ErrUnableToFindURL.Error()
 		return
@@ -161,8 +202,10 @@ func (c *consumer) GetUrl(_ context.Context, req *api.GetUrlRequest) (resp *api.
 	return
 }
 
-func (c *consumer) SaveShortUrl(_ context.Context, req *api.SaveShortUrlRequest) (resp *api.SaveShortUrlResponse, err error) {
-	shortURL, err := Store.Insert(req.Url, fmt.Sprintf("%v", req.UserID))
+// SaveShortUrl save url of authenticated user
+func (c *consumer) SaveShortUrl(ctx context.Context, req *api.SaveShortUrlRequest) (resp *api.SaveShortUrlResponse, err error) {
+	resp = &api.SaveShortUrlResponse{}
+	shortURL, err := Store.Insert(req.Url, middleware.UserIDFromContext(ctx), storage.InsertOptions{})
 
 	if err != nil {
 		errString := fmt.Sprintf("Save short route error: %v\n", err)
@@ -174,3 +217,25 @@ func (c *consumer) SaveShortUrl(_ context.Context, req *api.SaveShortUrlRequest)
 	resp.ShortUrl = fmt.Sprintf("%s/%s", config.Cfg.BaseURL, shortURL)
 	return
 }
+
+// GetUrlStats click statistics of user short url
+func (c *consumer) GetUrlStats(ctx context.Context, req *api.GetUrlStatsRequest) (resp *api.GetUrlStatsResponse, err error) {
+	resp = &api.GetUrlStatsResponse{}
+	stats, err := Store.GetStats(req.Id, middleware.UserIDFromContext(ctx))
+
+	if err != nil {
+		log.Printf("Store GET stats error: %v\n", err)
+		resp.Error = err.Error()
+		return
+	}
+
+	b, err := json.Marshal(stats)
+
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+
+	resp.Stats = string(b)
+	return
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	apiv2 "github.com/fd239/go_url_shortener/api/v2"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
+	"github.com/fd239/go_url_shortener/internal/app/middleware"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"log"
+	"time"
+)
+
+const (
+	// bulkBatchSize urls of bulk shorten stream saved at once
+	bulkBatchSize = 100
+	// bulkFlushInterval longest time bulk shorten url waits in a partial batch
+	bulkFlushInterval = 100 * time.Millisecond
+)
+
+type consumerV2 struct {
+	apiv2.UnimplementedShortenerServer
+}
+
+// NewConsumerV2 typed gRPC API reporting errors with status codes
+func NewConsumerV2() *consumerV2 {
+	return &consumerV2{}
+}
+
+// expiration expiry settings of request, nil timestamp means no absolute deadline
+func expiration(ttl int64, expiresAt *timestamppb.Timestamp) storage.Expiration {
+	exp := storage.Expiration{TTL: ttl}
+	if expiresAt != nil {
+		t := expiresAt.AsTime()
+		exp.ExpiresAt = &t
+	}
+
+	return exp
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+// Ping short url microservice health check
+func (c *consumerV2) Ping(_ context.Context, _ *apiv2.PingRequest) (*apiv2.PingResponse, error) {
+	if err := Store.Ping(); err != nil {
+		log.Printf("DB ping error: %v\n", err)
+		return nil, problem.Error(common.ErrPing)
+	}
+
+	return &apiv2.PingResponse{}, nil
+}
+
+// Shorten save url. Already shortened url is reported as AlreadyExists with its short url in details
+func (c *consumerV2) Shorten(ctx context.Context, req *apiv2.ShortenRequest) (*apiv2.ShortenResponse, error) {
+	expiresAt, err := expiration(req.Ttl, req.ExpiresAt).Deadline(time.Now())
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	shortURL, err := Store.Insert(req.Url, middleware.UserIDFromContext(ctx), storage.InsertOptions{Alias: req.Alias, ExpiresAt: expiresAt})
+	resp := &apiv2.ShortenResponse{ShortUrl: fmt.Sprintf("%s/%s", config.Cfg.BaseURL, shortURL)}
+
+	if errors.Is(err, common.ErrOriginalURLConflict) {
+		st, detailsErr := problem.Status(err).WithDetails(resp)
+		if detailsErr != nil {
+			return nil, problem.Error(detailsErr)
+		}
+		return nil, st.Err()
+	}
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	return resp, nil
+}
+
+// BatchShorten save several urls at once
+func (c *consumerV2) BatchShorten(ctx context.Context, req *apiv2.BatchShortenRequest) (*apiv2.BatchShortenResponse, error) {
+	items := make([]storage.BatchItemRequest, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, storage.BatchItemRequest{
+			CorrelationID: item.CorrelationId,
+			OriginalURL:   item.OriginalUrl,
+			Alias:         item.Alias,
+			Expiration:    expiration(item.Ttl, item.ExpiresAt),
+		})
+	}
+
+	created, err := Store.CreateItems(items, middleware.UserIDFromContext(ctx))
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	resp := &apiv2.BatchShortenResponse{Items: make([]*apiv2.BatchResult, 0, len(created))}
+	for _, item := range created {
+		resp.Items = append(resp.Items, &apiv2.BatchResult{CorrelationId: item.CorrelationID, ShortUrl: item.ShortURL})
+	}
+
+	return resp, nil
+}
+
+// GetUrl original url of short url
+func (c *consumerV2) GetUrl(_ context.Context, req *apiv2.GetUrlRequest) (*apiv2.GetUrlResponse, error) {
+	url, err := Store.Get(req.Id)
+	if err != nil {
+		if !unavailable(err) {
+			return nil, problem.Error(common.ErrUnableToFindURL)
+		}
+		return nil, problem.Error(err)
+	}
+
+	return &apiv2.GetUrlResponse{OriginalUrl: url}, nil
+}
+
+// ListUserUrls user saved urls. Request with page size, cursor, sort or filters gets one page of them
+func (c *consumerV2) ListUserUrls(ctx context.Context, req *apiv2.ListUserUrlsRequest) (*apiv2.ListUserUrlsResponse, error) {
+	userID := middleware.UserIDFromContext(ctx)
+	query := storage.UserURLQuery{
+		Cursor: req.Cursor,
+		Limit:  int(req.PageSize),
+		Sort:   req.Sort,
+		Search: req.Search,
+		Domain: req.Domain,
+	}
+
+	var page storage.UserURLPage
+	var err error
+	if query == (storage.UserURLQuery{}) {
+		page.Items, err = Store.GetUserURL(userID)
+		page.Total = len(page.Items)
+	} else {
+		page, err = Store.ListUserURLs(userID, query)
+	}
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	resp := &apiv2.ListUserUrlsResponse{
+		Urls:       make([]*apiv2.UserUrl, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+		TotalCount: int32(page.Total),
+	}
+	for _, v := range page.Items {
+		resp.Urls = append(resp.Urls, &apiv2.UserUrl{
+			ShortUrl:    fmt.Sprintf("%s/%s", config.Cfg.BaseURL, v.ShortURL),
+			OriginalUrl: v.OriginalURL,
+		})
+	}
+
+	return resp, nil
+}
+
+// StreamUserUrls send all user urls reading them from storage page by page
+func (c *consumerV2) StreamUserUrls(req *apiv2.StreamUserUrlsRequest, stream apiv2.Shortener_StreamUserUrlsServer) error {
+	userID := middleware.UserIDFromContext(stream.Context())
+	cursor := req.Cursor
+	for {
+		page, err := Store.ListUserURLs(userID, storage.UserURLQuery{Cursor: cursor, Limit: int(req.PageSize)})
+		if err != nil {
+			return problem.Error(err)
+		}
+
+		for i, item := range page.Items {
+			resp := &apiv2.StreamUserUrlsResponse{Url: &apiv2.UserUrl{
+				ShortUrl:    fmt.Sprintf("%s/%s", config.Cfg.BaseURL, item.ShortURL),
+				OriginalUrl: item.OriginalURL,
+			}}
+			if i == len(page.Items)-1 {
+				resp.NextCursor = page.NextCursor
+			}
+			if err = stream.Send(resp); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// BulkShorten save stream of urls by batches, sending short urls back as soon as their batch is saved
+func (c *consumerV2) BulkShorten(stream apiv2.Shortener_BulkShortenServer) error {
+	ctx := stream.Context()
+	userID := middleware.UserIDFromContext(ctx)
+
+	requests := make(chan *apiv2.BulkShortenRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(requests)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	batch := make([]storage.BatchItemRequest, 0, bulkBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		created, err := Store.CreateItems(batch, userID)
+		if err != nil {
+			return problem.Error(err)
+		}
+		for _, item := range created {
+			if err = stream.Send(&apiv2.BulkShortenResponse{CorrelationId: item.CorrelationID, ShortUrl: item.ShortURL}); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	ticker := time.NewTicker(bulkFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				if err := flush(); err != nil {
+					return err
+				}
+				select {
+				case err := <-recvErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			batch = append(batch, storage.BatchItemRequest{
+				CorrelationID: req.CorrelationId,
+				OriginalURL:   req.Url,
+				Alias:         req.Alias,
+				Expiration:    storage.Expiration{TTL: req.Ttl},
+			})
+			if len(batch) >= bulkBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// DeleteUrls queue deletion of user urls. Returned job is polled with GetDeleteJob
+func (c *consumerV2) DeleteUrls(ctx context.Context, req *apiv2.DeleteUrlsRequest) (*apiv2.DeleteUrlsResponse, error) {
+	job, err := Deletes.Submit(middleware.UserIDFromContext(ctx), req.Ids)
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	return &apiv2.DeleteUrlsResponse{Job: deleteJob(job)}, nil
+}
+
+// GetDeleteJob state of user delete job
+func (c *consumerV2) GetDeleteJob(ctx context.Context, req *apiv2.GetDeleteJobRequest) (*apiv2.GetDeleteJobResponse, error) {
+	job, ok := Deletes.Get(req.Id, middleware.UserIDFromContext(ctx))
+	if !ok {
+		return nil, problem.Error(common.ErrJobNotFound)
+	}
+
+	return &apiv2.GetDeleteJobResponse{Job: deleteJob(job)}, nil
+}
+
+// GetUrlStats click statistics of user short url
+func (c *consumerV2) GetUrlStats(ctx context.Context, req *apiv2.GetUrlStatsRequest) (*apiv2.GetUrlStatsResponse, error) {
+	stats, err := Store.GetStats(req.Id, middleware.UserIDFromContext(ctx))
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	return &apiv2.GetUrlStatsResponse{
+		ShortUrl:       stats.ShortURL,
+		TotalClicks:    int64(stats.TotalClicks),
+		UniqueVisitors: int64(stats.UniqueVisitors),
+		Hourly:         statsPoints(stats.Hourly),
+		Daily:          statsPoints(stats.Daily),
+	}, nil
+}
+
+// UpdateUrl change destination of user url
+func (c *consumerV2) UpdateUrl(ctx context.Context, req *apiv2.UpdateUrlRequest) (*apiv2.UpdateUrlResponse, error) {
+	change, err := Store.UpdateURL(storage.URLChange{
+		ShortURL:    req.Id,
+		OriginalURL: req.OriginalUrl,
+		UserID:      middleware.UserIDFromContext(ctx),
+		ChangedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	return &apiv2.UpdateUrlResponse{Change: urlChange(change)}, nil
+}
+
+// RestoreUrl undo deletion of user url
+func (c *consumerV2) RestoreUrl(ctx context.Context, req *apiv2.RestoreUrlRequest) (*apiv2.RestoreUrlResponse, error) {
+	if err := Store.RestoreURL(req.Id, middleware.UserIDFromContext(ctx)); err != nil {
+		return nil, problem.Error(err)
+	}
+
+	return &apiv2.RestoreUrlResponse{}, nil
+}
+
+// TransferUrl hand user url over to registered account
+func (c *consumerV2) TransferUrl(ctx context.Context, req *apiv2.TransferUrlRequest) (*apiv2.TransferUrlResponse, error) {
+	if err := transferURL(req.Id, middleware.UserIDFromContext(ctx), req.Email); err != nil {
+		return nil, problem.Error(err)
+	}
+
+	return &apiv2.TransferUrlResponse{}, nil
+}
+
+// GetUrlHistory destination changes of user url, oldest first
+func (c *consumerV2) GetUrlHistory(ctx context.Context, req *apiv2.GetUrlHistoryRequest) (*apiv2.GetUrlHistoryResponse, error) {
+	changes, err := Store.URLHistory(req.Id, middleware.UserIDFromContext(ctx))
+	if err != nil {
+		return nil, problem.Error(err)
+	}
+
+	resp := &apiv2.GetUrlHistoryResponse{Changes: make([]*apiv2.UrlChange, 0, len(changes))}
+	for _, change := range changes {
+		resp.Changes = append(resp.Changes, urlChange(change))
+	}
+
+	return resp, nil
+}
+
+func urlChange(change storage.URLChange) *apiv2.UrlChange {
+	return &apiv2.UrlChange{
+		ShortUrl:    change.ShortURL,
+		PreviousUrl: change.PreviousURL,
+		OriginalUrl: change.OriginalURL,
+		ChangedAt:   toTimestamp(change.ChangedAt),
+	}
+}
+
+func deleteJob(job deleter.Job) *apiv2.DeleteJob {
+	resp := &apiv2.DeleteJob{
+		Id:        job.ID,
+		Status:    job.Status,
+		Error:     job.Error,
+		Attempts:  int32(job.Attempts),
+		CreatedAt: toTimestamp(job.CreatedAt),
+		UpdatedAt: toTimestamp(job.UpdatedAt),
+	}
+	if job.Result != nil {
+		resp.Deleted = job.Result.Deleted
+		resp.NotOwned = job.Result.NotOwned
+	}
+
+	return resp
+}
+
+func statsPoints(points []storage.StatsPoint) []*apiv2.StatsPoint {
+	resp := make([]*apiv2.StatsPoint, 0, len(points))
+	for _, p := range points {
+		resp = append(resp, &apiv2.StatsPoint{Time: toTimestamp(p.Time), Clicks: int64(p.Clicks)})
+	}
+
+	return resp
+}
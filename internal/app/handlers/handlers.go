@@ -4,23 +4,59 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	apiv2 "github.com/fd239/go_url_shortener/api/v2"
 	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/analytics"
 	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
 	"github.com/fd239/go_url_shortener/internal/app/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/context"
-	"golang.org/x/sync/errgroup"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
-var Store *storage.Database
+var Store storage.Repository
+
+// Clicks redirects recorder, clicks are not recorded if nil
+var Clicks *analytics.Recorder
+
+// Deletes background queue of user delete requests
+var Deletes *deleter.Queue
 
 type ShortenRequest struct {
-	URL string `json:"url"`
+	URL   string `json:"url"`
+	Alias string `json:"alias,omitempty"`
+	storage.Expiration
+}
+
+// JobResponse delete job state returned to its owner
+type JobResponse struct {
+	ID       string                `json:"id"`
+	Status   string                `json:"status"`
+	Result   *storage.DeleteResult `json:"result,omitempty"`
+	Error    string                `json:"error,omitempty"`
+	Attempts int                   `json:"attempts"`
+	Created  time.Time             `json:"created_at"`
+	Updated  time.Time             `json:"updated_at"`
+}
+
+func newJobResponse(job deleter.Job) JobResponse {
+	return JobResponse{
+		ID:       job.ID,
+		Status:   job.Status,
+		Result:   job.Result,
+		Error:    job.Error,
+		Attempts: job.Attempts,
+		Created:  job.CreatedAt,
+		Updated:  job.UpdatedAt,
+	}
 }
 
 type ShortenResponse struct {
@@ -38,13 +74,13 @@ func BatchURLs(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Printf("batch urls body read error: %v\n", err)
-		http.Error(w, common.ErrBodyReadError.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrBodyReadError)
 		return
 	}
 
 	if len(body) == 0 {
 		log.Println(common.ErrEmptyBody)
-		http.Error(w, common.ErrEmptyBody.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrEmptyBody)
 		return
 	}
 
@@ -53,7 +89,7 @@ func BatchURLs(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Printf("json.Encode: %v\n", err)
-		http.Error(w, common.ErrBodyReadError.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrBodyReadError)
 		return
 	}
 
@@ -61,7 +97,7 @@ func BatchURLs(w http.ResponseWriter, r *http.Request) {
 	batchItemsResponse, batchErr := Store.CreateItems(batchItems, fmt.Sprintf("%v", userID))
 
 	if batchErr != nil {
-		http.Error(w, common.ErrBodyReadError.Error(), http.StatusBadRequest)
+		problem.Write(w, batchErr)
 		return
 	}
 
@@ -70,24 +106,22 @@ func BatchURLs(w http.ResponseWriter, r *http.Request) {
 
 	if err = json.NewEncoder(w).Encode(batchItemsResponse); err != nil {
 		log.Printf("json.Encode: %v\n", err)
-		http.Error(w, common.ErrResponseEncode.Error(), http.StatusBadRequest)
 	}
-
 }
 
-// DeleteURLs mark url as deleted in Postgres
+// DeleteURLs queue deletion of user urls. Responds with job to poll for deleted and not owned short urls
 func DeleteURLs(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
 		log.Printf("delete urls body read error: %v\n", err)
-		http.Error(w, common.ErrBodyReadError.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrBodyReadError)
 		return
 	}
 
 	if len(body) == 0 {
 		log.Println(common.ErrEmptyBody)
-		http.Error(w, common.ErrEmptyBody.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrEmptyBody)
 		return
 	}
 
@@ -96,80 +130,220 @@ func DeleteURLs(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Printf("json.Encode: %v\n", err)
-		http.Error(w, common.ErrBodyReadError.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrBodyReadError)
 		return
 	}
 
-	g, _ := errgroup.WithContext(r.Context())
-
-	g.Go(func() error {
-		return Store.UpdateItems(deleteIDs)
-	})
+	userID := context.Get(r, "userID")
+	job, err := Deletes.Submit(fmt.Sprintf("%v", userID), deleteIDs)
 
-	if err = g.Wait(); err != nil {
-		http.Error(w, common.ErrResponseEncode.Error(), http.StatusBadRequest)
+	if err != nil {
+		log.Printf("delete job submit error: %v\n", err)
+		problem.Write(w, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/user/jobs/"+job.ID)
 	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(newJobResponse(job)); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
+
+// GetJob status of user delete job
+func GetJob(w http.ResponseWriter, r *http.Request) {
+	userID := context.Get(r, "userID")
+	job, ok := Deletes.Get(chi.URLParam(r, "id"), fmt.Sprintf("%v", userID))
+
+	if !ok {
+		problem.Write(w, common.ErrJobNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(newJobResponse(job)); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
 }
 
 // GetURL GET method for receive url by short id
 func GetURL(w http.ResponseWriter, r *http.Request) {
-	status := 0
-
 	urlID := chi.URLParam(r, "id")
 	url, err := Store.Get(urlID)
 
 	if err != nil {
-		if errors.Is(err, common.ErrURLDeleted) {
-			status = http.StatusGone
-		} else {
-			log.Printf("Store GET error: %v\n", err)
-			http.Error(w, common.ErrUnableToFindURL.Error(), http.StatusBadRequest)
-			return
-		}
-	} else {
-		status = http.StatusTemporaryRedirect
-		w.Header().Set("Location", url)
+		problem.Write(w, err)
+		return
 	}
 
-	w.WriteHeader(status)
+	w.Header().Set("Location", url)
+	if Clicks != nil {
+		Clicks.Record(analytics.NewClick(urlID, r))
+	}
+
+	w.WriteHeader(http.StatusTemporaryRedirect)
 }
 
-// GetUserURLs Get all user saved urls by user ID
-func GetUserURLs(w http.ResponseWriter, r *http.Request) {
+// GetOpenAPI OpenAPI document of REST gateway
+func GetOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(apiv2.OpenAPI)
+}
+
+// GetURLStats click statistics of user short url
+func GetURLStats(w http.ResponseWriter, r *http.Request) {
+	urlID := chi.URLParam(r, "id")
 	userID := context.Get(r, "userID")
-	userURLs, err := Store.GetUserURL(fmt.Sprintf("%v", userID))
+	stats, err := Store.GetStats(urlID, fmt.Sprintf("%v", userID))
 
 	if err != nil {
-		http.Error(w, "store error", http.StatusNoContent)
+		problem.Write(w, err)
 		return
 	}
 
-	if len(userURLs) == 0 {
-		http.Error(w, common.ErrNoUserURLs.Error(), http.StatusNoContent)
+	statsJSON, err := json.Marshal(stats)
+
+	if err != nil {
+		log.Printf("url stats marshall error: %v\n", err)
+		problem.Write(w, common.ErrResponseEncode)
 		return
 	}
 
-	var baseURLItems []*storage.UserItem
-	for _, v := range userURLs {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Write(statsJSON)
+}
+
+// userURLParams query parameters of user urls listing
+var userURLParams = []string{"limit", "cursor", "sort", "search", "domain"}
+
+// userURLQuery read user urls listing query. False if request has no listing parameters
+func userURLQuery(r *http.Request) (storage.UserURLQuery, bool, error) {
+	values := r.URL.Query()
+	paged := false
+	for _, param := range userURLParams {
+		if _, ok := values[param]; ok {
+			paged = true
+		}
+	}
+
+	query := storage.UserURLQuery{
+		Cursor: values.Get("cursor"),
+		Sort:   values.Get("sort"),
+		Search: values.Get("search"),
+		Domain: values.Get("domain"),
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > storage.MaxPageSize {
+			return query, paged, common.ErrInvalidPageSize
+		}
+		query.Limit = n
+	}
+
+	return query, paged, nil
+}
+
+// userURLItems user items with full short urls
+func userURLItems(items []*storage.UserItem) []*storage.UserItem {
+	baseURLItems := make([]*storage.UserItem, 0, len(items))
+	for _, v := range items {
 		baseURLItems = append(baseURLItems, &storage.UserItem{OriginalURL: v.OriginalURL, ShortURL: fmt.Sprintf("%s/%s", config.Cfg.BaseURL, v.ShortURL)})
 	}
 
-	userURLsJSON, err := json.Marshal(baseURLItems)
+	return baseURLItems
+}
+
+// GetUserURLs Get user saved urls by user ID. Request with limit, cursor, sort, search or domain parameters
+// gets one page of urls with link to the next page, request without them gets all urls.
+// Number of urls matching filters is sent in X-Total-Count header
+func GetUserURLs(w http.ResponseWriter, r *http.Request) {
+	userID := fmt.Sprintf("%v", context.Get(r, "userID"))
+	query, paged, err := userURLQuery(r)
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	if !paged {
+		writeAllUserURLs(w, userID)
+		return
+	}
 
+	page, err := Store.ListUserURLs(userID, query)
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	userURLsJSON, err := json.Marshal(userURLItems(page.Items))
 	if err != nil {
 		log.Printf("user URLs marshall error: %v\n", err)
-		http.Error(w, common.ErrNoUserURLs.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrResponseEncode)
 		return
 	}
 
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		next := *r.URL
+		values := next.Query()
+		values.Set("cursor", page.NextCursor)
+		next.RawQuery = values.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.Header().Add("Accept", "application/json")
 	w.Write(userURLsJSON)
 }
 
+// writeAllUserURLs write all user urls as one JSON array, reading them from storage page by page
+func writeAllUserURLs(w http.ResponseWriter, userID string) {
+	query := storage.UserURLQuery{Limit: storage.MaxPageSize}
+	page, err := Store.ListUserURLs(userID, query)
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	if page.Total == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Add("Accept", "application/json")
+
+	w.Write([]byte("["))
+	first := true
+	for {
+		for _, item := range userURLItems(page.Items) {
+			itemJSON, err := json.Marshal(item)
+			if err != nil {
+				log.Printf("user URLs marshall error: %v\n", err)
+				return
+			}
+			if !first {
+				w.Write([]byte(","))
+			}
+			first = false
+			w.Write(itemJSON)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+		if page, err = Store.ListUserURLs(userID, query); err != nil {
+			log.Printf("user URLs list error: %v\n", err)
+			return
+		}
+	}
+	w.Write([]byte("]"))
+}
+
 // SaveShortURL receive short URL in POST method and save it to storage
 func SaveShortURL(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
@@ -177,26 +351,30 @@ func SaveShortURL(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Println(common.ErrBodyReadError)
-		http.Error(w, common.ErrBodyReadError.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrBodyReadError)
 		return
 	}
 
 	if len(body) == 0 {
 		log.Println(common.ErrEmptyBody)
-		http.Error(w, common.ErrEmptyBody.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrEmptyBody)
+		return
+	}
+
+	expiresAt, err := queryExpiration(r)
+	if err != nil {
+		problem.Write(w, err)
 		return
 	}
 
 	userID := context.Get(r, "userID")
-	shortURL, err := Store.Insert(string(body), fmt.Sprintf("%v", userID))
+	shortURL, err := Store.Insert(string(body), fmt.Sprintf("%v", userID), storage.InsertOptions{ExpiresAt: expiresAt})
 
 	if err != nil {
 		if errors.Is(err, common.ErrOriginalURLConflict) {
 			status = http.StatusConflict
 		} else {
-			errString := fmt.Sprintf("Save short route error: %v\n", err)
-			log.Println(errString)
-			http.Error(w, errString, http.StatusBadRequest)
+			problem.Write(w, err)
 			return
 		}
 	} else {
@@ -209,29 +387,56 @@ func SaveShortURL(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(fmt.Sprintf("%s/%s", config.Cfg.BaseURL, shortURL)))
 }
 
+// queryExpiration link expiry time from ttl (seconds) or expires_at (RFC 3339) query params
+func queryExpiration(r *http.Request) (time.Time, error) {
+	var expiration storage.Expiration
+	query := r.URL.Query()
+
+	if ttl := query.Get("ttl"); ttl != "" {
+		seconds, err := strconv.ParseInt(ttl, 10, 64)
+		if err != nil {
+			return time.Time{}, common.ErrInvalidExpiry
+		}
+		expiration.TTL = seconds
+	}
+
+	if expiresAt := query.Get("expires_at"); expiresAt != "" {
+		at, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return time.Time{}, common.ErrInvalidExpiry
+		}
+		expiration.ExpiresAt = &at
+	}
+
+	return expiration.Deadline(time.Now())
+}
+
 // HandleURL save short URL received from POST request
 func HandleURL(w http.ResponseWriter, r *http.Request) {
 	shorten := ShortenRequest{}
 	status := 0
 
 	if err := json.NewDecoder(r.Body).Decode(&shorten); err != nil {
-		http.Error(w, common.ErrUnableToFindURL.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrBodyReadError)
+		return
+	}
+
+	expiresAt, err := shorten.Deadline(time.Now())
+	if err != nil {
+		problem.Write(w, err)
 		return
 	}
 
 	userID := context.Get(r, "userID")
-	shortURL, err := Store.Insert(shorten.URL, fmt.Sprintf("%v", userID))
+	shortURL, err := Store.Insert(shorten.URL, fmt.Sprintf("%v", userID), storage.InsertOptions{Alias: shorten.Alias, ExpiresAt: expiresAt})
 
 	if err != nil {
 		if errors.Is(err, common.ErrOriginalURLConflict) {
 			status = http.StatusConflict
 		} else {
-			errString := fmt.Sprintf("Save short route error: %s", err.Error())
-			log.Printf("json.Decode: %v\n", err)
-			http.Error(w, errString, http.StatusBadRequest)
+			problem.Write(w, err)
 			return
 		}
-
 	} else {
 		status = http.StatusCreated
 	}
@@ -242,7 +447,7 @@ func HandleURL(w http.ResponseWriter, r *http.Request) {
 
 	if jsonErr != nil {
 		log.Printf("json.Marshall: %v\n", jsonErr)
-		http.Error(w, common.ErrResponseEncode.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrResponseEncode)
 		return
 	}
 
@@ -259,7 +464,7 @@ func Ping(w http.ResponseWriter, _ *http.Request) {
 
 	if err != nil {
 		log.Printf("DB ping error: %v\n", err)
-		http.Error(w, common.ErrPing.Error(), http.StatusInternalServerError)
+		problem.Write(w, common.ErrPing)
 		return
 	}
 
@@ -267,30 +472,8 @@ func Ping(w http.ResponseWriter, _ *http.Request) {
 }
 
 func GetStats(w http.ResponseWriter, r *http.Request) {
-	trustedSubnet := config.Cfg.TrustedSubnet
-
-	if trustedSubnet == "" {
-		log.Println("Trusted Subnet not specified")
-		http.Error(w, "Trusted Subnet not specified", http.StatusForbidden)
-		return
-	}
-	_, ipNet, err := net.ParseCIDR(trustedSubnet)
-	if err != nil {
-		log.Println("Can't parse CIDR")
-		http.Error(w, err.Error(), http.StatusForbidden)
-		return
-	}
-
-	ip, err := getRequestIp(r)
-	if err != nil {
-		log.Println("Can't parse IP")
-		http.Error(w, err.Error(), http.StatusForbidden)
-		return
-	}
-
-	if !ipNet.Contains(ip) {
-		log.Println("Can't contain IP" + ip.String())
-		http.Error(w, "Can't contain IP"+ip.String(), http.StatusForbidden)
+	if err := trustedRequest(r); err != nil {
+		problem.Write(w, err)
 		return
 	}
 
@@ -304,7 +487,7 @@ func GetStats(w http.ResponseWriter, r *http.Request) {
 
 	b, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, common.ErrResponseEncode)
 		return
 	}
 
@@ -315,6 +498,34 @@ func GetStats(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// trustedRequest ErrUntrustedNetwork unless request came from trusted subnet
+func trustedRequest(r *http.Request) error {
+	trustedSubnet := config.Cfg.TrustedSubnet
+
+	if trustedSubnet == "" {
+		log.Println("Trusted Subnet not specified")
+		return common.ErrUntrustedNetwork
+	}
+	_, ipNet, err := net.ParseCIDR(trustedSubnet)
+	if err != nil {
+		log.Println("Can't parse CIDR")
+		return common.ErrUntrustedNetwork
+	}
+
+	ip, err := getRequestIp(r)
+	if err != nil {
+		log.Println("Can't parse IP")
+		return common.ErrUntrustedNetwork
+	}
+
+	if !ipNet.Contains(ip) {
+		log.Println("Can't contain IP" + ip.String())
+		return common.ErrUntrustedNetwork
+	}
+
+	return nil
+}
+
 func getRequestIp(r *http.Request) (net.IP, error) {
 	remoteAddr := r.RemoteAddr
 
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// URLUpdateRequest new destination of short url
+type URLUpdateRequest struct {
+	OriginalURL string `json:"original_url"`
+}
+
+// TransferRequest registered account receiving short url
+type TransferRequest struct {
+	Email string `json:"email"`
+}
+
+// URLChangeResponse destination change of short url
+type URLChangeResponse struct {
+	ShortURL    string    `json:"short_url"`
+	PreviousURL string    `json:"previous_url"`
+	OriginalURL string    `json:"original_url"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+func newURLChangeResponse(change storage.URLChange) URLChangeResponse {
+	return URLChangeResponse{
+		ShortURL:    change.ShortURL,
+		PreviousURL: change.PreviousURL,
+		OriginalURL: change.OriginalURL,
+		ChangedAt:   change.ChangedAt,
+	}
+}
+
+// transferURL hand user url over to user of registered account
+func transferURL(id string, userID string, email string) error {
+	account, err := Store.GetAccount(strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return err
+	}
+
+	return Store.TransferURL(id, userID, account.UserID)
+}
+
+// UpdateURL change destination of user short url
+func UpdateURL(w http.ResponseWriter, r *http.Request) {
+	var req URLUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, common.ErrBodyReadError)
+		return
+	}
+
+	userID := context.Get(r, "userID")
+	change, err := Store.UpdateURL(storage.URLChange{
+		ShortURL:    chi.URLParam(r, "id"),
+		OriginalURL: req.OriginalURL,
+		UserID:      fmt.Sprintf("%v", userID),
+		ChangedAt:   time.Now(),
+	})
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err = json.NewEncoder(w).Encode(newURLChangeResponse(change)); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
+
+// RestoreURL undo deletion of user short url
+func RestoreURL(w http.ResponseWriter, r *http.Request) {
+	userID := context.Get(r, "userID")
+	if err := Store.RestoreURL(chi.URLParam(r, "id"), fmt.Sprintf("%v", userID)); err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TransferURL hand user short url over to registered account
+func TransferURL(w http.ResponseWriter, r *http.Request) {
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, common.ErrBodyReadError)
+		return
+	}
+
+	userID := context.Get(r, "userID")
+	if err := transferURL(chi.URLParam(r, "id"), fmt.Sprintf("%v", userID), req.Email); err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetURLHistory destination changes of user short url, oldest first
+func GetURLHistory(w http.ResponseWriter, r *http.Request) {
+	userID := context.Get(r, "userID")
+	changes, err := Store.URLHistory(chi.URLParam(r, "id"), fmt.Sprintf("%v", userID))
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	resp := make([]URLChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		resp = append(resp, newURLChangeResponse(change))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net/http"
+	"time"
+)
+
+// BlockRequest reason short url is disabled. Legal takedowns are served with 451, other blocks with 410
+type BlockRequest struct {
+	Reason string `json:"reason"`
+	Legal  bool   `json:"legal"`
+}
+
+// BlockURL disable short url, available from trusted subnet only
+func BlockURL(w http.ResponseWriter, r *http.Request) {
+	if err := trustedRequest(r); err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	var req BlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, common.ErrBodyReadError)
+		return
+	}
+
+	urlID := chi.URLParam(r, "id")
+	err := Store.DisableURL(urlID, storage.Moderation{Reason: req.Reason, Legal: req.Legal, DisabledAt: time.Now()})
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	log.Printf("Short url %s disabled: %s\n", urlID, req.Reason)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnblockURL lift block of short url, available from trusted subnet only
+func UnblockURL(w http.ResponseWriter, r *http.Request) {
+	if err := trustedRequest(r); err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	urlID := chi.URLParam(r, "id")
+	if err := Store.EnableURL(urlID); err != nil {
+		problem.Write(w, err)
+		return
+	}
+
+	log.Printf("Short url %s enabled\n", urlID)
+	w.WriteHeader(http.StatusNoContent)
+}
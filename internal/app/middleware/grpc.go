@@ -0,0 +1,295 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/analytics"
+	"github.com/fd239/go_url_shortener/internal/app/apikey"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/ratelimit"
+	"github.com/fd239/go_url_shortener/internal/app/session"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TokenMetadataKey gRPC metadata key of encrypted user token, same token as in HTTP "token" cookie
+	TokenMetadataKey = "token"
+	// AuthorizationMetadataKey gRPC metadata key of "Bearer" api key
+	AuthorizationMetadataKey = "authorization"
+	// RetryAfterMetadataKey gRPC metadata key of seconds to wait after ResourceExhausted
+	RetryAfterMetadataKey = "retry-after"
+)
+
+type userIDKey struct{}
+
+// validator request message with rules generated by protoc-gen-validate
+type validator interface {
+	Validate() error
+}
+
+// authStream server stream with authenticated user in context
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// validateStream server stream validating every received message
+type validateStream struct {
+	grpc.ServerStream
+}
+
+func (s *validateStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if v, ok := m.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return problem.Error(fmt.Errorf("%w: %v", common.ErrInvalidRequest, err))
+		}
+	}
+
+	return nil
+}
+
+// UserIDFromContext user ID verified by auth interceptor
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey{}).(string)
+	return userID
+}
+
+// ContextWithUserID context carrying verified user ID
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// isInfraMethod health and reflection methods, they are served without user
+func isInfraMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.")
+}
+
+// methodScopes api key scope required by methods of v1 and v2 shortener services. Methods
+// not listed here are served to user sessions only
+var methodScopes = map[string]string{
+	"Ping":           apikey.ScopeRead,
+	"GetUrl":         apikey.ScopeRead,
+	"GetUserUrls":    apikey.ScopeRead,
+	"ListUserUrls":   apikey.ScopeRead,
+	"StreamUserUrls": apikey.ScopeRead,
+	"GetDeleteJob":   apikey.ScopeRead,
+	"GetUrlStats":    apikey.ScopeRead,
+	"GetUrlHistory":  apikey.ScopeRead,
+	"HandleUrl":      apikey.ScopeWrite,
+	"SaveShortUrl":   apikey.ScopeWrite,
+	"BatchUrls":      apikey.ScopeWrite,
+	"Shorten":        apikey.ScopeWrite,
+	"BatchShorten":   apikey.ScopeWrite,
+	"BulkShorten":    apikey.ScopeWrite,
+	"UpdateUrl":      apikey.ScopeWrite,
+	"RestoreUrl":     apikey.ScopeWrite,
+	"DeleteUrls":     apikey.ScopeDelete,
+}
+
+// methodScope api key scope required by full gRPC method name
+func methodScope(fullMethod string) string {
+	return methodScopes[fullMethod[strings.LastIndex(fullMethod, "/")+1:]]
+}
+
+// authenticate user by api key in "authorization" metadata or by token from incoming metadata.
+// New user token is returned when request has none, its token has expired or is due for refresh
+func authenticate(ctx context.Context, fullMethod string) (userID string, newToken string, err error) {
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(AuthorizationMetadataKey); len(values) > 0 {
+			return authenticateKey(values[0], fullMethod)
+		}
+		if tokens := md.Get(TokenMetadataKey); len(tokens) > 0 {
+			token = tokens[0]
+		}
+	}
+
+	userID, newToken, err = session.Resolve(token, time.Now())
+	if errors.Is(err, session.ErrTokenMalformed) {
+		log.Printf("User token rejected: %v\n", err)
+		return "", "", problem.Error(common.ErrUserCookie)
+	}
+	if err != nil {
+		log.Printf("Crypt new user encrypt error: %v", err)
+		return "", "", problem.Error(err)
+	}
+
+	return userID, newToken, nil
+}
+
+// authenticateKey user of "Bearer" api key allowed to call method
+func authenticateKey(value string, fullMethod string) (string, string, error) {
+	key, ok := apikey.FromHeader(value)
+	found, err := authenticateAPIKey(key)
+	if !ok || errors.Is(err, common.ErrInvalidAPIKey) {
+		return "", "", problem.Error(common.ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return "", "", problem.Error(err)
+	}
+
+	if !apikey.Allowed(found.Scopes, methodScope(fullMethod)) {
+		return "", "", problem.Error(common.ErrScopeDenied)
+	}
+
+	return found.UserID, "", nil
+}
+
+// AuthUnaryInterceptor auth to service by token in metadata. Issued token is sent in response header
+func AuthUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if isInfraMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	userID, newToken, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	if newToken != "" {
+		if err = grpc.SetHeader(ctx, metadata.Pairs(TokenMetadataKey, newToken)); err != nil {
+			return nil, err
+		}
+	}
+
+	return handler(ContextWithUserID(ctx, userID), req)
+}
+
+// AuthStreamInterceptor auth to service by token in metadata. Issued token is sent in response header
+func AuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isInfraMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	userID, newToken, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	if newToken != "" {
+		if err = ss.SetHeader(metadata.Pairs(TokenMetadataKey, newToken)); err != nil {
+			return err
+		}
+	}
+
+	return handler(srv, &authStream{ServerStream: ss, ctx: ContextWithUserID(ss.Context(), userID)})
+}
+
+// ValidateUnaryInterceptor reject requests breaking their validation rules with InvalidArgument
+func ValidateUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if v, ok := req.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, problem.Error(fmt.Errorf("%w: %v", common.ErrInvalidRequest, err))
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// ValidateStreamInterceptor reject stream messages breaking their validation rules with InvalidArgument
+func ValidateStreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validateStream{ServerStream: ss})
+}
+
+// shortenMethods v1 and v2 methods limited by ShortenLimits
+var shortenMethods = map[string]bool{
+	"HandleUrl":    true,
+	"SaveShortUrl": true,
+	"BatchUrls":    true,
+	"Shorten":      true,
+	"BatchShorten": true,
+	"BulkShorten":  true,
+	"UpdateUrl":    true,
+}
+
+// methodLimits rate limit policy of full gRPC method name, nil when method is not limited
+func methodLimits(fullMethod string) *ratelimit.Policy {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	switch {
+	case shortenMethods[method]:
+		return ShortenLimits
+	case method == "GetUrl":
+		return RedirectLimits
+	}
+
+	return nil
+}
+
+// peerIP client IP of gRPC call. Address forwarded by REST gateway is used only for calls made over loopback,
+// where the gateway connects from, other callers can not set it
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return host
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+			return analytics.ForwardedIP(forwarded[0])
+		}
+	}
+
+	return host
+}
+
+// limitCall check limits of method for authenticated user. Seconds to wait are returned on ResourceExhausted
+func limitCall(ctx context.Context, fullMethod string) (metadata.MD, error) {
+	ok, wait := methodLimits(fullMethod).Allow(UserIDFromContext(ctx), peerIP(ctx))
+	if ok {
+		return nil, nil
+	}
+
+	retryAfter := ratelimit.RetryAfter(wait)
+	return metadata.Pairs(RetryAfterMetadataKey, strconv.Itoa(retryAfter)),
+		problem.Error(fmt.Errorf("%w: retry after %ds", common.ErrRateLimited, retryAfter))
+}
+
+// RateLimitUnaryInterceptor reject calls over limits with ResourceExhausted. Must be chained after auth
+func RateLimitUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, err := limitCall(ctx, info.FullMethod)
+	if err != nil {
+		if hErr := grpc.SetHeader(ctx, md); hErr != nil {
+			log.Printf("Retry-after header error: %v", hErr)
+		}
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// RateLimitStreamInterceptor reject streams over limits with ResourceExhausted, every stream counts as one call.
+// Must be chained after auth
+func RateLimitStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, err := limitCall(ss.Context(), info.FullMethod)
+	if err != nil {
+		if hErr := ss.SetHeader(md); hErr != nil {
+			log.Printf("Retry-after header error: %v", hErr)
+		}
+		return err
+	}
+
+	return handler(srv, ss)
+}
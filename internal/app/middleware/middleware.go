@@ -2,17 +2,22 @@ package middleware
 
 import (
 	"compress/gzip"
+	"errors"
 	"expvar"
 	"fmt"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/apikey"
 	"github.com/fd239/go_url_shortener/internal/app/common"
-	"github.com/fd239/go_url_shortener/internal/app/crypt"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/session"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
 	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
 	"github.com/gorilla/context"
 	"io"
 	"log"
 	"net/http"
 	"net/http/pprof"
+	"time"
 )
 
 type gzipWriter struct {
@@ -24,37 +29,119 @@ func (w gzipWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
-// AuthMiddleware auth to service by token in cookie
+// TokenCookieName cookie carrying encrypted user token
+const TokenCookieName = "token"
+
+// SetTokenCookie send user token in cookie living as long as the token
+func SetTokenCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     TokenCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(session.TTL().Seconds()),
+		HttpOnly: true,
+		Secure:   config.Cfg.SecureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// StartSession issue new token of user and send it in cookie
+func StartSession(w http.ResponseWriter, userID string) error {
+	token, err := session.New(userID, time.Now()).Token()
+	if err != nil {
+		return err
+	}
+
+	SetTokenCookie(w, token)
+	return nil
+}
+
+// LookupAPIKey find api key by hash. Api keys are rejected while it is nil
+var LookupAPIKey func(hash string) (storage.APIKey, error)
+
+// authenticateAPIKey find api key presented by client. Unknown key is reported as ErrInvalidAPIKey
+func authenticateAPIKey(key string) (storage.APIKey, error) {
+	if LookupAPIKey == nil {
+		return storage.APIKey{}, common.ErrInvalidAPIKey
+	}
+
+	found, err := LookupAPIKey(apikey.Hash(key))
+	if errors.Is(err, common.ErrAPIKeyNotFound) {
+		return storage.APIKey{}, common.ErrInvalidAPIKey
+	}
+
+	return found, err
+}
+
+// AuthMiddleware auth to service by api key in "Authorization: Bearer" header or by token in cookie.
+// Missing, broken or expired token starts new anonymous user, token past half of its lifetime is silently refreshed
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		decryptedUserID := ""
-		if tokenCookie, err := r.Cookie("token"); err == nil {
-			decryptedUserID, err = crypt.Decrypt(tokenCookie.Value)
-			if err != nil {
-				log.Printf("Decrypt error: %v\n", err)
-				http.Error(w, common.ErrUserCookie.Error(), http.StatusInternalServerError)
+		if header := r.Header.Get("Authorization"); header != "" {
+			key, ok := apikey.FromHeader(header)
+			found, err := authenticateAPIKey(key)
+			if !ok || errors.Is(err, common.ErrInvalidAPIKey) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				problem.Write(w, common.ErrInvalidAPIKey)
 				return
 			}
-		}
-		if len(decryptedUserID) == 0 {
-			decryptedUserID = uuid.NewString()
-			encryptedUserID, err := crypt.Encrypt(decryptedUserID)
 			if err != nil {
-				log.Printf("Crypt new user encrypt error: %v", err)
-				http.Error(w, common.ErrUserCookie.Error(), http.StatusInternalServerError)
+				problem.Write(w, err)
 				return
 			}
 
-			cookie := &http.Cookie{
-				Name:   "token",
-				Value:  encryptedUserID,
-				MaxAge: 300,
-			}
+			context.Set(r, "userID", found.UserID)
+			context.Set(r, "scopes", found.Scopes)
+			next.ServeHTTP(w, r)
+			return
+		}
 
-			http.SetCookie(w, cookie)
+		token := ""
+		if tokenCookie, err := r.Cookie(TokenCookieName); err == nil {
+			token = tokenCookie.Value
+		}
 
+		now := time.Now()
+		userID, newToken, err := session.Resolve(token, now)
+		if errors.Is(err, session.ErrTokenMalformed) {
+			log.Printf("User token rejected: %v\n", err)
+			userID, newToken, err = session.Resolve("", now)
+		}
+		if err != nil {
+			log.Printf("Crypt new user encrypt error: %v", err)
+			problem.Write(w, err)
+			return
+		}
+
+		if newToken != "" {
+			SetTokenCookie(w, newToken)
+		}
+		context.Set(r, "userID", userID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope reject requests made with api key lacking scope. User session may do anything
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, _ := context.Get(r, "scopes").([]string)
+			if !apikey.Allowed(scopes, scope) {
+				problem.Write(w, common.ErrScopeDenied)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession reject requests made with api key, account and keys are managed by user session only
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := context.GetOk(r, "scopes"); ok {
+			problem.Write(w, common.ErrSessionRequired)
+			return
 		}
-		context.Set(r, "userID", decryptedUserID)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -66,7 +153,7 @@ func DecompressMiddleware(next http.Handler) http.Handler {
 			gz, err := gzip.NewReader(r.Body)
 			if err != nil {
 				log.Printf("gzip body decode error: %v", err)
-				http.Error(w, common.ErrGzipRead.Error(), http.StatusInternalServerError)
+				problem.Write(w, common.ErrGzipRead)
 				return
 			}
 			r.Body = gz
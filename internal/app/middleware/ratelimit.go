@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/analytics"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/ratelimit"
+	"github.com/gorilla/context"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ShortenLimits limits of shortening requests. Nothing is limited while it is nil
+	ShortenLimits *ratelimit.Policy
+	// RedirectLimits limits of short url redirects. Nothing is limited while it is nil
+	RedirectLimits *ratelimit.Policy
+)
+
+// rateLimit reject requests over limits of policy with 429 and Retry-After header
+func rateLimit(next http.Handler, policy func() *ratelimit.Policy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := fmt.Sprintf("%v", context.Get(r, "userID"))
+		if ok, wait := policy().Allow(userID, analytics.ClientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(wait)))
+			problem.Write(w, common.ErrRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitShorten apply ShortenLimits by user and client IP
+func LimitShorten(next http.Handler) http.Handler {
+	return rateLimit(next, func() *ratelimit.Policy { return ShortenLimits })
+}
+
+// LimitRedirect apply RedirectLimits by user and client IP
+func LimitRedirect(next http.Handler) http.Handler {
+	return rateLimit(next, func() *ratelimit.Policy { return RedirectLimits })
+}
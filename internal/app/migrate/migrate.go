@@ -0,0 +1,299 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FS migrations shipped with the service
+//
+//go:embed migrations/*.sql
+var FS embed.FS
+
+// lockID postgres advisory lock key held while migrations run
+const lockID = 7239001
+
+const createVersionsStmt = `CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version    bigint PRIMARY KEY,
+			name       varchar(255) NOT NULL,
+			applied_at timestamptz  NOT NULL DEFAULT now()
+		)`
+const lockStmt = `SELECT pg_advisory_lock($1)`
+const unlockStmt = `SELECT pg_advisory_unlock($1)`
+const getVersionsStmt = `SELECT version, applied_at FROM schema_migrations ORDER BY version`
+const insertVersionStmt = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+const deleteVersionStmt = `DELETE FROM schema_migrations WHERE version = $1`
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var (
+	ErrBadFileName  = errors.New("migration file name must look like 0001_name.up.sql")
+	ErrIncomplete   = errors.New("migration must have both up and down files")
+	ErrDuplicate    = errors.New("duplicate migration version")
+	ErrUnknownApply = errors.New("applied migration version is unknown")
+)
+
+// Migration single schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status migration with its state in database
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations to postgres. Concurrent migrators are serialized by advisory lock
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// Load read migrations from directory "migrations" of fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parts := fileName.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBadFileName, entry.Name())
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrBadFileName, entry.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicate, version)
+		}
+
+		if parts[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: %d_%s", ErrIncomplete, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// New create migrator of embedded migrations
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load(FS)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithMigrations(db, migrations), nil
+}
+
+// NewWithMigrations create migrator of given migrations
+func NewWithMigrations(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up apply all pending migrations, each in its own transaction. Returns number of applied migrations
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			err = inTx(ctx, conn, migration.Up, insertVersionStmt, migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("Migration %d_%s applied", migration.Version, migration.Name)
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down roll back the latest applied migration. Returns false if nothing is applied
+func (m *Migrator) Down(ctx context.Context) (bool, error) {
+	rolledBack := false
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		var latest int64 = -1
+		for version := range versions {
+			if version > latest {
+				latest = version
+			}
+		}
+		if latest < 0 {
+			return nil
+		}
+
+		migration, ok := m.find(latest)
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrUnknownApply, latest)
+		}
+
+		err = inTx(ctx, conn, migration.Down, deleteVersionStmt, migration.Version)
+		if err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Migration %d_%s rolled back", migration.Version, migration.Name)
+		rolledBack = true
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status state of every known migration
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			appliedAt, ok := versions[migration.Version]
+			statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// locked run fn on a single connection holding the migrations advisory lock
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, lockStmt, lockID); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), unlockStmt, lockID); err != nil {
+			log.Printf("Migrations unlock error: %v", err)
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, createVersionsStmt); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions applied migration versions with their apply time
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, getVersionsStmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// inTx run migration script and version bookkeeping statement in one transaction
+func inTx(ctx context.Context, conn *sql.Conn, script string, versionStmt string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, versionStmt, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
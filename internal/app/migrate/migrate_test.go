@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create", Up: "CREATE TABLE t (id int)", Down: "DROP TABLE t"},
+	{Version: 2, Name: "widen", Up: "ALTER TABLE t ALTER COLUMN id TYPE bigint", Down: "ALTER TABLE t ALTER COLUMN id TYPE int"},
+}
+
+func expectLocked(mock sqlmock.Sqlmock, versions *sqlmock.Rows) {
+	mock.ExpectExec(regexp.QuoteMeta(lockStmt)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(createVersionsStmt)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(getVersionsStmt)).WillReturnRows(versions)
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta(unlockStmt)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func versionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"version", "applied_at"})
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr error
+	}{
+		{
+			name: "OK",
+			fsys: fstest.MapFS{
+				"migrations/0002_widen.up.sql":   {Data: []byte("up 2")},
+				"migrations/0002_widen.down.sql": {Data: []byte("down 2")},
+				"migrations/0001_init.up.sql":    {Data: []byte("up 1")},
+				"migrations/0001_init.down.sql":  {Data: []byte("down 1")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+				{Version: 2, Name: "widen", Up: "up 2", Down: "down 2"},
+			},
+		},
+		{
+			name:    "Bad file name",
+			fsys:    fstest.MapFS{"migrations/init.sql": {Data: []byte("up")}},
+			wantErr: ErrBadFileName,
+		},
+		{
+			name:    "Missing down",
+			fsys:    fstest.MapFS{"migrations/0001_init.up.sql": {Data: []byte("up")}},
+			wantErr: ErrIncomplete,
+		},
+		{
+			name: "Duplicate version",
+			fsys: fstest.MapFS{
+				"migrations/0001_init.up.sql":  {Data: []byte("up")},
+				"migrations/0001_other.up.sql": {Data: []byte("up")},
+			},
+			wantErr: ErrDuplicate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load(FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, int64(i+1), m.Version, "migration versions must have no gaps")
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLocked(mock, versionRows().AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(testMigrations[1].Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(insertVersionStmt)).WithArgs(2, "widen").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	applied, err := NewWithMigrations(db, testMigrations).Up(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	errTest := errors.New("syntax error")
+	expectLocked(mock, versionRows())
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(testMigrations[0].Up)).WillReturnError(errTest)
+	mock.ExpectRollback()
+	expectUnlock(mock)
+
+	applied, err := NewWithMigrations(db, testMigrations).Up(context.Background())
+	assert.ErrorIs(t, err, errTest)
+	assert.Zero(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLocked(mock, versionRows().AddRow(1, time.Now()).AddRow(2, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(testMigrations[1].Down)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(deleteVersionStmt)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	rolledBack, err := NewWithMigrations(db, testMigrations).Down(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, rolledBack)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_DownNothingApplied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLocked(mock, versionRows())
+	expectUnlock(mock)
+
+	rolledBack, err := NewWithMigrations(db, testMigrations).Down(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, rolledBack)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	appliedAt := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	expectLocked(mock, versionRows().AddRow(1, appliedAt))
+	expectUnlock(mock)
+
+	statuses, err := NewWithMigrations(db, testMigrations).Status(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Status{
+		{Migration: testMigrations[0], Applied: true, AppliedAt: appliedAt},
+		{Migration: testMigrations[1]},
+	}, statuses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
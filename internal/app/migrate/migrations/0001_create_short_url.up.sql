@@ -0,0 +1,9 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE TABLE IF NOT EXISTS short_url
+(
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    deleted      bool             DEFAULT false,
+    original_url varchar(150) UNIQUE NOT NULL,
+    short_url    varchar(50)         NOT NULL,
+    user_id      varchar(50)
+);
@@ -0,0 +1,2 @@
+DROP SEQUENCE IF EXISTS short_url_seq;
+DROP INDEX IF EXISTS short_url_short_url_key;
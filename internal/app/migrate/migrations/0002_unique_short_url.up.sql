@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS short_url_short_url_key ON short_url (short_url);
+CREATE SEQUENCE IF NOT EXISTS short_url_seq;
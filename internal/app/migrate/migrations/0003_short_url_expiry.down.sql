@@ -0,0 +1 @@
+ALTER TABLE short_url DROP COLUMN IF EXISTS expires_at;
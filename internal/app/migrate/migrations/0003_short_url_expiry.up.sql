@@ -0,0 +1 @@
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS expires_at timestamptz;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS clicks
+(
+    id         bigserial PRIMARY KEY,
+    short_url  varchar(50) NOT NULL,
+    clicked_at timestamptz NOT NULL,
+    referrer   text,
+    user_agent text,
+    ip         varchar(50)
+);
+CREATE INDEX IF NOT EXISTS clicks_short_url_idx ON clicks (short_url);
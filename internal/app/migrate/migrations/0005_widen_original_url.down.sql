@@ -0,0 +1 @@
+ALTER TABLE short_url ALTER COLUMN original_url TYPE varchar(150);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS short_url_user_created_idx;
+ALTER TABLE short_url DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,2 @@
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS short_url_user_created_idx ON short_url (user_id, created_at, short_url);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS accounts
+(
+    email         varchar(320) PRIMARY KEY,
+    password_hash text        NOT NULL,
+    user_id       text        NOT NULL UNIQUE,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         varchar(32) PRIMARY KEY,
+    user_id    text        NOT NULL,
+    name       text        NOT NULL DEFAULT '',
+    prefix     varchar(16) NOT NULL,
+    key_hash   char(64)    NOT NULL UNIQUE,
+    scopes     text[]      NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id, created_at);
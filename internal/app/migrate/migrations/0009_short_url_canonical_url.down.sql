@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS short_url_canonical_url_key;
+ALTER TABLE short_url ADD CONSTRAINT short_url_original_url_key UNIQUE (original_url);
+ALTER TABLE short_url DROP COLUMN IF EXISTS canonical_url;
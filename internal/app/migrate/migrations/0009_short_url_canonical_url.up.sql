@@ -0,0 +1,6 @@
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS canonical_url text;
+-- Existing urls are copied as is, they are not canonicalized
+UPDATE short_url SET canonical_url = original_url WHERE canonical_url IS NULL;
+ALTER TABLE short_url ALTER COLUMN canonical_url SET NOT NULL;
+ALTER TABLE short_url DROP CONSTRAINT IF EXISTS short_url_original_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS short_url_canonical_url_key ON short_url (canonical_url);
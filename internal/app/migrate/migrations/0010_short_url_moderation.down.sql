@@ -0,0 +1,3 @@
+ALTER TABLE short_url DROP COLUMN IF EXISTS moderation_legal;
+ALTER TABLE short_url DROP COLUMN IF EXISTS moderation_reason;
+ALTER TABLE short_url DROP COLUMN IF EXISTS disabled_at;
@@ -0,0 +1,3 @@
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS disabled_at timestamptz;
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS moderation_reason text NOT NULL DEFAULT '';
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS moderation_legal boolean NOT NULL DEFAULT false;
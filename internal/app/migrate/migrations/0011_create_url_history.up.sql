@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS url_history
+(
+    id           bigserial PRIMARY KEY,
+    short_url    varchar(50) NOT NULL,
+    previous_url text        NOT NULL,
+    original_url text        NOT NULL,
+    user_id      text        NOT NULL,
+    changed_at   timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS url_history_short_url_idx ON url_history (short_url, changed_at);
@@ -0,0 +1,5 @@
+ALTER TABLE short_url ADD COLUMN IF NOT EXISTS click_count bigint NOT NULL DEFAULT 0;
+UPDATE short_url SET click_count = c.count
+    FROM (SELECT short_url, count(*) AS count FROM clicks GROUP BY short_url) AS c
+    WHERE short_url.short_url = c.short_url;
+CREATE INDEX IF NOT EXISTS short_url_user_clicks_idx ON short_url (user_id, click_count, short_url);
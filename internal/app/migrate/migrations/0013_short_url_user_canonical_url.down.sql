@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS short_url_user_canonical_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS short_url_canonical_url_key ON short_url (canonical_url);
@@ -0,0 +1,6 @@
+-- Urls are deduplicated per user among links which are not deleted: other users shorten the same url
+-- independently and deleted link does not block shortening its url again.
+-- Rows saved before 0009 keep their original url as canonical form, they are not canonicalized and
+-- do not deduplicate against normalized inserts.
+DROP INDEX IF EXISTS short_url_canonical_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS short_url_user_canonical_url_key ON short_url (user_id, canonical_url) WHERE deleted IS NOT TRUE;
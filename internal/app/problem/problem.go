@@ -0,0 +1,156 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+)
+
+const (
+	// ContentType media type of error responses, RFC 7807
+	ContentType = "application/problem+json"
+	// Domain error domain of gRPC ErrorInfo details
+	Domain = "go_url_shortener"
+	// CodeInternal code of errors not known to the mapping, their text is never sent to clients
+	CodeInternal = "internal"
+)
+
+// Problem error response body. Code is stable machine-readable error code, Detail is human-readable message
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// kind error code and statuses of sentinel error
+type kind struct {
+	err    error
+	code   string
+	status int
+	grpc   codes.Code
+}
+
+// kinds mapping of sentinel errors, checked in order with errors.Is
+var kinds = []kind{
+	{common.ErrInvalidRequest, "invalid_request", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrEmptyBody, "empty_body", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrBodyReadError, "invalid_body", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrGzipRead, "invalid_encoding", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidURL, "invalid_url", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidAlias, "invalid_alias", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidExpiry, "invalid_expiry", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidCursor, "invalid_cursor", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidSort, "invalid_sort", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidPageSize, "invalid_page_size", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidAccount, "invalid_account", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrInvalidScope, "invalid_scope", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrBlockedURL, "blocked_url", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrSelfReference, "self_reference", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrRedirectChain, "redirect_chain_too_deep", http.StatusBadRequest, codes.InvalidArgument},
+	{common.ErrUserCookie, "invalid_session", http.StatusUnauthorized, codes.Unauthenticated},
+	{common.ErrInvalidCredentials, "invalid_credentials", http.StatusUnauthorized, codes.Unauthenticated},
+	{common.ErrInvalidAPIKey, "invalid_api_key", http.StatusUnauthorized, codes.Unauthenticated},
+	{common.ErrScopeDenied, "scope_denied", http.StatusForbidden, codes.PermissionDenied},
+	{common.ErrSessionRequired, "session_required", http.StatusForbidden, codes.PermissionDenied},
+	{common.ErrUntrustedNetwork, "untrusted_network", http.StatusForbidden, codes.PermissionDenied},
+	{common.ErrUnableToFindURL, "url_not_found", http.StatusNotFound, codes.NotFound},
+	{common.ErrJobNotFound, "job_not_found", http.StatusNotFound, codes.NotFound},
+	{common.ErrAccountNotFound, "account_not_found", http.StatusNotFound, codes.NotFound},
+	{common.ErrAPIKeyNotFound, "api_key_not_found", http.StatusNotFound, codes.NotFound},
+	{common.ErrRouteNotFound, "route_not_found", http.StatusNotFound, codes.Unimplemented},
+	{common.ErrMethodNotAllowed, "method_not_allowed", http.StatusMethodNotAllowed, codes.Unimplemented},
+	{common.ErrOriginalURLConflict, "url_exists", http.StatusConflict, codes.AlreadyExists},
+	{common.ErrAliasConflict, "alias_taken", http.StatusConflict, codes.AlreadyExists},
+	{common.ErrAccountExists, "account_exists", http.StatusConflict, codes.AlreadyExists},
+	{common.ErrAlreadyRegistered, "already_registered", http.StatusConflict, codes.AlreadyExists},
+	{common.ErrURLDeleted, "url_deleted", http.StatusGone, codes.FailedPrecondition},
+	{common.ErrURLExpired, "url_expired", http.StatusGone, codes.FailedPrecondition},
+	{common.ErrURLDisabled, "url_disabled", http.StatusGone, codes.FailedPrecondition},
+	{common.ErrURLLegal, "url_unavailable_for_legal_reasons", http.StatusUnavailableForLegalReasons, codes.FailedPrecondition},
+	{common.ErrRateLimited, "rate_limited", http.StatusTooManyRequests, codes.ResourceExhausted},
+	{deleter.ErrQueueFull, "queue_full", http.StatusServiceUnavailable, codes.ResourceExhausted},
+	{deleter.ErrQueueClosed, "queue_closed", http.StatusServiceUnavailable, codes.Unavailable},
+	{common.ErrShortIDCollision, "short_url_exhausted", http.StatusServiceUnavailable, codes.Unavailable},
+	{common.ErrPing, "storage_unavailable", http.StatusServiceUnavailable, codes.Unavailable},
+}
+
+// lookup kind of error. Unknown errors are internal, they are logged and their text is hidden
+func lookup(err error) (kind, string) {
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k, err.Error()
+		}
+	}
+
+	log.Printf("Internal error: %v\n", err)
+	return kind{code: CodeInternal, status: http.StatusInternalServerError, grpc: codes.Internal}, "internal error"
+}
+
+// Code machine-readable code of error
+func Code(err error) string {
+	k, _ := lookup(err)
+	return k.code
+}
+
+// New problem describing error
+func New(err error) Problem {
+	k, detail := lookup(err)
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(k.status),
+		Status: k.status,
+		Code:   k.code,
+		Detail: detail,
+	}
+}
+
+// Write respond with problem describing error
+func Write(w http.ResponseWriter, err error) {
+	p := New(err)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	if err = json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("json.Encode: %v\n", err)
+	}
+}
+
+// NotFound chi handler of unknown routes
+func NotFound(w http.ResponseWriter, _ *http.Request) {
+	Write(w, common.ErrRouteNotFound)
+}
+
+// MethodNotAllowed chi handler of known routes requested with wrong method
+func MethodNotAllowed(w http.ResponseWriter, _ *http.Request) {
+	Write(w, common.ErrMethodNotAllowed)
+}
+
+// Status gRPC status of error, with error code in ErrorInfo details
+func Status(err error) *status.Status {
+	k, detail := lookup(err)
+
+	st := status.New(k.grpc, detail)
+	withInfo, infoErr := st.WithDetails(&errdetails.ErrorInfo{Reason: k.code, Domain: Domain})
+	if infoErr != nil {
+		log.Printf("gRPC error details error: %v\n", infoErr)
+		return st
+	}
+
+	return withInfo
+}
+
+// Error gRPC status error of error
+func Error(err error) error {
+	return Status(err).Err()
+}
@@ -0,0 +1,74 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{name: "Sentinel", err: common.ErrUnableToFindURL, wantStatus: http.StatusNotFound, wantCode: "url_not_found", wantDetail: common.ErrUnableToFindURL.Error()},
+		{name: "Wrapped", err: fmt.Errorf("%w: retry after 2s", common.ErrRateLimited), wantStatus: http.StatusTooManyRequests, wantCode: "rate_limited", wantDetail: "too many requests, retry later: retry after 2s"},
+		{name: "Queue", err: deleter.ErrQueueFull, wantStatus: http.StatusServiceUnavailable, wantCode: "queue_full", wantDetail: deleter.ErrQueueFull.Error()},
+		{name: "Unknown is hidden", err: errors.New("pq: password authentication failed"), wantStatus: http.StatusInternalServerError, wantCode: CodeInternal, wantDetail: "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Write(w, tt.err)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+			var p Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, Problem{
+				Type:   "about:blank",
+				Title:  http.StatusText(tt.wantStatus),
+				Status: tt.wantStatus,
+				Code:   tt.wantCode,
+				Detail: tt.wantDetail,
+			}, p)
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	st := Status(common.ErrAliasConflict)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	assert.Equal(t, common.ErrAliasConflict.Error(), st.Message())
+
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "alias_taken", info.Reason)
+	assert.Equal(t, Domain, info.Domain)
+
+	assert.Equal(t, codes.Internal, Status(errors.New("disk is full")).Code())
+}
+
+func TestKinds_Unique(t *testing.T) {
+	seen := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		assert.False(t, seen[k.code], "duplicate code %s", k.code)
+		seen[k.code] = true
+		assert.NotEmpty(t, http.StatusText(k.status), k.code)
+	}
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"expvar"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval how often buckets refilled to burst are forgotten
+const sweepInterval = time.Minute
+
+// metrics allowed and limited requests counters by policy, key kind and outcome, e.g. "shorten_ip_limited"
+var metrics = expvar.NewMap("rate_limit")
+
+// bucket tokens left to key at the time of last request
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter token bucket per key. Every key may spend burst requests at once, tokens are refilled at rate per second
+type Limiter struct {
+	name  string
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter create limiter named in metrics. Zero rate or burst disables limiter, nil is returned
+func NewLimiter(name string, rate float64, burst int) *Limiter {
+	if rate <= 0 || burst <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		name:    name,
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow spend token of key. When key has no tokens left, time until next token is returned
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		metrics.Add(l.name+"_limited", 1)
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+	metrics.Add(l.name+"_allowed", 1)
+	return true, 0
+}
+
+// refund give back token spent by key on request rejected by another limit
+func (l *Limiter) refund(key string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(l.burst, b.tokens+1)
+		metrics.Add(l.name+"_allowed", -1)
+	}
+}
+
+// sweep forget buckets which have been refilled to burst, they are the same as new ones. Must be called with mu held
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Policy limits of one kind of requests, by user ID and by client IP. Nil limiters are not applied
+type Policy struct {
+	User *Limiter
+	IP   *Limiter
+}
+
+// NewPolicy create policy named in metrics. Zero rate disables the limit
+func NewPolicy(name string, userRate float64, userBurst int, ipRate float64, ipBurst int) *Policy {
+	return &Policy{
+		User: NewLimiter(name+"_user", userRate, userBurst),
+		IP:   NewLimiter(name+"_ip", ipRate, ipBurst),
+	}
+}
+
+// Allow check user limit, then IP limit. Request rejected by IP limit does not spend user token
+func (p *Policy) Allow(userID string, ip string) (bool, time.Duration) {
+	if p == nil {
+		return true, 0
+	}
+
+	if userID != "" {
+		if ok, wait := p.User.Allow(userID); !ok {
+			return false, wait
+		}
+	}
+	if ip != "" {
+		if ok, wait := p.IP.Allow(ip); !ok {
+			if userID != "" {
+				p.User.refund(userID)
+			}
+			return false, wait
+		}
+	}
+
+	return true, 0
+}
+
+// RetryAfter whole seconds to wait, as sent in Retry-After header
+func RetryAfter(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+
+	return seconds
+}
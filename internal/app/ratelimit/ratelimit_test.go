@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter("test", 2, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("a")
+		assert.True(t, ok, "burst request %d", i)
+	}
+
+	ok, wait := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _ = l.Allow("b")
+	assert.True(t, ok, "keys have their own buckets")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok, "token refilled")
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+	assert.Len(t, l.buckets, 1, "idle buckets are forgotten")
+	assert.Equal(t, 2.0, l.buckets["a"].tokens, "bucket never holds more than burst")
+}
+
+func TestNewLimiter_Disabled(t *testing.T) {
+	assert.Nil(t, NewLimiter("test", 0, 10))
+	assert.Nil(t, NewLimiter("test", 10, 0))
+
+	var l *Limiter
+	ok, _ := l.Allow("a")
+	assert.True(t, ok)
+}
+
+func TestPolicy_Allow(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPolicy("test", 1, 1, 0.5, 2)
+	p.User.now = func() time.Time { return now }
+	p.IP.now = func() time.Time { return now }
+
+	ok, _ := p.Allow("user", "10.0.0.1")
+	assert.True(t, ok)
+
+	ok, wait := p.Allow("user", "10.0.0.1")
+	assert.False(t, ok, "user limit exceeded")
+	assert.Equal(t, time.Second, wait)
+
+	ok, _ = p.Allow("other", "10.0.0.1")
+	assert.True(t, ok, "request rejected by user limit does not spend ip token")
+
+	ok, wait = p.Allow("third", "10.0.0.1")
+	assert.False(t, ok, "ip limit exceeded")
+	assert.Equal(t, 2*time.Second, wait)
+
+	ok, _ = p.Allow("third", "10.0.0.2")
+	assert.True(t, ok, "request rejected by ip limit does not spend user token")
+	ok, _ = p.Allow("third", "10.0.0.3")
+	assert.False(t, ok)
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, 1, RetryAfter(0))
+	assert.Equal(t, 1, RetryAfter(200*time.Millisecond))
+	assert.Equal(t, 3, RetryAfter(2100*time.Millisecond))
+}
@@ -0,0 +1,207 @@
+package safety
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rule blocklist line. Exactly one of domain, glob and re is set
+type rule struct {
+	allow  bool
+	domain string
+	glob   string
+	re     *regexp.Regexp
+}
+
+// match rule matches url of host
+func (r rule) match(host string, canonical string) bool {
+	switch {
+	case r.re != nil:
+		return r.re.MatchString(canonical)
+	case r.glob != "":
+		ok, _ := path.Match(r.glob, host)
+		return ok
+	}
+
+	return matchDomain(r.domain, host)
+}
+
+// matchDomain host is domain or its subdomain
+func matchDomain(domain string, host string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// parseRules read blocklist, one rule per line:
+//
+//	example.com      block domain and its subdomains
+//	*.example.*      block hosts matching glob
+//	/^https?://x/    block canonical urls matching regular expression
+//	!good.example    allow rule, allowed urls are never blocked
+//
+// Empty lines and lines starting with # are skipped
+func parseRules(r io.Reader) ([]rule, error) {
+	var rules []rule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rl rule
+		if strings.HasPrefix(line, "!") {
+			rl.allow = true
+			line = strings.TrimSpace(line[1:])
+		}
+
+		switch {
+		case len(line) > 2 && strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/"):
+			re, err := regexp.Compile(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			rl.re = re
+		case strings.ContainsAny(line, "*?["):
+			if _, err := path.Match(line, ""); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			rl.glob = strings.ToLower(line)
+		case line != "":
+			rl.domain = strings.TrimSuffix(strings.ToLower(line), ".")
+		default:
+			return nil, fmt.Errorf("line %d: empty rule", n)
+		}
+
+		rules = append(rules, rl)
+	}
+
+	return rules, scanner.Err()
+}
+
+// List blocklist of url destinations loaded from local file. File is reloaded when it changes
+type List struct {
+	path string
+
+	mu      sync.RWMutex
+	rules   []rule
+	modTime time.Time
+}
+
+// LoadList load blocklist from file. Empty path is empty list
+func LoadList(path string) (*List, error) {
+	l := &List{path: path}
+	if path == "" {
+		return l, nil
+	}
+
+	if _, err := l.Reload(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// NewList blocklist of rules read from r, it is never reloaded
+func NewList(r io.Reader) (*List, error) {
+	rules, err := parseRules(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &List{rules: rules}, nil
+}
+
+// Reload read blocklist file again if it was modified since last load. Rules are kept if file is invalid
+func (l *List) Reload() (bool, error) {
+	if l.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return false, err
+	}
+
+	l.mu.RLock()
+	unchanged := info.ModTime().Equal(l.modTime)
+	l.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	rules, err := parseRules(f)
+	if err != nil {
+		return false, fmt.Errorf("blocklist %s: %w", l.path, err)
+	}
+
+	l.mu.Lock()
+	l.rules = rules
+	l.modTime = info.ModTime()
+	l.mu.Unlock()
+
+	return true, nil
+}
+
+// Watch reload blocklist file every interval until ctx is done
+func (l *List) Watch(ctx context.Context, interval time.Duration) {
+	if l.path == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := l.Reload()
+			if err != nil {
+				log.Printf("Blocklist reload error: %v\n", err)
+				continue
+			}
+			if reloaded {
+				log.Printf("Blocklist %s reloaded\n", l.path)
+			}
+		}
+	}
+}
+
+// Blocked url of host is matched by block rule and is not matched by any allow rule
+func (l *List) Blocked(host string, canonical string) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	blocked := false
+	for _, r := range l.rules {
+		if !r.match(host, canonical) {
+			continue
+		}
+		if r.allow {
+			return false
+		}
+		blocked = true
+	}
+
+	return blocked
+}
@@ -0,0 +1,294 @@
+package safety
+
+import (
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/fd239/go_url_shortener/internal/app/urlnorm"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResolveCacheTTL time resolved shortener link is reused without new request
+	DefaultResolveCacheTTL = 10 * time.Minute
+	// DefaultMaxResolves shortener links resolved at the same time
+	DefaultMaxResolves = 8
+	// resolveCacheSize most shortener links kept in cache
+	resolveCacheSize = 10000
+)
+
+// Options destination checks settings
+type Options struct {
+	// BaseURL of this shortener, urls pointing at it are loops
+	BaseURL string
+	// Shorteners domains of known url shorteners, their links are followed to find final destination
+	Shorteners []string
+	// MaxDepth number of shortener links url may go through before its destination
+	MaxDepth int
+	// ResolveTimeout timeout of one shortener link resolution, also the longest wait for free resolution slot
+	ResolveTimeout time.Duration
+	// ResolveCacheTTL time resolved shortener link is reused, DefaultResolveCacheTTL if not set
+	ResolveCacheTTL time.Duration
+	// MaxResolves shortener links resolved at the same time, DefaultMaxResolves if not set
+	MaxResolves int
+}
+
+// resolution outcome of shortener link request
+type resolution struct {
+	location string
+	ok       bool
+	expires  time.Time
+}
+
+// Checker checks destinations of urls before they are shortened
+type Checker struct {
+	list       *List
+	self       *url.URL
+	shorteners []string
+	maxDepth   int
+	client     *http.Client
+	resolves   chan struct{}
+	cacheTTL   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]resolution
+}
+
+// NewChecker checker of destinations against blocklist
+func NewChecker(list *List, opts Options) *Checker {
+	if opts.ResolveCacheTTL <= 0 {
+		opts.ResolveCacheTTL = DefaultResolveCacheTTL
+	}
+	if opts.MaxResolves <= 0 {
+		opts.MaxResolves = DefaultMaxResolves
+	}
+
+	c := &Checker{
+		list:     list,
+		maxDepth: opts.MaxDepth,
+		client: &http.Client{
+			Timeout: opts.ResolveTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		resolves: make(chan struct{}, opts.MaxResolves),
+		cacheTTL: opts.ResolveCacheTTL,
+		cache:    make(map[string]resolution),
+	}
+
+	if _, canonical, err := urlnorm.Normalize(opts.BaseURL); err == nil {
+		if u, err := url.Parse(canonical); err == nil {
+			c.self = u
+		}
+	}
+
+	for _, domain := range opts.Shorteners {
+		domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			c.shorteners = append(c.shorteners, domain)
+		}
+	}
+
+	return c
+}
+
+// Check url may be shortened: it is valid, does not point at this shortener and its destination is not blocked.
+// Links of known shorteners are followed up to max depth. Links which can not be resolved are accepted
+func (c *Checker) Check(raw string) error {
+	return c.check(raw, true)
+}
+
+// check url, links of known shorteners are followed only if follow is set and accepted as unresolved otherwise
+func (c *Checker) check(raw string, follow bool) error {
+	_, canonical, err := urlnorm.Normalize(raw)
+	if err != nil {
+		return err
+	}
+
+	for depth := 0; ; depth++ {
+		u, err := url.Parse(canonical)
+		if err != nil {
+			return common.ErrInvalidURL
+		}
+
+		if c.selfReference(u) {
+			return common.ErrSelfReference
+		}
+		if c.list.Blocked(u.Hostname(), canonical) {
+			return fmt.Errorf("%w: %s", common.ErrBlockedURL, u.Hostname())
+		}
+		if !c.shortener(u.Hostname()) {
+			return nil
+		}
+		if depth >= c.maxDepth {
+			return common.ErrRedirectChain
+		}
+		if !follow {
+			return nil
+		}
+
+		next, ok := c.resolve(u)
+		if !ok {
+			return nil
+		}
+		if _, canonical, err = urlnorm.Normalize(next); err != nil {
+			return nil
+		}
+	}
+}
+
+// selfReference url points at this shortener: it has scheme, host and port of base url and path under base url path
+func (c *Checker) selfReference(u *url.URL) bool {
+	if c.self == nil || u.Scheme != c.self.Scheme || u.Host != c.self.Host {
+		return false
+	}
+
+	base := strings.TrimSuffix(c.self.Path, "/")
+	return base == "" || u.Path == base || strings.HasPrefix(u.Path, base+"/")
+}
+
+// shortener host belongs to known url shortener
+func (c *Checker) shortener(host string) bool {
+	for _, domain := range c.shorteners {
+		if matchDomain(domain, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolve location short url of other shortener redirects to. False if it does not redirect.
+// Answers are cached, requests are made by limited number of callers at once, the rest wait for resolve timeout
+// and accept link as unresolved
+func (c *Checker) resolve(u *url.URL) (string, bool) {
+	link := u.String()
+	if r, ok := c.cached(link); ok {
+		return r.location, r.ok
+	}
+
+	if !c.acquire() {
+		log.Printf("Shortener link resolve skipped, too many links are resolved: %s\n", link)
+		return "", false
+	}
+	defer func() { <-c.resolves }()
+
+	resp, err := c.client.Head(link)
+	if err != nil {
+		log.Printf("Shortener link resolve error: %v\n", err)
+		return "", false
+	}
+	resp.Body.Close()
+
+	r := resolution{expires: time.Now().Add(c.cacheTTL)}
+	if resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode < http.StatusBadRequest {
+		if location, err := resp.Location(); err == nil {
+			r.location, r.ok = location.String(), true
+		}
+	}
+	c.remember(link, r)
+
+	return r.location, r.ok
+}
+
+// acquire take resolution slot, waiting for it no longer than resolve timeout
+func (c *Checker) acquire() bool {
+	select {
+	case c.resolves <- struct{}{}:
+		return true
+	default:
+	}
+
+	if c.client.Timeout <= 0 {
+		c.resolves <- struct{}{}
+		return true
+	}
+
+	timer := time.NewTimer(c.client.Timeout)
+	defer timer.Stop()
+	select {
+	case c.resolves <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// cached resolution of shortener link which is not expired
+func (c *Checker) cached(link string) (resolution, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.cache[link]
+	if !ok || !time.Now().Before(r.expires) {
+		return resolution{}, false
+	}
+
+	return r, true
+}
+
+// remember resolution of shortener link. Expired entries are dropped when cache is full, whole cache if none expired
+func (c *Checker) remember(link string, r resolution) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cache) >= resolveCacheSize {
+		now := time.Now()
+		for cachedLink, cached := range c.cache {
+			if !now.Before(cached.expires) {
+				delete(c.cache, cachedLink)
+			}
+		}
+		if len(c.cache) >= resolveCacheSize {
+			c.cache = make(map[string]resolution)
+		}
+	}
+	c.cache[link] = r
+}
+
+// guarded repository which checks destinations of urls before inserting them
+type guarded struct {
+	storage.Repository
+	checker *Checker
+}
+
+// Guard repository which rejects urls not passing checker
+func Guard(repo storage.Repository, checker *Checker) storage.Repository {
+	return &guarded{Repository: repo, checker: checker}
+}
+
+// Insert check url and insert it
+func (g *guarded) Insert(item string, userID string, opts storage.InsertOptions) (string, error) {
+	if err := g.checker.Check(item); err != nil {
+		return "", err
+	}
+
+	return g.Repository.Insert(item, userID, opts)
+}
+
+// CreateItems check all urls of batch and insert them. Batch is rejected if any url fails check.
+// Shortener links of batches are not followed, so large batches make no outbound requests
+func (g *guarded) CreateItems(items []storage.BatchItemRequest, userID string) ([]storage.BatchItemResponse, error) {
+	for _, item := range items {
+		if err := g.checker.check(item.OriginalURL, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.Repository.CreateItems(items, userID)
+}
+
+// UpdateURL check new destination and change it
+func (g *guarded) UpdateURL(change storage.URLChange) (storage.URLChange, error) {
+	if err := g.checker.Check(change.OriginalURL); err != nil {
+		return storage.URLChange{}, err
+	}
+
+	return g.Repository.UpdateURL(change)
+}
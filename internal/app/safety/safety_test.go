@@ -0,0 +1,245 @@
+package safety
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRules = `# test blocklist
+malware.example
+*.phish.*
+/^https://docs\.example/private/
+!safe.malware.example
+`
+
+func TestList_Blocked(t *testing.T) {
+	l, err := NewList(strings.NewReader(testRules))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{name: "Domain", url: "https://malware.example/a", want: true},
+		{name: "Subdomain", url: "https://cdn.malware.example/a", want: true},
+		{name: "Allowed subdomain", url: "https://safe.malware.example/a", want: false},
+		{name: "Other domain with same suffix", url: "https://notmalware.example/a", want: false},
+		{name: "Glob", url: "https://login.phish.io/", want: true},
+		{name: "Regexp", url: "https://docs.example/private/report", want: true},
+		{name: "Regexp other path", url: "https://docs.example/public/report", want: false},
+		{name: "Clean", url: common.TestURL, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, l.Blocked(u.Hostname(), tt.url))
+		})
+	}
+
+	var empty *List
+	assert.False(t, empty.Blocked("malware.example", "https://malware.example/"))
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	for _, rules := range []string{"/(/", "[a-", "!"} {
+		_, err := NewList(strings.NewReader(rules))
+		assert.Error(t, err, rules)
+	}
+}
+
+func TestList_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist")
+	require.NoError(t, os.WriteFile(path, []byte("malware.example\n"), 0600))
+
+	l, err := LoadList(path)
+	require.NoError(t, err)
+	assert.True(t, l.Blocked("malware.example", "https://malware.example/"))
+
+	reloaded, err := l.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded, "file is not changed")
+
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.WriteFile(path, []byte("other.example\n"), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	reloaded, err = l.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.False(t, l.Blocked("malware.example", "https://malware.example/"))
+	assert.True(t, l.Blocked("other.example", "https://other.example/"))
+
+	modTime = modTime.Add(time.Second)
+	require.NoError(t, os.WriteFile(path, []byte("/(/\n"), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	_, err = l.Reload()
+	assert.Error(t, err)
+	assert.True(t, l.Blocked("other.example", "https://other.example/"), "invalid file keeps rules")
+
+	_, err = LoadList(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+// shortener test url shortener redirecting every request to location
+func shortener(t *testing.T, location string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestChecker_Check(t *testing.T) {
+	blocked := shortener(t, "https://malware.example/payload")
+	clean := shortener(t, common.TestURL)
+	self := shortener(t, "http://localhost:8080/abc")
+	second := shortener(t, clean.URL+"/x")
+	third := shortener(t, second.URL+"/y")
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	l, err := NewList(strings.NewReader(testRules))
+	require.NoError(t, err)
+	c := NewChecker(l, Options{
+		BaseURL:        "http://localhost:8080",
+		Shorteners:     []string{"127.0.0.1"},
+		MaxDepth:       2,
+		ResolveTimeout: time.Second,
+	})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "OK", url: common.TestURL},
+		{name: "Invalid", url: "javascript:alert(1)", wantErr: common.ErrInvalidURL},
+		{name: "Blocked", url: "https://cdn.malware.example/a", wantErr: common.ErrBlockedURL},
+		{name: "Self", url: "http://LOCALHOST:8080/abc", wantErr: common.ErrSelfReference},
+		{name: "Self on other port", url: "http://localhost:9090/abc"},
+		{name: "Self on other scheme", url: "https://localhost:8080/abc"},
+		{name: "Shortener to clean", url: clean.URL + "/a"},
+		{name: "Shortener to blocked", url: blocked.URL + "/a", wantErr: common.ErrBlockedURL},
+		{name: "Shortener to self", url: self.URL + "/a", wantErr: common.ErrSelfReference},
+		{name: "Chain within depth", url: second.URL + "/a"},
+		{name: "Chain too deep", url: third.URL + "/a", wantErr: common.ErrRedirectChain},
+		{name: "Unresolved shortener", url: down.URL + "/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, c.Check(tt.url), tt.wantErr)
+		})
+	}
+}
+
+func TestChecker_SelfReferenceBasePath(t *testing.T) {
+	c := NewChecker(nil, Options{BaseURL: "https://example.com/s/"})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "Short url", url: "https://EXAMPLE.com:443/s/abc", wantErr: common.ErrSelfReference},
+		{name: "Base url", url: "https://example.com/s", wantErr: common.ErrSelfReference},
+		{name: "Other path", url: "https://example.com/docs"},
+		{name: "Path with base prefix", url: "https://example.com/settings"},
+		{name: "Other scheme", url: "http://example.com/s/abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, c.Check(tt.url), tt.wantErr)
+		})
+	}
+}
+
+// countingShortener test url shortener redirecting to location and counting requests
+func countingShortener(t *testing.T, location string) (*httptest.Server, *int32) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestChecker_ResolveCache(t *testing.T) {
+	ts, requests := countingShortener(t, "https://malware.example/payload")
+	l, err := NewList(strings.NewReader(testRules))
+	require.NoError(t, err)
+	c := NewChecker(l, Options{Shorteners: []string{"127.0.0.1"}, MaxDepth: 2, ResolveTimeout: time.Second})
+
+	assert.ErrorIs(t, c.Check(ts.URL+"/a"), common.ErrBlockedURL)
+	assert.ErrorIs(t, c.Check(ts.URL+"/a"), common.ErrBlockedURL, "cached resolution")
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	c.cache[ts.URL+"/a"] = resolution{location: common.TestURL, ok: true, expires: time.Now()}
+	assert.ErrorIs(t, c.Check(ts.URL+"/a"), common.ErrBlockedURL, "expired resolution")
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestChecker_MaxResolves(t *testing.T) {
+	ts, requests := countingShortener(t, "https://malware.example/payload")
+	c := NewChecker(nil, Options{Shorteners: []string{"127.0.0.1"}, MaxDepth: 2, ResolveTimeout: 10 * time.Millisecond, MaxResolves: 1})
+
+	c.resolves <- struct{}{}
+	assert.NoError(t, c.Check(ts.URL+"/a"), "link is unresolved while all slots are busy")
+	assert.Zero(t, atomic.LoadInt32(requests))
+
+	<-c.resolves
+	c.Check(ts.URL + "/a")
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestGuard(t *testing.T) {
+	l, err := NewList(strings.NewReader(testRules))
+	require.NoError(t, err)
+	repo := Guard(storage.NewMemoryStorage(), NewChecker(l, Options{}))
+
+	_, err = repo.Insert("https://malware.example/a", "user", storage.InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrBlockedURL)
+	assert.Zero(t, repo.URLCount(), "blocked url is not saved")
+
+	_, err = repo.Insert(common.TestURL, "user", storage.InsertOptions{})
+	assert.NoError(t, err)
+
+	_, err = repo.CreateItems([]storage.BatchItemRequest{
+		{CorrelationID: "1", OriginalURL: common.TestURL + "/1"},
+		{CorrelationID: "2", OriginalURL: "https://login.phish.io/"},
+	}, "user")
+	assert.ErrorIs(t, err, common.ErrBlockedURL)
+	urls, err := repo.GetUserURL("user")
+	assert.NoError(t, err)
+	assert.Len(t, urls, 1, "batch with blocked url is rejected as whole")
+}
+
+func TestGuard_BatchNotResolved(t *testing.T) {
+	ts, requests := countingShortener(t, "https://malware.example/payload")
+	l, err := NewList(strings.NewReader(testRules))
+	require.NoError(t, err)
+	repo := Guard(storage.NewMemoryStorage(), NewChecker(l, Options{Shorteners: []string{"127.0.0.1"}, MaxDepth: 2, ResolveTimeout: time.Second}))
+
+	_, err = repo.CreateItems([]storage.BatchItemRequest{{CorrelationID: "1", OriginalURL: ts.URL + "/a"}}, "user")
+	assert.NoError(t, err)
+	assert.Zero(t, atomic.LoadInt32(requests), "batch links are not followed")
+
+	_, err = repo.Insert(ts.URL+"/b", "user", storage.InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrBlockedURL)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
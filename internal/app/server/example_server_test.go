@@ -33,7 +33,7 @@ func ExampleSaveShortURL() {
 	fmt.Printf("Short URL: %v\n", fmt.Sprintf("http://localhost:8080%s", string(b)))
 	// Output:
 	// Code: 201
-	// Short URL: http://localhost:8080/a7a40cddf446bc419af5737fc92f1757
+	// Short URL: http://localhost:8080/5X2HT0AB
 }
 
 func ExampleGetURL() {
@@ -42,7 +42,7 @@ func ExampleGetURL() {
 	r, _ := http.NewRequest("GET", "/"+common.TestShortID, nil)
 
 	handlers.Store, _ = storage.InitDB()
-	handlers.Store.Items[common.TestShortID] = common.TestURL
+	handlers.Store.Insert(common.TestURL, "", storage.InsertOptions{})
 
 	router.ServeHTTP(w, r)
 	router.HandleFunc("/", handlers.GetURL)
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	apiv2 "github.com/fd239/go_url_shortener/api/v2"
+	"github.com/fd239/go_url_shortener/internal/app/middleware"
+	"github.com/fd239/go_url_shortener/internal/app/session"
+	gcontext "github.com/gorilla/context"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// gatewayPrefix path the REST gateway is mounted at
+const gatewayPrefix = "/api/v2"
+
+// NewGateway REST gateway translating /api/v2 requests to v2 gRPC calls over conn
+func NewGateway(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithMetadata(gatewayMetadata))
+	if err := apiv2.RegisterShortenerHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// DialGateway connect gateway to local gRPC server listening on addr
+func DialGateway(addr net.Addr) (*grpc.ClientConn, error) {
+	return grpc.Dial(loopbackAddress(addr), grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// gatewayMetadata pass user authenticated by AuthMiddleware to gRPC as token metadata
+func gatewayMetadata(_ context.Context, r *http.Request) metadata.MD {
+	userID := gcontext.Get(r, "userID")
+	if userID == nil {
+		return nil
+	}
+
+	token, err := session.New(fmt.Sprintf("%v", userID), time.Now()).Token()
+	if err != nil {
+		log.Printf("Gateway token encrypt error: %v", err)
+		return nil
+	}
+
+	return metadata.Pairs(middleware.TokenMetadataKey, token)
+}
+
+// loopbackAddress address to dial a listener, unspecified host is replaced by loopback
+func loopbackAddress(addr net.Addr) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || !tcpAddr.IP.IsUnspecified() {
+		return addr.String()
+	}
+
+	return net.JoinHostPort("localhost", fmt.Sprint(tcpAddr.Port))
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGateway(t *testing.T) {
+	gateway, err := NewGateway(context.Background(), dialGRPC(t))
+	require.NoError(t, err)
+
+	r := CreateRouter()
+	r.Mount(gatewayPrefix, gateway)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		code     int
+		contains string
+	}{
+		{
+			name:     "Shorten 200",
+			method:   http.MethodPost,
+			path:     "/api/v2/shorten",
+			body:     fmt.Sprintf(`{"url":"%s"}`, common.TestURL),
+			code:     http.StatusOK,
+			contains: fmt.Sprintf(`"%s/%s"`, config.Cfg.BaseURL, common.TestShortID),
+		},
+		{
+			name:   "Shorten 400 Invalid url",
+			method: http.MethodPost,
+			path:   "/api/v2/shorten",
+			body:   `{"url":"not a url"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:     "GetUrl 200",
+			method:   http.MethodGet,
+			path:     "/api/v2/urls/" + common.TestShortID,
+			code:     http.StatusOK,
+			contains: common.TestURL,
+		},
+		{
+			name:   "GetUrl 404",
+			method: http.MethodGet,
+			path:   "/api/v2/urls/unknown",
+			code:   http.StatusNotFound,
+		},
+		{
+			name:     "ListUserUrls 200 same user as cookie",
+			method:   http.MethodGet,
+			path:     "/api/v2/user/urls",
+			code:     http.StatusOK,
+			contains: common.TestURL,
+		},
+		{
+			name:     "OpenAPI 200",
+			method:   http.MethodGet,
+			path:     "/api/v2/openapi.json",
+			code:     http.StatusOK,
+			contains: `"/api/v2/shorten"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := client.do(tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.code, resp.StatusCode, body)
+			assert.Contains(t, body, tt.contains)
+		})
+	}
+}
+
+func TestLoopbackAddress(t *testing.T) {
+	assert.Equal(t, "localhost:9000", loopbackAddress(&net.TCPAddr{IP: net.IPv6unspecified, Port: 9000}))
+	assert.Equal(t, "127.0.0.1:9000", loopbackAddress(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9000}))
+}
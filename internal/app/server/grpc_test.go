@@ -0,0 +1,444 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/fd239/go_url_shortener/api"
+	apiv2 "github.com/fd239/go_url_shortener/api/v2"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/apikey"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
+	"github.com/fd239/go_url_shortener/internal/app/handlers"
+	"github.com/fd239/go_url_shortener/internal/app/middleware"
+	"github.com/fd239/go_url_shortener/internal/app/ratelimit"
+	"github.com/fd239/go_url_shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// login issue user token and return context sending it with every call
+func login(t *testing.T, conn *grpc.ClientConn) context.Context {
+	var header metadata.MD
+	_, err := apiv2.NewShortenerClient(conn).Ping(context.Background(), &apiv2.PingRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	tokens := header.Get(middleware.TokenMetadataKey)
+	require.Len(t, tokens, 1)
+
+	return metadata.AppendToOutgoingContext(context.Background(), middleware.TokenMetadataKey, tokens[0])
+}
+
+// dialGRPC start gRPC server on in-memory listener and connect to it
+func dialGRPC(t *testing.T) *grpc.ClientConn {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+	handlers.Deletes, err = deleter.NewQueue(handlers.Store, "", deleter.Options{FlushInterval: time.Millisecond})
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer, healthServer := NewGRPCServer()
+	watchHealth(context.Background(), healthServer, handlers.Store, 0)
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		grpcServer.Stop()
+		handlers.Deletes.Close()
+	})
+
+	return conn
+}
+
+func TestGRPCv2(t *testing.T) {
+	conn := dialGRPC(t)
+	client := apiv2.NewShortenerClient(conn)
+	ctx := login(t, conn)
+	shortURL := fmt.Sprintf("%s/%s", config.Cfg.BaseURL, common.TestShortID)
+
+	tests := []struct {
+		name string
+		call func() (interface{}, error)
+		want interface{}
+		code codes.Code
+	}{
+		{
+			name: "Shorten OK",
+			call: func() (interface{}, error) {
+				resp, err := client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL})
+				return resp.GetShortUrl(), err
+			},
+			want: shortURL,
+		},
+		{
+			name: "Shorten invalid url",
+			call: func() (interface{}, error) {
+				return client.Shorten(ctx, &apiv2.ShortenRequest{Url: "not a url"})
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Shorten reserved alias",
+			call: func() (interface{}, error) {
+				return client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL, Alias: "ping"})
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Shorten alias",
+			call: func() (interface{}, error) {
+				resp, err := client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL + "/sale", Alias: "spring-sale"})
+				return resp.GetShortUrl(), err
+			},
+			want: config.Cfg.BaseURL + "/spring-sale",
+		},
+		{
+			name: "Shorten alias taken",
+			call: func() (interface{}, error) {
+				return client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL + "/2", Alias: "spring-sale"})
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name: "BatchShorten empty",
+			call: func() (interface{}, error) {
+				return client.BatchShorten(ctx, &apiv2.BatchShortenRequest{})
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "BatchShorten OK",
+			call: func() (interface{}, error) {
+				resp, err := client.BatchShorten(ctx, &apiv2.BatchShortenRequest{
+					Items: []*apiv2.BatchItem{{CorrelationId: "1", OriginalUrl: common.TestURL + "/batch"}},
+				})
+				return len(resp.GetItems()), err
+			},
+			want: 1,
+		},
+		{
+			name: "GetUrl OK",
+			call: func() (interface{}, error) {
+				resp, err := client.GetUrl(ctx, &apiv2.GetUrlRequest{Id: common.TestShortID})
+				return resp.GetOriginalUrl(), err
+			},
+			want: common.TestURL,
+		},
+		{
+			name: "GetUrl not found",
+			call: func() (interface{}, error) {
+				return client.GetUrl(ctx, &apiv2.GetUrlRequest{Id: "unknown"})
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "ListUserUrls OK",
+			call: func() (interface{}, error) {
+				resp, err := client.ListUserUrls(ctx, &apiv2.ListUserUrlsRequest{})
+				return len(resp.GetUrls()), err
+			},
+			want: 3,
+		},
+		{
+			name: "ListUserUrls page",
+			call: func() (interface{}, error) {
+				resp, err := client.ListUserUrls(ctx, &apiv2.ListUserUrlsRequest{PageSize: 2, Sort: "-created_at"})
+				return []interface{}{len(resp.GetUrls()), resp.GetTotalCount(), resp.GetNextCursor() != ""}, err
+			},
+			want: []interface{}{2, int32(3), true},
+		},
+		{
+			name: "ListUserUrls search",
+			call: func() (interface{}, error) {
+				resp, err := client.ListUserUrls(ctx, &apiv2.ListUserUrlsRequest{Search: "/BATCH"})
+				return resp.GetTotalCount(), err
+			},
+			want: int32(1),
+		},
+		{
+			name: "ListUserUrls invalid sort",
+			call: func() (interface{}, error) {
+				return client.ListUserUrls(ctx, &apiv2.ListUserUrlsRequest{Sort: "title"})
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "GetUrlStats not owner",
+			call: func() (interface{}, error) {
+				return client.GetUrlStats(context.Background(), &apiv2.GetUrlStatsRequest{Id: common.TestShortID})
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "DeleteUrls no ids",
+			call: func() (interface{}, error) {
+				return client.DeleteUrls(ctx, &apiv2.DeleteUrlsRequest{})
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "GetDeleteJob not found",
+			call: func() (interface{}, error) {
+				return client.GetDeleteJob(ctx, &apiv2.GetDeleteJobRequest{Id: "unknown"})
+			},
+			code: codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			assert.Equal(t, tt.code, status.Code(err), "error: %v", err)
+			if tt.code == codes.OK {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	t.Run("DeleteUrls then GetUrl", func(t *testing.T) {
+		resp, err := client.DeleteUrls(ctx, &apiv2.DeleteUrlsRequest{Ids: []string{common.TestShortID}})
+		require.NoError(t, err)
+		assert.Equal(t, deleter.StatusPending, resp.Job.Status)
+
+		var job *apiv2.DeleteJob
+		assert.Eventually(t, func() bool {
+			got, err := client.GetDeleteJob(ctx, &apiv2.GetDeleteJobRequest{Id: resp.Job.Id})
+			require.NoError(t, err)
+			job = got.Job
+			return job.Status == deleter.StatusDone
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []string{common.TestShortID}, job.Deleted)
+
+		_, err = client.GetUrl(ctx, &apiv2.GetUrlRequest{Id: common.TestShortID})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("v1 served side by side", func(t *testing.T) {
+		resp, err := api.NewShortenerClient(conn).GetUrl(ctx, &api.GetUrlRequest{Id: "spring-sale"})
+		require.NoError(t, err)
+		assert.Equal(t, common.TestURL+"/sale", resp.ShortUrl)
+	})
+}
+
+func TestGRPCAuth(t *testing.T) {
+	conn := dialGRPC(t)
+	client := api.NewShortenerClient(conn)
+	ctx := login(t, conn)
+
+	saved, err := client.SaveShortUrl(ctx, &api.SaveShortUrlRequest{Url: common.TestURL, UserID: "forged"})
+	require.NoError(t, err)
+	assert.Empty(t, saved.Error)
+
+	userURLs, err := client.GetUserUrls(ctx, &api.GetUserUrlRequest{UserId: "forged"})
+	require.NoError(t, err)
+	assert.Contains(t, userURLs.UserUrls, common.TestURL)
+
+	userURLs, err = client.GetUserUrls(context.Background(), &api.GetUserUrlRequest{UserId: "forged"})
+	require.NoError(t, err)
+	assert.Equal(t, common.ErrNoUserURLs.Error(), userURLs.Error)
+
+	badCtx := metadata.AppendToOutgoingContext(context.Background(), middleware.TokenMetadataKey, "not-hex")
+	_, err = client.GetUserUrls(badCtx, &api.GetUserUrlRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	var header metadata.MD
+	_, err = client.Ping(ctx, &api.PingRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Empty(t, header.Get(middleware.TokenMetadataKey))
+}
+
+func TestGRPCAPIKeys(t *testing.T) {
+	conn := dialGRPC(t)
+	client := apiv2.NewShortenerClient(conn)
+
+	key, secret, err := apikey.New("service", "service", []string{apikey.ScopeRead}, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, handlers.Store.CreateAPIKey(key))
+	_, err = handlers.Store.Insert(common.TestURL, key.UserID, storage.InsertOptions{})
+	require.NoError(t, err)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), middleware.AuthorizationMetadataKey, "Bearer "+key)
+	}
+
+	var header metadata.MD
+	resp, err := client.ListUserUrls(withKey(secret), &apiv2.ListUserUrlsRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Len(t, resp.Urls, 1)
+	assert.Empty(t, header.Get(middleware.TokenMetadataKey))
+
+	_, err = client.Shorten(withKey(secret), &apiv2.ShortenRequest{Url: common.TestURL + "/new"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	bulk, err := client.BulkShorten(withKey(secret))
+	require.NoError(t, err)
+	_, err = bulk.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.ListUserUrls(withKey("usk_unknown"), &apiv2.ListUserUrlsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPCLinkOwnership(t *testing.T) {
+	conn := dialGRPC(t)
+	client := apiv2.NewShortenerClient(conn)
+	ctx := login(t, conn)
+
+	shortened, err := client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL})
+	require.NoError(t, err)
+	id := shortened.ShortUrl[strings.LastIndex(shortened.ShortUrl, "/")+1:]
+
+	_, err = client.UpdateUrl(login(t, conn), &apiv2.UpdateUrlRequest{Id: id, OriginalUrl: common.TestURL + "/fixed"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "url of other user")
+
+	updated, err := client.UpdateUrl(ctx, &apiv2.UpdateUrlRequest{Id: id, OriginalUrl: common.TestURL + "/fixed"})
+	require.NoError(t, err)
+	assert.Equal(t, common.TestURL, updated.Change.PreviousUrl)
+	assert.Equal(t, common.TestURL+"/fixed", updated.Change.OriginalUrl)
+
+	history, err := client.GetUrlHistory(ctx, &apiv2.GetUrlHistoryRequest{Id: id})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 1)
+	assert.Equal(t, updated.Change.ChangedAt.AsTime(), history.Changes[0].ChangedAt.AsTime())
+
+	_, err = client.RestoreUrl(ctx, &apiv2.RestoreUrlRequest{Id: id})
+	assert.NoError(t, err)
+
+	_, err = client.TransferUrl(ctx, &apiv2.TransferUrlRequest{Id: id, Email: "nobody@example.com"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.TransferUrl(ctx, &apiv2.TransferUrlRequest{Id: id, Email: "not an email"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	require.NoError(t, handlers.Store.CreateAccount(storage.Account{Email: "colleague@example.com", UserID: "colleague"}))
+	_, err = client.TransferUrl(ctx, &apiv2.TransferUrlRequest{Id: id, Email: "colleague@example.com"})
+	require.NoError(t, err)
+
+	_, err = client.GetUrlHistory(ctx, &apiv2.GetUrlHistoryRequest{Id: id})
+	assert.Equal(t, codes.NotFound, status.Code(err), "url is handed over")
+	urls, err := handlers.Store.GetUserURL("colleague")
+	require.NoError(t, err)
+	assert.Equal(t, []*storage.UserItem{{ShortURL: id, OriginalURL: common.TestURL + "/fixed"}}, urls)
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	middleware.ShortenLimits = ratelimit.NewPolicy("test_grpc_shorten", 0.001, 1, 0, 0)
+	defer func() { middleware.ShortenLimits = nil }()
+
+	conn := dialGRPC(t)
+	client := apiv2.NewShortenerClient(conn)
+	ctx := login(t, conn)
+
+	_, err := client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL + "/next"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get(middleware.RetryAfterMetadataKey))
+
+	bulk, err := client.BulkShorten(ctx)
+	require.NoError(t, err)
+	_, err = bulk.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.Shorten(login(t, conn), &apiv2.ShortenRequest{Url: common.TestURL + "/other"})
+	assert.NoError(t, err, "other user has own limit")
+
+	_, err = client.ListUserUrls(ctx, &apiv2.ListUserUrlsRequest{})
+	assert.NoError(t, err, "reads are not limited")
+}
+
+func TestGRPCRateLimitForwardedFor(t *testing.T) {
+	middleware.ShortenLimits = ratelimit.NewPolicy("test_grpc_shorten_ip", 0, 0, 0.001, 1)
+	defer func() { middleware.ShortenLimits = nil }()
+
+	conn := dialGRPC(t)
+	client := apiv2.NewShortenerClient(conn)
+
+	_, err := client.Shorten(login(t, conn), &apiv2.ShortenRequest{Url: common.TestURL})
+	require.NoError(t, err)
+
+	ctx := metadata.AppendToOutgoingContext(login(t, conn), "x-forwarded-for", "203.0.113.7")
+	_, err = client.Shorten(ctx, &apiv2.ShortenRequest{Url: common.TestURL + "/next"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "forwarded address is trusted only from gateway")
+}
+
+func TestGRPCStreams(t *testing.T) {
+	conn := dialGRPC(t)
+	client := apiv2.NewShortenerClient(conn)
+	ctx := login(t, conn)
+
+	const total = 250
+	bulk, err := client.BulkShorten(ctx)
+	require.NoError(t, err)
+	for i := 0; i < total; i++ {
+		err = bulk.Send(&apiv2.BulkShortenRequest{CorrelationId: fmt.Sprint(i), Url: fmt.Sprintf("%s/%d", common.TestURL, i)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, bulk.CloseSend())
+
+	saved := make(map[string]bool)
+	for {
+		resp, err := bulk.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.ShortUrl)
+		saved[resp.CorrelationId] = true
+	}
+	assert.Len(t, saved, total)
+
+	stream, err := client.StreamUserUrls(ctx, &apiv2.StreamUserUrlsRequest{PageSize: 100})
+	require.NoError(t, err)
+	received, cursors := 0, 0
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%s/%d", common.TestURL, received), resp.Url.OriginalUrl)
+		if resp.NextCursor != "" {
+			cursors++
+		}
+		received++
+	}
+	assert.Equal(t, total, received)
+	assert.Equal(t, 2, cursors)
+
+	t.Run("Invalid url ends stream", func(t *testing.T) {
+		bulk, err := client.BulkShorten(ctx)
+		require.NoError(t, err)
+		require.NoError(t, bulk.Send(&apiv2.BulkShortenRequest{Url: "not a url"}))
+		_, err = bulk.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		stream, err := client.StreamUserUrls(ctx, &apiv2.StreamUserUrlsRequest{Cursor: "%%%"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
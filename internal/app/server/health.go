@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log"
+	"time"
+)
+
+// healthServices services reported by health server, empty name is the whole server
+var healthServices = []string{"", "api.Shortener", "api.v2.Shortener"}
+
+// Pinger storage availability check
+type Pinger interface {
+	Ping() error
+}
+
+// watchHealth set serving status of all services by storage ping until context is done
+func watchHealth(ctx context.Context, healthServer *health.Server, pinger Pinger, interval time.Duration) {
+	check := func() {
+		status := healthpb.HealthCheckResponse_SERVING
+		if err := pinger.Ping(); err != nil {
+			log.Printf("Health check storage ping error: %v", err)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		for _, service := range healthServices {
+			healthServer.SetServingStatus(service, status)
+		}
+	}
+
+	check()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testPinger struct {
+	down int32
+}
+
+func (p *testPinger) Ping() error {
+	if atomic.LoadInt32(&p.down) == 1 {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestWatchHealth(t *testing.T) {
+	healthServer := health.NewServer()
+	pinger := &testPinger{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchHealth(ctx, healthServer, pinger, time.Millisecond)
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return resp.Status
+	}
+
+	for _, service := range healthServices {
+		assert.Eventually(t, func() bool {
+			return status(service) == healthpb.HealthCheckResponse_SERVING
+		}, time.Second, time.Millisecond)
+	}
+
+	atomic.StoreInt32(&pinger.down, 1)
+	assert.Eventually(t, func() bool {
+		return status("api.v2.Shortener") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, time.Millisecond)
+}
+
+// listServices service names reported by server reflection
+func listServices(t *testing.T, conn *grpc.ClientConn) []string {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	defer stream.CloseSend()
+
+	err = stream.Send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	return services
+}
+
+func TestGRPCHealthAndReflection(t *testing.T) {
+	conn := dialGRPC(t)
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "api.Shortener"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	services := listServices(t, conn)
+	assert.Contains(t, services, "api.Shortener")
+	assert.Contains(t, services, "api.v2.Shortener")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+}
@@ -2,20 +2,35 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/fd239/go_url_shortener/api"
+	apiv2 "github.com/fd239/go_url_shortener/api/v2"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/analytics"
+	"github.com/fd239/go_url_shortener/internal/app/apikey"
+	"github.com/fd239/go_url_shortener/internal/app/crypt"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
 	"github.com/fd239/go_url_shortener/internal/app/handlers"
 	"github.com/fd239/go_url_shortener/internal/app/middleware"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/ratelimit"
+	"github.com/fd239/go_url_shortener/internal/app/safety"
 	"github.com/fd239/go_url_shortener/internal/app/storage"
 	"github.com/go-chi/chi/v5"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"golang.org/x/crypto/acme/autocert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -29,47 +44,163 @@ type Server interface {
 }
 
 type server struct {
-	address string
-	baseURL string
-	useTLS  bool
+	address     string
+	grpcAddress string
+	baseURL     string
+	useTLS      bool
+	blocklist   *safety.List
+}
+
+// lookupAPIKey find api key in current storage
+func lookupAPIKey(hash string) (storage.APIKey, error) {
+	return handlers.Store.GetAPIKey(hash)
 }
 
 func CreateRouter() *chi.Mux {
+	middleware.LookupAPIKey = lookupAPIKey
+
+	read := middleware.RequireScope(apikey.ScopeRead)
+	write := middleware.RequireScope(apikey.ScopeWrite)
+	del := middleware.RequireScope(apikey.ScopeDelete)
+
 	r := chi.NewRouter()
+	r.NotFound(problem.NotFound)
+	r.MethodNotAllowed(problem.MethodNotAllowed)
 	r.Use(middleware.AuthMiddleware)
 	r.Use(middleware.DecompressMiddleware)
 	r.Mount("/debug", middleware.Profiler())
 	r.Get("/ping", handlers.Ping)
-	r.Get("/api/user/urls", handlers.GetUserURLs)
-	r.Delete("/api/user/urls", handlers.DeleteURLs)
-	r.Post("/api/shorten/batch", handlers.BatchURLs)
-	r.Post("/api/shorten", handlers.HandleURL)
+	r.With(read).Get("/api/user/urls", handlers.GetUserURLs)
+	r.With(del).Delete("/api/user/urls", handlers.DeleteURLs)
+	r.With(read).Get("/api/user/urls/{id}/stats", handlers.GetURLStats)
+	r.With(read).Get("/api/user/urls/{id}/history", handlers.GetURLHistory)
+	r.With(write, middleware.LimitShorten).Patch("/api/user/urls/{id}", handlers.UpdateURL)
+	r.With(write).Post("/api/user/urls/{id}/restore", handlers.RestoreURL)
+	r.With(middleware.RequireSession).Post("/api/user/urls/{id}/transfer", handlers.TransferURL)
+	r.With(read).Get("/api/user/jobs/{id}", handlers.GetJob)
+	r.With(middleware.RequireSession).Post("/api/user/register", handlers.Register)
+	r.With(middleware.RequireSession).Post("/api/user/login", handlers.Login)
+	r.With(middleware.RequireSession).Post("/api/user/keys", handlers.CreateAPIKey)
+	r.With(middleware.RequireSession).Get("/api/user/keys", handlers.ListAPIKeys)
+	r.With(middleware.RequireSession).Delete("/api/user/keys/{id}", handlers.RevokeAPIKey)
+	r.With(write, middleware.LimitShorten).Post("/api/shorten/batch", handlers.BatchURLs)
+	r.With(write, middleware.LimitShorten).Post("/api/shorten", handlers.HandleURL)
 	r.Get("/api/internal/stats", handlers.GetStats)
-	r.Get("/{id}", handlers.GetURL)
-	r.Post("/", handlers.SaveShortURL)
+	r.Put("/api/internal/urls/{id}/block", handlers.BlockURL)
+	r.Delete("/api/internal/urls/{id}/block", handlers.UnblockURL)
+	r.Get(gatewayPrefix+"/openapi.json", handlers.GetOpenAPI)
+	r.With(middleware.LimitRedirect).Get("/{id}", handlers.GetURL)
+	r.With(write, middleware.LimitShorten).Post("/", handlers.SaveShortURL)
 
 	return r
 }
 
 // NewServer creating server instance and initialize store
 func NewServer(address string, baseURL string, useTLS bool) (*server, error) {
-	var err error
-	handlers.Store, err = storage.InitDB()
+	if err := crypt.Init(); err != nil {
+		log.Println("Secret keys load error: ", err)
+		return nil, err
+	}
+
+	blocklist, err := safety.LoadList(config.Cfg.BlocklistFile)
+	if err != nil {
+		log.Println("Blocklist load error: ", err)
+		return nil, err
+	}
+
+	analytics.TrustedProxies, err = analytics.ParseProxies(config.Cfg.TrustedProxies)
+	if err != nil {
+		log.Println("Trusted proxies parse error: ", err)
+		return nil, err
+	}
+
+	store, err := storage.InitDB()
 	if err != nil {
 		return nil, err
 	}
+	handlers.Store = safety.Guard(store, safety.NewChecker(blocklist, safety.Options{
+		BaseURL:         baseURL,
+		Shorteners:      config.Cfg.ShortenerDomains,
+		MaxDepth:        config.Cfg.ShortenerMaxDepth,
+		ResolveTimeout:  config.Cfg.ShortenerResolveTimeout,
+		ResolveCacheTTL: config.Cfg.ShortenerResolveCacheTTL,
+		MaxResolves:     config.Cfg.ShortenerMaxResolves,
+	}))
+	handlers.Clicks = analytics.NewRecorder(handlers.Store, analytics.Options{
+		BufferSize:    config.Cfg.ClickBufferSize,
+		FlushInterval: config.Cfg.ClickFlushInterval,
+	})
+	handlers.Deletes, err = deleter.NewQueue(handlers.Store, deleteJobsPath(), deleter.Options{
+		QueueSize:     config.Cfg.DeleteQueueSize,
+		Workers:       config.Cfg.DeleteWorkers,
+		BatchSize:     config.Cfg.DeleteBatchSize,
+		FlushInterval: config.Cfg.DeleteFlushInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	middleware.ShortenLimits = ratelimit.NewPolicy("shorten",
+		config.Cfg.ShortenUserRate, config.Cfg.ShortenUserBurst, config.Cfg.ShortenIPRate, config.Cfg.ShortenIPBurst)
+	middleware.RedirectLimits = ratelimit.NewPolicy("redirect",
+		config.Cfg.RedirectUserRate, config.Cfg.RedirectUserBurst, config.Cfg.RedirectIPRate, config.Cfg.RedirectIPBurst)
 	return &server{
-		address: address,
-		baseURL: baseURL,
-		useTLS:  useTLS,
+		address:     address,
+		grpcAddress: config.Cfg.GRPCAddress,
+		baseURL:     baseURL,
+		useTLS:      useTLS,
+		blocklist:   blocklist,
 	}, nil
 }
 
-// Start router create and server start
-func (s *server) Start() error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// defaultDeleteJobsPath delete jobs journal of postgres storage when path is not set
+const defaultDeleteJobsPath = "delete_jobs.journal"
+
+// deleteJobsPath delete jobs journal set in config. By default it is kept next to file storage or in working
+// directory for postgres. In-memory storage loses urls on restart, so its jobs are journaled only if path is set
+func deleteJobsPath() string {
+	switch {
+	case config.Cfg.DeleteJobsPath != "":
+		return config.Cfg.DeleteJobsPath
+	case config.Cfg.DatabaseDSN != "":
+		return defaultDeleteJobsPath
+	case config.Cfg.FileStoragePath != "":
+		return config.Cfg.FileStoragePath + ".jobs"
+	}
+
+	return ""
+}
+
+// NewGRPCServer gRPC server serving v1 and v2 shortener APIs side by side, with health and reflection services
+func NewGRPCServer() (*grpc.Server, *health.Server) {
+	middleware.LookupAPIKey = lookupAPIKey
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainStreamInterceptor(
+			grpc_prometheus.StreamServerInterceptor,
+			middleware.AuthStreamInterceptor,
+			middleware.RateLimitStreamInterceptor,
+			middleware.ValidateStreamInterceptor,
+		),
+		grpc.ChainUnaryInterceptor(
+			grpc_prometheus.UnaryServerInterceptor,
+			middleware.AuthUnaryInterceptor,
+			middleware.RateLimitUnaryInterceptor,
+			middleware.ValidateUnaryInterceptor,
+		),
+	)
+
+	api.RegisterShortenerServer(grpcServer, handlers.NewConsumer())
+	apiv2.RegisterShortenerServer(grpcServer, handlers.NewConsumerV2())
 
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	return grpcServer, healthServer
+}
+
+// Start router create and server start. Blocks until termination signal or serve error
+func (s *server) Start() error {
 	r := CreateRouter()
 
 	srv := &http.Server{
@@ -88,44 +219,107 @@ func (s *server) Start() error {
 		srv.TLSConfig = manager.TLSConfig()
 	}
 
-	if s.useTLS {
-		go srv.ListenAndServeTLS(certFile, keyFile)
-	} else {
-		go srv.ListenAndServe()
+	listener, err := net.Listen("tcp", s.grpcAddress)
+	if err != nil {
+		log.Println("GRPC failed to listen: ", err)
+		return err
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
-		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-	)
+	grpcServer, healthServer := NewGRPCServer()
 
-	listener, err := net.Listen("tcp", ":9000")
+	gatewayConn, err := DialGateway(listener.Addr())
 	if err != nil {
-		log.Println("GRPC failed to listen: ", err)
+		log.Println("GRPC gateway dial error: ", err)
+		return err
+	}
+	defer gatewayConn.Close()
+
+	gateway, err := NewGateway(context.Background(), gatewayConn)
+	if err != nil {
+		log.Println("GRPC gateway register error: ", err)
 		return err
 	}
+	r.Mount(gatewayPrefix, gateway)
 
-	consumer := handlers.NewConsumer()
-	api.RegisterShortenerServer(grpcServer, consumer)
+	serveErr := make(chan error, 2)
+	go func() {
+		var err error
+		if s.useTLS {
+			err = srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("http serve: %w", err)
+		}
+	}()
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			serveErr <- fmt.Errorf("grpc serve: %w", err)
+		}
+	}()
 
-	go log.Fatal(grpcServer.Serve(listener))
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go watchHealth(bgCtx, healthServer, handlers.Store, config.Cfg.HealthCheckInterval)
+	if config.Cfg.ExpirySweepInterval > 0 {
+		go storage.RunSweeper(bgCtx, handlers.Store, config.Cfg.ExpirySweepInterval)
+	}
+	go s.blocklist.Watch(bgCtx, config.Cfg.BlocklistReloadInterval)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
 
-	v := <-quit
+	select {
+	case v := <-quit:
+		log.Printf("signal.Notify: %v", v)
+	case err = <-serveErr:
+		log.Printf("Server error: %v", err)
+	}
+
+	healthServer.Shutdown()
+	stopBackground()
+	s.shutdown(srv, grpcServer)
 
-	log.Printf("signal.Notify: %v", v)
+	return err
+}
+
+// shutdown stop HTTP and gRPC servers together within shutdown timeout, then flush background writers and storage
+func (s *server) shutdown(srv *http.Server, grpcServer *grpc.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Cfg.ShutdownTimeout)
+	defer cancel()
 
-	if handlers.Store.PGConn != nil {
-		if err := handlers.Store.PGConn.Close(); err != nil {
-			log.Printf("Postgres close error: %v", err)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Server shutdown error: %v", err)
 		}
-	}
+	}()
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			log.Println("GRPC graceful stop timed out, closing connections")
+			grpcServer.Stop()
+		}
+	}()
+	wg.Wait()
+
+	handlers.Clicks.Close()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+	if err := handlers.Deletes.Close(); err != nil {
+		log.Printf("Delete queue close error: %v", err)
 	}
 
-	return nil
+	if err := handlers.Store.Close(); err != nil {
+		log.Printf("Storage close error: %v", err)
+	}
 }
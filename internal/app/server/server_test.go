@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/caarlos0/env/v6"
 	"github.com/fd239/go_url_shortener/config"
 	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/deleter"
 	"github.com/fd239/go_url_shortener/internal/app/handlers"
+	"github.com/fd239/go_url_shortener/internal/app/middleware"
+	"github.com/fd239/go_url_shortener/internal/app/problem"
+	"github.com/fd239/go_url_shortener/internal/app/ratelimit"
+	"github.com/fd239/go_url_shortener/internal/app/safety"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
 	"github.com/fd239/go_url_shortener/internal/app/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,9 +21,12 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func getJSONRequest() *bytes.Buffer {
@@ -39,8 +49,14 @@ func getUserUrlsRequest() *bytes.Buffer {
 	return &buf
 }
 
+// getShortID short url of url saved by another user after attempt collisions
+func getShortID(url string, attempt int) string {
+	id, _ := shortid.NewHash(shortid.DefaultLength).Generate(url, attempt)
+	return id
+}
+
 func getJSONResponse() string {
-	res := handlers.ShortenResponse{Result: fmt.Sprintf("%s/%s", config.Cfg.BaseURL, common.TestShortID)}
+	res := handlers.ShortenResponse{Result: fmt.Sprintf("%s/%s", config.Cfg.BaseURL, getShortID(common.TestURL, 1))}
 	b, err := json.Marshal(res)
 
 	if err != nil {
@@ -51,6 +67,12 @@ func getJSONResponse() string {
 	return string(b)
 }
 
+// problemBody error response body of sentinel error
+func problemBody(err error) string {
+	b, _ := json.Marshal(problem.New(err))
+	return string(b)
+}
+
 func testRequest(t *testing.T, ts *httptest.Server, method, path string, body io.Reader) (*http.Response, string, string, string) {
 	req, err := http.NewRequest(method, ts.URL+path, body)
 	require.NoError(t, err)
@@ -74,6 +96,56 @@ func testRequest(t *testing.T, ts *httptest.Server, method, path string, body io
 	return resp, stringBody, location, contentType
 }
 
+// testClient http client of one user with own cookies which does not follow redirects
+type testClient struct {
+	t      *testing.T
+	url    string
+	client *http.Client
+	header http.Header
+}
+
+func newTestClient(t *testing.T, ts *httptest.Server) *testClient {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	return &testClient{
+		t:   t,
+		url: ts.URL,
+		client: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		header: http.Header{},
+	}
+}
+
+// withHeader copy of client sharing its cookies which sets header on every request
+func (c *testClient) withHeader(key, value string) *testClient {
+	header := c.header.Clone()
+	header.Set(key, value)
+	return &testClient{t: c.t, url: c.url, client: c.client, header: header}
+}
+
+// do send request and return response with its body
+func (c *testClient) do(method, path, body string) (*http.Response, string) {
+	req, err := http.NewRequest(method, c.url+path, strings.NewReader(body))
+	require.NoError(c.t, err)
+	for key := range c.header {
+		req.Header.Set(key, c.header.Get(key))
+	}
+
+	resp, err := c.client.Do(req)
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+
+	return resp, strings.TrimSuffix(string(respBody), "\n")
+}
+
 func TestRouter(t *testing.T) {
 	type want struct {
 		code        int
@@ -99,7 +171,12 @@ func TestRouter(t *testing.T) {
 		{
 			name: "POST 400 Empty body",
 			args: args{http.MethodPost, "/", nil},
-			want: want{http.StatusBadRequest, common.ErrEmptyBody.Error(), "", "text/plain; charset=utf-8"},
+			want: want{http.StatusBadRequest, problemBody(common.ErrEmptyBody), "", problem.ContentType},
+		},
+		{
+			name: "POST 400 Invalid url",
+			args: args{http.MethodPost, "/", strings.NewReader("javascript:alert(1)")},
+			want: want{http.StatusBadRequest, problemBody(common.ErrInvalidURL), "", problem.ContentType},
 		},
 		{
 			name: "GET 307",
@@ -109,12 +186,12 @@ func TestRouter(t *testing.T) {
 		{
 			name: "GET 405 No ID in request",
 			args: args{http.MethodGet, "/", nil},
-			want: want{http.StatusMethodNotAllowed, "", "", ""},
+			want: want{http.StatusMethodNotAllowed, problemBody(common.ErrMethodNotAllowed), "", problem.ContentType},
 		},
 		{
-			name: "GET 400 No URL in map",
+			name: "GET 404 No URL in map",
 			args: args{http.MethodGet, "/123", nil},
-			want: want{http.StatusBadRequest, common.ErrUnableToFindURL.Error(), "", "text/plain; charset=utf-8"},
+			want: want{http.StatusNotFound, problemBody(common.ErrUnableToFindURL), "", problem.ContentType},
 		},
 		{
 			name: "POST API 200",
@@ -124,7 +201,67 @@ func TestRouter(t *testing.T) {
 		{
 			name: "Delete user Urls. No Content",
 			args: args{http.MethodGet, "/api/user/urls", getJSONRequest()},
-			want: want{http.StatusNoContent, "", "", "text/plain; charset=utf-8"},
+			want: want{http.StatusNoContent, "", "", ""},
+		},
+		{
+			name: "Ping 200",
+			args: args{http.MethodGet, "/ping", nil},
+			want: want{http.StatusOK, "", "", ""},
+		},
+		{
+			name: "POST batch 201",
+			args: args{http.MethodPost, "/api/shorten/batch", strings.NewReader(fmt.Sprintf(`[{"correlation_id":"1","original_url":"%s"}]`, common.TestURL))},
+			want: want{http.StatusCreated, fmt.Sprintf(`[{"correlation_id":"1","short_url":"%s/%s"}]`, config.Cfg.BaseURL, getShortID(common.TestURL, 2)), "", "application/json"},
+		},
+		{
+			name: "GET job 404",
+			args: args{http.MethodGet, "/api/user/jobs/unknown", nil},
+			want: want{http.StatusNotFound, problemBody(common.ErrJobNotFound), "", problem.ContentType},
+		},
+		{
+			name: "POST API alias 201",
+			args: args{http.MethodPost, "/api/shorten", strings.NewReader(fmt.Sprintf(`{"url":"%s","alias":"spring-sale"}`, common.TestURL))},
+			want: want{http.StatusCreated, fmt.Sprintf(`{"result":"%s/spring-sale"}`, config.Cfg.BaseURL), "", "application/json; charset=UTF-8"},
+		},
+		{
+			name: "GET alias 307",
+			args: args{http.MethodGet, "/spring-sale", nil},
+			want: want{http.StatusTemporaryRedirect, "", common.TestURL, ""},
+		},
+		{
+			name: "POST API alias 409 Taken",
+			args: args{http.MethodPost, "/api/shorten", strings.NewReader(fmt.Sprintf(`{"url":"%s/2","alias":"spring-sale"}`, common.TestURL))},
+			want: want{http.StatusConflict, problemBody(common.ErrAliasConflict), "", problem.ContentType},
+		},
+		{
+			name: "POST API alias 400 Reserved",
+			args: args{http.MethodPost, "/api/shorten", strings.NewReader(fmt.Sprintf(`{"url":"%s","alias":"ping"}`, common.TestURL))},
+			want: want{http.StatusBadRequest, problemBody(common.ErrInvalidAlias), "", problem.ContentType},
+		},
+		{
+			name: "POST 400 Invalid ttl",
+			args: args{http.MethodPost, "/?ttl=soon", strings.NewReader(common.TestURL)},
+			want: want{http.StatusBadRequest, problemBody(common.ErrInvalidExpiry), "", problem.ContentType},
+		},
+		{
+			name: "POST API ttl 201",
+			args: args{http.MethodPost, "/api/shorten", strings.NewReader(fmt.Sprintf(`{"url":"%s","alias":"summer-sale","ttl":3600}`, common.TestURL))},
+			want: want{http.StatusCreated, fmt.Sprintf(`{"result":"%s/summer-sale"}`, config.Cfg.BaseURL), "", "application/json; charset=UTF-8"},
+		},
+		{
+			name: "POST API 400 Expires in past",
+			args: args{http.MethodPost, "/api/shorten", strings.NewReader(fmt.Sprintf(`{"url":"%s","expires_at":"2000-01-01T00:00:00Z"}`, common.TestURL))},
+			want: want{http.StatusBadRequest, problemBody(common.ErrInvalidExpiry), "", problem.ContentType},
+		},
+		{
+			name: "GET stats 404 Not owner",
+			args: args{http.MethodGet, "/api/user/urls/spring-sale/stats", nil},
+			want: want{http.StatusNotFound, problemBody(common.ErrUnableToFindURL), "", problem.ContentType},
+		},
+		{
+			name: "POST batch alias 409 Taken",
+			args: args{http.MethodPost, "/api/shorten/batch", strings.NewReader(fmt.Sprintf(`[{"correlation_id":"1","original_url":"%s/3","alias":"spring-sale"}]`, common.TestURL))},
+			want: want{http.StatusConflict, problemBody(common.ErrAliasConflict), "", problem.ContentType},
 		},
 	}
 
@@ -134,6 +271,9 @@ func TestRouter(t *testing.T) {
 	if err != nil {
 		fmt.Printf("Error database init: %v\n", err)
 	}
+	handlers.Deletes, err = deleter.NewQueue(handlers.Store, "", deleter.Options{})
+	require.NoError(t, err)
+	defer handlers.Deletes.Close()
 
 	r := CreateRouter()
 	ts := httptest.NewServer(r)
@@ -154,6 +294,426 @@ func TestRouter(t *testing.T) {
 	}
 }
 
+func TestDeleteOwnURLs(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+	handlers.Deletes, err = deleter.NewQueue(handlers.Store, "", deleter.Options{FlushInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer handlers.Deletes.Close()
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	owner, other := newTestClient(t, ts), newTestClient(t, ts)
+
+	// deleteURLs submit delete job and poll it until finished
+	deleteURLs := func(client *testClient, ids string) handlers.JobResponse {
+		resp, body := client.do(http.MethodDelete, "/api/user/urls", ids)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		var job handlers.JobResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &job))
+		assert.Equal(t, deleter.StatusPending, job.Status)
+		assert.Equal(t, "/api/user/jobs/"+job.ID, resp.Header.Get("Location"))
+
+		assert.Eventually(t, func() bool {
+			resp, body := client.do(http.MethodGet, "/api/user/jobs/"+job.ID, "")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.NoError(t, json.Unmarshal([]byte(body), &job))
+			return job.Status != deleter.StatusPending
+		}, time.Second, 5*time.Millisecond)
+
+		return job
+	}
+
+	resp, _ := owner.do(http.MethodPost, "/", common.TestURL)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	job := deleteURLs(other, fmt.Sprintf(`["%s"]`, common.TestShortID))
+	assert.Equal(t, deleter.StatusDone, job.Status)
+	assert.Equal(t, &storage.DeleteResult{Deleted: []string{}, NotOwned: []string{common.TestShortID}}, job.Result)
+
+	resp, _ = owner.do(http.MethodGet, "/"+common.TestShortID, "")
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	job = deleteURLs(owner, fmt.Sprintf(`["%s","unknown"]`, common.TestShortID))
+	assert.Equal(t, deleter.StatusDone, job.Status)
+	assert.Equal(t, &storage.DeleteResult{Deleted: []string{common.TestShortID}, NotOwned: []string{"unknown"}}, job.Result)
+
+	resp, _ = other.do(http.MethodGet, "/api/user/jobs/"+job.ID, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = owner.do(http.MethodGet, "/"+common.TestShortID, "")
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+}
+
+func TestSessionCookieDefaultConfig(t *testing.T) {
+	saved := config.Cfg
+	defer func() { config.Cfg = saved }()
+	config.Cfg = config.Config{}
+	require.NoError(t, env.Parse(&config.Cfg))
+
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	resp, _ := client.do(http.MethodPost, "/", common.TestURL)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+	assert.False(t, cookies[0].Secure, "server without tls")
+
+	resp, body := client.do(http.MethodGet, "/api/user/urls", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "cookie is sent back over plain http")
+	assert.Contains(t, body, common.TestURL)
+}
+
+func TestAccounts(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	userURLs := func(client *testClient) []storage.UserItem {
+		resp, body := client.do(http.MethodGet, "/api/user/urls", "")
+		var items []storage.UserItem
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.Unmarshal([]byte(body), &items))
+		}
+		return items
+	}
+	const credentials = `{"email":" User@Example.com ","password":"correct horse"}`
+
+	owner := newTestClient(t, ts)
+	resp, _ := owner.do(http.MethodPost, "/", common.TestURL)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.Equal(t, "/", cookies[0].Path)
+
+	resp, _ = owner.do(http.MethodPost, "/api/user/register", `{"email":"not an email","password":"correct horse"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, body := owner.do(http.MethodPost, "/api/user/register", credentials)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var account handlers.AccountResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &account))
+	assert.Equal(t, "user@example.com", account.Email)
+
+	resp, _ = owner.do(http.MethodPost, "/api/user/register", credentials)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, _ = newTestClient(t, ts).do(http.MethodPost, "/api/user/register", credentials)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// links of anonymous user are merged into the account on login
+	device := newTestClient(t, ts)
+	resp, _ = device.do(http.MethodPost, "/", common.TestURL+"/device")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = device.do(http.MethodPost, "/api/user/login", `{"email":"user@example.com","password":"wrong password"}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body = device.do(http.MethodPost, "/api/user/login", credentials)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &account))
+	assert.Equal(t, 1, account.Merged)
+
+	assert.Len(t, userURLs(device), 2)
+	assert.Equal(t, userURLs(owner), userURLs(device))
+}
+
+func TestLinkOwnership(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+	handlers.Deletes, err = deleter.NewQueue(handlers.Store, "", deleter.Options{FlushInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer handlers.Deletes.Close()
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	owner := newTestClient(t, ts)
+	resp, body := owner.do(http.MethodPost, "/", common.TestURL)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	id := body[strings.LastIndex(body, "/")+1:]
+
+	resp, _ = newTestClient(t, ts).do(http.MethodPatch, "/api/user/urls/"+id, `{"original_url":"`+common.TestURL+`/fixed"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "url of other user")
+	resp, _ = owner.do(http.MethodPatch, "/api/user/urls/"+id, `{"original_url":"not a url"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, body = owner.do(http.MethodPatch, "/api/user/urls/"+id, `{"original_url":"`+common.TestURL+`/fixed"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var change handlers.URLChangeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &change))
+	assert.Equal(t, common.TestURL, change.PreviousURL)
+	assert.Equal(t, common.TestURL+"/fixed", change.OriginalURL)
+
+	resp, _ = owner.do(http.MethodGet, "/"+id, "")
+	assert.Equal(t, common.TestURL+"/fixed", resp.Header.Get("Location"))
+
+	resp, body = owner.do(http.MethodGet, "/api/user/urls/"+id+"/history", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var history []handlers.URLChangeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &history))
+	assert.Equal(t, []handlers.URLChangeResponse{change}, history)
+
+	resp, _ = owner.do(http.MethodDelete, "/api/user/urls", `["`+id+`"]`)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Eventually(t, func() bool {
+		resp, _ := owner.do(http.MethodGet, "/"+id, "")
+		return resp.StatusCode == http.StatusGone
+	}, time.Second, 10*time.Millisecond)
+	resp, _ = owner.do(http.MethodPost, "/api/user/urls/"+id+"/restore", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = owner.do(http.MethodGet, "/"+id, "")
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	colleague := newTestClient(t, ts)
+	resp, _ = colleague.do(http.MethodPost, "/api/user/register", `{"email":"colleague@example.com","password":"correct horse"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = owner.do(http.MethodPost, "/api/user/urls/"+id+"/transfer", `{"email":"nobody@example.com"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = owner.do(http.MethodPost, "/api/user/urls/"+id+"/transfer", `{"email":"Colleague@example.com"}`)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = owner.do(http.MethodGet, "/api/user/urls/"+id+"/history", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "url is handed over")
+	resp, body = colleague.do(http.MethodGet, "/api/user/urls", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, common.TestURL+"/fixed")
+}
+
+func TestUserURLsPaging(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	userURLs := func(path string) (*http.Response, []storage.UserItem) {
+		resp, body := client.do(http.MethodGet, path, "")
+		var items []storage.UserItem
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.Unmarshal([]byte(body), &items))
+		}
+		return resp, items
+	}
+
+	resp, _ := userURLs("/api/user/urls")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	for i := 0; i < 5; i++ {
+		resp, _ := client.do(http.MethodPost, "/", fmt.Sprintf("%s/%d", common.TestURL, i))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	resp, _ = client.do(http.MethodPost, "/", "https://docs.example.com/guide")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, items := userURLs("/api/user/urls")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, items, 6, "all urls without parameters")
+	assert.Equal(t, "6", resp.Header.Get("X-Total-Count"))
+	assert.Empty(t, resp.Header.Get("Link"))
+
+	var got []string
+	path := "/api/user/urls?limit=4&sort=-created_at"
+	for pages := 0; path != ""; pages++ {
+		require.Less(t, pages, 3)
+		resp, items = userURLs(path)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "6", resp.Header.Get("X-Total-Count"))
+		for _, item := range items {
+			got = append(got, item.OriginalURL)
+		}
+
+		path = ""
+		if link := resp.Header.Get("Link"); link != "" {
+			require.True(t, strings.HasSuffix(link, `>; rel="next"`), link)
+			path = link[1:strings.Index(link, ">")]
+		}
+	}
+	assert.Equal(t, []string{"https://docs.example.com/guide", common.TestURL + "/4", common.TestURL + "/3",
+		common.TestURL + "/2", common.TestURL + "/1", common.TestURL + "/0"}, got)
+
+	resp, items = userURLs("/api/user/urls?domain=docs.example.com")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-Total-Count"))
+	require.Len(t, items, 1)
+	assert.Equal(t, "https://docs.example.com/guide", items[0].OriginalURL)
+
+	resp, items = userURLs("/api/user/urls?search=missing")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-Total-Count"))
+	assert.Empty(t, items)
+
+	for _, path := range []string{"/api/user/urls?limit=0", "/api/user/urls?limit=x", "/api/user/urls?sort=title", "/api/user/urls?cursor=x"} {
+		resp, _ = userURLs(path)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, path)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+	handlers.Deletes, err = deleter.NewQueue(handlers.Store, "", deleter.Options{FlushInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer handlers.Deletes.Close()
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	user, client := newTestClient(t, ts), newTestClient(t, ts)
+	// withKey client of no user authenticated by api key
+	withKey := func(key string) *testClient {
+		return client.withHeader("Authorization", "Bearer "+key)
+	}
+	createKey := func(body string) handlers.APIKeyResponse {
+		resp, respBody := user.do(http.MethodPost, "/api/user/keys", body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var key handlers.APIKeyResponse
+		require.NoError(t, json.Unmarshal([]byte(respBody), &key))
+		return key
+	}
+
+	resp, _ := user.do(http.MethodPost, "/api/user/keys", `{"name":"ci","scopes":["admin"]}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	writer := createKey(`{"name":"ci","scopes":["write"]}`)
+	reader := createKey(`{"name":"dashboard","scopes":["read"]}`)
+	assert.NotEmpty(t, writer.Key)
+	assert.Equal(t, []string{"write"}, writer.Scopes)
+
+	resp, body := user.do(http.MethodGet, "/api/user/keys", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotContains(t, body, writer.Key)
+	var keys []handlers.APIKeyResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &keys))
+	assert.Len(t, keys, 2)
+
+	// api keys act as the user who created them, within their scopes, without cookies
+	resp, _ = withKey(writer.Key).do(http.MethodPost, "/", common.TestURL)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Cookies())
+
+	resp, body = withKey(reader.Key).do(http.MethodGet, "/api/user/urls", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, common.TestURL)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{name: "Write key reads", method: http.MethodGet, path: "/api/user/urls", key: writer.Key, want: http.StatusForbidden},
+		{name: "Read key writes", method: http.MethodPost, path: "/api/shorten", key: reader.Key, want: http.StatusForbidden},
+		{name: "Read key deletes", method: http.MethodDelete, path: "/api/user/urls", key: reader.Key, want: http.StatusForbidden},
+		{name: "Key manages keys", method: http.MethodGet, path: "/api/user/keys", key: reader.Key, want: http.StatusForbidden},
+		{name: "Unknown key", method: http.MethodGet, path: "/api/user/urls", key: "usk_unknown", want: http.StatusUnauthorized},
+		{name: "Public route", method: http.MethodGet, path: "/" + common.TestShortID, key: reader.Key, want: http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := withKey(tt.key).do(tt.method, tt.path, "")
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+
+	resp, _ = user.do(http.MethodDelete, "/api/user/keys/"+reader.ID, "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = user.do(http.MethodDelete, "/api/user/keys/"+reader.ID, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = withKey(reader.Key).do(http.MethodGet, "/api/user/urls", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestRateLimit(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+	middleware.ShortenLimits = ratelimit.NewPolicy("test_shorten", 0, 0, 0.001, 2)
+	middleware.RedirectLimits = ratelimit.NewPolicy("test_redirect", 0.001, 1, 0, 0)
+	defer func() {
+		middleware.ShortenLimits = nil
+		middleware.RedirectLimits = nil
+	}()
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	client := newTestClient(t, ts)
+	do := func(method, path, body string) *http.Response {
+		resp, _ := client.do(method, path, body)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/", common.TestURL).StatusCode)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", `{"url":"`+common.TestURL+`/json"}`).StatusCode)
+
+	resp := do(http.MethodPost, "/api/shorten/batch", "[]")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "shorten routes share client ip limit")
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 1000, retryAfter, 1)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, do(http.MethodGet, "/"+common.TestShortID, "").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "/"+common.TestShortID, "").StatusCode, "redirects limited by user")
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/user/urls", "").StatusCode, "other routes are not limited")
+}
+
+func TestModeration(t *testing.T) {
+	var err error
+	handlers.Store, err = storage.InitDB()
+	require.NoError(t, err)
+	id, err := handlers.Store.Insert(common.TestURL, "user", storage.InsertOptions{})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(CreateRouter())
+	defer ts.Close()
+
+	client := newTestClient(t, ts).withHeader("X-Real-IP", "127.0.0.1")
+	do := func(method, path, body string) *http.Response {
+		resp, _ := client.do(method, path, body)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/api/internal/urls/"+id+"/block", `{"reason":"spam"}`).StatusCode, "no trusted subnet")
+
+	config.Cfg.TrustedSubnet = "127.0.0.0/8"
+	defer func() { config.Cfg.TrustedSubnet = "" }()
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/api/internal/urls/unknown/block", `{"reason":"spam"}`).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/internal/urls/"+id+"/block", `{`).StatusCode)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/api/internal/urls/"+id+"/block", `{"reason":"spam"}`).StatusCode)
+	assert.Equal(t, http.StatusGone, do(http.MethodGet, "/"+id, "").StatusCode)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/api/internal/urls/"+id+"/block", `{"reason":"court order","legal":true}`).StatusCode)
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, do(http.MethodGet, "/"+id, "").StatusCode)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/internal/urls/"+id+"/block", "").StatusCode)
+	assert.Equal(t, http.StatusTemporaryRedirect, do(http.MethodGet, "/"+id, "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/api/internal/urls/"+id+"/block", "").StatusCode, "url is not blocked")
+}
+
 func BenchmarkHandlerSaveURL(b *testing.B) {
 	w := httptest.NewRecorder()
 	router := CreateRouter()
@@ -180,7 +740,7 @@ func BenchmarkHandlerGetURL(b *testing.B) {
 
 	router.HandleFunc("/", handlers.GetURL)
 	handlers.Store, _ = storage.InitDB()
-	handlers.Store.Items[common.TestShortID] = common.TestURL
+	handlers.Store.Insert(common.TestURL, "", storage.InsertOptions{})
 
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -195,6 +755,31 @@ func BenchmarkHandlerGetURL(b *testing.B) {
 	}
 }
 
+func TestDeleteJobsPath(t *testing.T) {
+	saved := config.Cfg
+	defer func() { config.Cfg = saved }()
+
+	tests := []struct {
+		name     string
+		jobsPath string
+		dsn      string
+		filePath string
+		want     string
+	}{
+		{name: "Memory", want: ""},
+		{name: "File", filePath: "urls.log", want: "urls.log.jobs"},
+		{name: "Postgres", dsn: "postgres://localhost/short_url", want: defaultDeleteJobsPath},
+		{name: "Configured", jobsPath: "jobs.journal", dsn: "postgres://localhost/short_url", want: "jobs.journal"},
+		{name: "Configured memory", jobsPath: "jobs.journal", want: "jobs.journal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.DeleteJobsPath, config.Cfg.DatabaseDSN, config.Cfg.FileStoragePath = tt.jobsPath, tt.dsn, tt.filePath
+			assert.Equal(t, tt.want, deleteJobsPath())
+		})
+	}
+}
+
 func TestNewServer(t *testing.T) {
 	type args struct {
 		address string
@@ -204,22 +789,33 @@ func TestNewServer(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
+		keys    string
 		want    *server
 		wantErr assert.ErrorAssertionFunc
 	}{
 		{
 			name: "OK",
 			args: args{"localhost:8000", "http://localhost:8080", false},
+			keys: "k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
 			want: &server{
-				address: "localhost:8000",
-				baseURL: "http://localhost:8080",
-				useTLS:  false,
+				address:   "localhost:8000",
+				baseURL:   "http://localhost:8080",
+				useTLS:    false,
+				blocklist: &safety.List{},
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name:    "No secret keys",
+			args:    args{"localhost:8000", "http://localhost:8080", false},
+			wantErr: assert.Error,
+		},
 	}
+	defer func() { config.Cfg.SecretKeys = "" }()
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.SecretKeys = tt.keys
 			got, err := NewServer(tt.args.address, tt.args.baseURL, tt.args.useTLS)
 			if !tt.wantErr(t, err, fmt.Sprintf("NewServer(%v, %v, %v)", tt.args.address, tt.args.baseURL, tt.args.useTLS)) {
 				return
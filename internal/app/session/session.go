@@ -0,0 +1,117 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/crypt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// DefaultTTL token lifetime used when it is not configured
+const DefaultTTL = 30 * 24 * time.Hour
+
+var (
+	ErrTokenMalformed = errors.New("malformed user token")
+	ErrTokenExpired   = errors.New("user token expired")
+)
+
+// Claims user identity carried by token. Token is sealed with AES-GCM, so claims can not be forged
+type Claims struct {
+	UserID    string `json:"uid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+
+	// stale token is sealed by retired key or in legacy format
+	stale bool
+}
+
+// TTL configured token lifetime
+func TTL() time.Duration {
+	if config.Cfg.SessionTTL <= 0 {
+		return DefaultTTL
+	}
+
+	return config.Cfg.SessionTTL
+}
+
+// New claims of user issued now and valid for TTL
+func New(userID string, now time.Time) Claims {
+	return Claims{
+		UserID:    userID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(TTL()).Unix(),
+	}
+}
+
+// Token encrypted claims sent to client
+func (c Claims) Token() (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return crypt.Encrypt(string(payload))
+}
+
+// Expired check token lifetime is over
+func (c Claims) Expired(now time.Time) bool {
+	return now.Unix() >= c.ExpiresAt
+}
+
+// NeedsRefresh check more than half of token lifetime has passed or token is sealed by retired key
+func (c Claims) NeedsRefresh(now time.Time) bool {
+	return c.stale || now.Unix() >= c.IssuedAt+(c.ExpiresAt-c.IssuedAt)/2
+}
+
+// Parse decrypt token claims. Tokens issued before claims were introduced carry bare user ID,
+// they are accepted once as due for refresh
+func Parse(token string, now time.Time) (Claims, error) {
+	payload, stale, err := crypt.Decrypt(token)
+	if err != nil || payload == "" {
+		return Claims{}, ErrTokenMalformed
+	}
+
+	if !strings.HasPrefix(payload, "{") {
+		return Claims{UserID: payload, stale: true}, nil
+	}
+
+	var claims Claims
+	if err = json.Unmarshal([]byte(payload), &claims); err != nil || claims.UserID == "" {
+		return Claims{}, ErrTokenMalformed
+	}
+	claims.stale = stale
+
+	if claims.Expired(now) {
+		return Claims{}, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// Resolve user of token. Missing or expired token starts new anonymous user, token past half
+// of its lifetime is reissued. New token is returned only when it has to be sent to client
+func Resolve(token string, now time.Time) (userID string, newToken string, err error) {
+	if token != "" {
+		claims, err := Parse(token, now)
+		switch {
+		case err == nil && !claims.NeedsRefresh(now):
+			return claims.UserID, "", nil
+		case err == nil:
+			newToken, err = New(claims.UserID, now).Token()
+			return claims.UserID, newToken, err
+		case !errors.Is(err, ErrTokenExpired):
+			return "", "", err
+		}
+	}
+
+	userID = uuid.NewString()
+	newToken, err = New(userID, now).Token()
+	if err != nil {
+		return "", "", err
+	}
+
+	return userID, newToken, nil
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/crypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testUserID = "testUser"
+
+func TestResolve(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	token := func(issued time.Time) string {
+		token, err := New(testUserID, issued).Token()
+		require.NoError(t, err)
+		return token
+	}
+	legacy, err := crypt.Encrypt(testUserID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		token     string
+		sameUser  bool
+		refreshed bool
+		wantErr   error
+	}{
+		{name: "No token starts new user", token: "", refreshed: true},
+		{name: "Fresh token", token: token(now.Add(-time.Hour)), sameUser: true},
+		{name: "Old token refreshed", token: token(now.Add(-DefaultTTL * 3 / 4)), sameUser: true, refreshed: true},
+		{name: "Expired token starts new user", token: token(now.Add(-DefaultTTL - time.Hour)), refreshed: true},
+		{name: "Legacy token refreshed", token: legacy, sameUser: true, refreshed: true},
+		{name: "Not hex", token: "not-hex", wantErr: ErrTokenMalformed},
+		{name: "Forged", token: "00ff00ff00ff00ff00ff00ff00ff00ff", wantErr: ErrTokenMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, newToken, err := Resolve(tt.token, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.sameUser, userID == testUserID)
+			assert.NotEmpty(t, userID)
+
+			if !tt.refreshed {
+				assert.Empty(t, newToken)
+				return
+			}
+			claims, err := Parse(newToken, now)
+			require.NoError(t, err)
+			assert.Equal(t, New(userID, now), claims)
+		})
+	}
+}
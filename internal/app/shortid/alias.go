@@ -0,0 +1,37 @@
+package shortid
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MinAliasLength shortest custom alias
+	MinAliasLength = 3
+	// MaxAliasLength longest custom alias, limited by short url column size
+	MaxAliasLength = 50
+)
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// reserved words clashing with service routes, never used as short IDs
+var reserved = map[string]bool{
+	"api":   true,
+	"ping":  true,
+	"debug": true,
+}
+
+// IsReserved check short ID is a reserved word. Comparison is case-insensitive
+func IsReserved(id string) bool {
+	return reserved[strings.ToLower(id)]
+}
+
+// ValidateAlias check custom alias length, character set and reserved words
+func ValidateAlias(alias string) error {
+	if len(alias) < MinAliasLength || len(alias) > MaxAliasLength || !aliasPattern.MatchString(alias) || IsReserved(alias) {
+		return common.ErrInvalidAlias
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package shortid
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	// ModeSequence base62 encoded number from storage sequence
+	ModeSequence = "sequence"
+	// ModeRandom random base62 string
+	ModeRandom = "random"
+	// ModeHash truncated base62 encoded hash of original url
+	ModeHash = "hash"
+)
+
+// DefaultLength short ID length for random and hash modes
+const DefaultLength = 8
+
+// MaxAttempts number of candidates tried before giving up on collisions
+const MaxAttempts = 10
+
+// HashAttempts number of salted hash candidates of url, later candidates are random. Every user has own
+// short url of the same url, so hash candidates alone run out when many users shorten it
+const HashAttempts = 3
+
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrUnknownMode = errors.New("unknown short id mode")
+
+// Generator produces short IDs
+type Generator interface {
+	// Generate candidate short ID for url. Attempt starts from 0 and grows on every collision
+	Generate(url string, attempt int) (string, error)
+}
+
+// Sequence source of unique increasing numbers
+type Sequence interface {
+	Next() (uint64, error)
+}
+
+// New create generator by mode name. Empty mode means hash, non-positive length means DefaultLength
+func New(mode string, length int, seq Sequence) (Generator, error) {
+	if length <= 0 {
+		length = DefaultLength
+	}
+
+	switch mode {
+	case ModeSequence:
+		return NewSequence(seq), nil
+	case ModeRandom:
+		return NewRandom(length), nil
+	case ModeHash, "":
+		return NewHash(length), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMode, mode)
+	}
+}
+
+// Encode number to base62 string
+func Encode(n uint64) string {
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	var buf [11]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = alphabet[n%62]
+		n /= 62
+	}
+
+	return string(buf[i:])
+}
+
+type sequenceGenerator struct {
+	seq Sequence
+}
+
+// NewSequence generator of base62 encoded sequence numbers. Every attempt takes the next number
+func NewSequence(seq Sequence) Generator {
+	return &sequenceGenerator{seq: seq}
+}
+
+func (g *sequenceGenerator) Generate(_ string, _ int) (string, error) {
+	n, err := g.seq.Next()
+	if err != nil {
+		return "", err
+	}
+
+	return Encode(n), nil
+}
+
+type randomGenerator struct {
+	length int
+}
+
+// NewRandom generator of random base62 strings of given length
+func NewRandom(length int) Generator {
+	return &randomGenerator{length: length}
+}
+
+func (g *randomGenerator) Generate(_ string, _ int) (string, error) {
+	buf := make([]byte, g.length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = alphabet[n.Int64()]
+	}
+
+	return string(buf), nil
+}
+
+type hashGenerator struct {
+	length int
+}
+
+// NewHash generator of base62 encoded sha256 of url truncated to given length.
+// Collisions are resolved by salting url with attempt number, after HashAttempts by random strings
+func NewHash(length int) Generator {
+	return &hashGenerator{length: length}
+}
+
+func (g *hashGenerator) Generate(url string, attempt int) (string, error) {
+	if attempt >= HashAttempts {
+		return NewRandom(g.length).Generate(url, attempt)
+	}
+
+	data := url
+	if attempt > 0 {
+		data = url + "#" + strconv.Itoa(attempt)
+	}
+	sum := sha256.Sum256([]byte(data))
+
+	id := ""
+	for i := 0; len(id) < g.length && i+8 <= len(sum); i += 8 {
+		id += Encode(binary.BigEndian.Uint64(sum[i : i+8]))
+	}
+	if len(id) > g.length {
+		id = id[:g.length]
+	}
+
+	return id, nil
+}
+
+// Counter in-process Sequence, safe for concurrent use
+type Counter struct {
+	n uint64
+}
+
+// NewCounter create counter which next number is start+1
+func NewCounter(start uint64) *Counter {
+	return &Counter{n: start}
+}
+
+// Next increment counter
+func (c *Counter) Next() (uint64, error) {
+	return atomic.AddUint64(&c.n, 1), nil
+}
+
+// Advance move counter forward so next number is greater than n
+func (c *Counter) Advance(n uint64) {
+	for {
+		current := atomic.LoadUint64(&c.n)
+		if current >= n || atomic.CompareAndSwapUint64(&c.n, current, n) {
+			return
+		}
+	}
+}
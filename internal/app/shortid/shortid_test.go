@@ -0,0 +1,144 @@
+package shortid
+
+import (
+	"errors"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+var errTest = errors.New("test error")
+
+type errSequence struct{}
+
+func (errSequence) Next() (uint64, error) {
+	return 0, errTest
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		n    uint64
+		want string
+	}{
+		{name: "Zero", n: 0, want: "0"},
+		{name: "Single digit", n: 61, want: "z"},
+		{name: "Two digits", n: 62, want: "10"},
+		{name: "Max", n: ^uint64(0), want: "LygHa16AHYF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Encode(tt.n))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		wantErr bool
+	}{
+		{name: "Default", mode: ""},
+		{name: "Hash", mode: ModeHash},
+		{name: "Random", mode: ModeRandom},
+		{name: "Sequence", mode: ModeSequence},
+		{name: "Unknown", mode: "md5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := New(tt.mode, 0, NewCounter(0))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnknownMode)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, gen)
+		})
+	}
+}
+
+func TestHashGenerator(t *testing.T) {
+	gen := NewHash(DefaultLength)
+
+	first, err := gen.Generate("https://yandex.ru", 0)
+	assert.NoError(t, err)
+	assert.Len(t, first, DefaultLength)
+
+	again, _ := gen.Generate("https://yandex.ru", 0)
+	assert.Equal(t, first, again)
+
+	retry, _ := gen.Generate("https://yandex.ru", 1)
+	assert.Len(t, retry, DefaultLength)
+	assert.NotEqual(t, first, retry)
+
+	long, _ := NewHash(20).Generate("https://yandex.ru", 0)
+	assert.Len(t, long, 20)
+
+	random, _ := gen.Generate("https://yandex.ru", HashAttempts)
+	assert.Len(t, random, DefaultLength)
+	again, _ = gen.Generate("https://yandex.ru", HashAttempts)
+	assert.NotEqual(t, random, again, "candidates after hash attempts are random")
+}
+
+func TestRandomGenerator(t *testing.T) {
+	gen := NewRandom(12)
+
+	first, err := gen.Generate("https://yandex.ru", 0)
+	assert.NoError(t, err)
+	assert.Len(t, first, 12)
+
+	second, _ := gen.Generate("https://yandex.ru", 0)
+	assert.NotEqual(t, first, second)
+}
+
+func TestSequenceGenerator(t *testing.T) {
+	gen := NewSequence(NewCounter(61))
+
+	first, err := gen.Generate("https://yandex.ru", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", first)
+
+	second, _ := gen.Generate("https://yandex.ru", 0)
+	assert.Equal(t, "11", second)
+
+	_, err = NewSequence(errSequence{}).Generate("https://yandex.ru", 0)
+	assert.ErrorIs(t, err, errTest)
+}
+
+func TestCounterAdvance(t *testing.T) {
+	counter := NewCounter(0)
+	counter.Advance(10)
+	n, _ := counter.Next()
+	assert.Equal(t, uint64(11), n)
+
+	counter.Advance(5)
+	n, _ = counter.Next()
+	assert.Equal(t, uint64(12), n)
+}
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr bool
+	}{
+		{name: "OK", alias: "spring-sale_2"},
+		{name: "Too short", alias: "ab", wantErr: true},
+		{name: "Too long", alias: strings.Repeat("a", MaxAliasLength+1), wantErr: true},
+		{name: "Invalid characters", alias: "spring/sale", wantErr: true},
+		{name: "Reserved", alias: "ping", wantErr: true},
+		{name: "Reserved upper case", alias: "Debug", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAlias(tt.alias)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, common.ErrInvalidAlias)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"time"
+)
+
+const (
+	// DefaultPageSize user urls returned in one page when limit is not set
+	DefaultPageSize = 100
+	// MaxPageSize most user urls returned in one page
+	MaxPageSize = 1000
+)
+
+// UserURLPage page of user urls with cursor of the next page. Next cursor is empty on the last page
+type UserURLPage struct {
+	Items      []*UserItem `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	// Total number of user urls matching query filters on all pages
+	Total int `json:"total"`
+}
+
+// cursor position in user urls listing. Clients get it as opaque string.
+// Listing resumes past the last seen item: in-memory backends order items by sequence number, postgres by creation
+// time, ties are broken by short url. Sort is set for listings not in default order, cursor is valid for listing
+// of the same sort only
+type cursor struct {
+	Seq       uint64    `json:"n,omitempty"`
+	CreatedAt time.Time `json:"t,omitempty"`
+	Clicks    int64     `json:"c,omitempty"`
+	ShortURL  string    `json:"s,omitempty"`
+	Sort      string    `json:"k,omitempty"`
+	// Total in-memory backends count user urls on the first page only, later pages report it from cursor
+	Total int `json:"m,omitempty"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parse client cursor. Empty cursor is the first page
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	if s == "" {
+		return c, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, common.ErrInvalidCursor
+	}
+	if err = json.Unmarshal(b, &c); err != nil || c.ShortURL == "" {
+		return cursor{}, common.ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// pageLimit clamp requested page size
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return limit
+}
@@ -0,0 +1,550 @@
+package storage
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// compactMinRecords log is not compacted until it has at least this many records
+const compactMinRecords = 1000
+
+// clicksLogSuffix clicks are kept in a separate log next to the storage log, it is never compacted
+const clicksLogSuffix = ".clicks"
+
+// FileStorageOptions file repo durability settings
+type FileStorageOptions struct {
+	SyncPolicy      SyncPolicy
+	SyncInterval    time.Duration
+	CompactInterval time.Duration
+}
+
+// FileStorage in-memory repo persisted to append-only record log. Change which fails to be logged is undone in memory
+type FileStorage struct {
+	*MemoryStorage
+	Filename string
+	mu       sync.Mutex
+	log      *recordLog
+	clickLog *recordLog
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewFileStorage creating file repo, replaying saved records and starting background sync and compaction
+func NewFileStorage(fileName string, opts FileStorageOptions) (*FileStorage, error) {
+	recLog, err := openRecordLog(fileName, opts.SyncPolicy)
+	if err != nil {
+		log.Println("Error storage log open: ", err.Error())
+		return nil, err
+	}
+
+	clickLog, err := openRecordLog(fileName+clicksLogSuffix, opts.SyncPolicy)
+	if err != nil {
+		log.Println("Error clicks log open: ", err.Error())
+		recLog.Close()
+		return nil, err
+	}
+
+	fs := &FileStorage{
+		MemoryStorage: NewMemoryStorage(),
+		Filename:      fileName,
+		log:           recLog,
+		clickLog:      clickLog,
+		done:          make(chan struct{}),
+	}
+
+	err = fs.RestoreItems()
+	if err != nil {
+		log.Println("Error storage log replay: ", err.Error())
+		recLog.Close()
+		clickLog.Close()
+		return nil, err
+	}
+	fs.seq.Advance(uint64(fs.URLCount()))
+
+	if recLog.policy == SyncInterval {
+		interval := opts.SyncInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		fs.runEvery(interval, func() {
+			if err := fs.log.Sync(); err != nil {
+				log.Printf("Storage log sync error: %v", err)
+			}
+			if err := fs.clickLog.Sync(); err != nil {
+				log.Printf("Clicks log sync error: %v", err)
+			}
+		})
+	}
+
+	if opts.CompactInterval > 0 {
+		fs.runEvery(opts.CompactInterval, func() {
+			if err := fs.Compact(false); err != nil {
+				log.Printf("Storage log compaction error: %v", err)
+			}
+		})
+	}
+
+	return fs, nil
+}
+
+// runEvery run task in background until storage is closed
+func (fs *FileStorage) runEvery(interval time.Duration, task func()) {
+	fs.wg.Add(1)
+	go func() {
+		defer fs.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				task()
+			case <-fs.done:
+				return
+			}
+		}
+	}()
+}
+
+// Insert save short url and user ID to storage and log
+func (fs *FileStorage) Insert(item string, userID string, opts InsertOptions) (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	hashString, err := fs.MemoryStorage.Insert(item, userID, opts)
+	if err != nil {
+		return hashString, err
+	}
+
+	err = fs.log.Append(fs.insertRecord(hashString))
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		fs.removeItems([]string{hashString})
+		return "", err
+	}
+
+	return hashString, nil
+}
+
+// CreateItems batch insert items to storage and log. Batch is saved as a whole, nothing is saved or logged
+// if any item fails
+func (fs *FileStorage) CreateItems(items []BatchItemRequest, userID string) ([]BatchItemResponse, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	batchItemsResponse, created, err := fs.MemoryStorage.createItems(items, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]record, 0, len(created))
+	for _, id := range created {
+		records = append(records, fs.insertRecord(id))
+	}
+
+	if err = fs.log.Append(records...); err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		fs.removeItems(created)
+		return nil, err
+	}
+
+	return batchItemsResponse, nil
+}
+
+// insertRecord log record of stored item
+func (fs *FileStorage) insertRecord(shortURL string) record {
+	item, _ := fs.index.get(shortURL)
+	return record{Op: recordInsert, ShortURL: item.ShortURL, OriginalURL: item.OriginalURL, User: item.User, ExpiresAt: expiryRef(item.ExpiresAt)}
+}
+
+// UpdateItems batch mark user items as deleted in storage and log
+func (fs *FileStorage) UpdateItems(itemsIDs []string, userID string) (DeleteResult, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	deleted := make(map[string]bool, len(itemsIDs))
+	records := make([]record, 0, len(itemsIDs))
+	for _, id := range itemsIDs {
+		if fs.deleteOwned(id, userID) {
+			deleted[id] = true
+			records = append(records, record{Op: recordDelete, ShortURL: id})
+		}
+	}
+
+	err := fs.log.Append(records...)
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		for id := range deleted {
+			fs.undelete(id)
+		}
+		return DeleteResult{}, err
+	}
+
+	return newDeleteResult(itemsIDs, deleted), nil
+}
+
+// DeleteBatch mark items of several users as deleted in storage and log
+func (fs *FileStorage) DeleteBatch(requests []DeleteRequest) ([]DeleteResult, error) {
+	results := make([]DeleteResult, 0, len(requests))
+	for _, req := range requests {
+		result, err := fs.UpdateItems(req.IDs, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// PurgeExpired mark items expired by now as deleted in storage and log
+func (fs *FileStorage) PurgeExpired(now time.Time) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	ids := fs.expire(now)
+	records := make([]record, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, record{Op: recordDelete, ShortURL: id})
+	}
+
+	err := fs.log.Append(records...)
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		for _, id := range ids {
+			fs.undelete(id)
+		}
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// CreateAccount register account in storage and log
+func (fs *FileStorage) CreateAccount(account Account) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.CreateAccount(account); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordAccount, User: account.UserID, Account: &account})
+	if err != nil {
+		log.Println("DB Save account error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// CreateAPIKey save api key of user in storage and log
+func (fs *FileStorage) CreateAPIKey(key APIKey) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.CreateAPIKey(key); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordAPIKey, User: key.UserID, APIKey: &key})
+	if err != nil {
+		log.Println("DB Save api key error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAPIKey delete api key of user in storage and log
+func (fs *FileStorage) RevokeAPIKey(id string, userID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.RevokeAPIKey(id, userID); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordRevoke, User: userID, APIKey: &APIKey{ID: id, UserID: userID}})
+	if err != nil {
+		log.Println("DB Save api key error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DisableURL disable short url by moderator in storage and log
+func (fs *FileStorage) DisableURL(id string, moderation Moderation) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.DisableURL(id, moderation); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordDisable, ShortURL: id, Moderation: &moderation})
+	if err != nil {
+		log.Println("DB Save moderation error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// EnableURL lift moderation of short url in storage and log
+func (fs *FileStorage) EnableURL(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.EnableURL(id); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordEnable, ShortURL: id})
+	if err != nil {
+		log.Println("DB Save moderation error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// MergeUser move all urls of one user to another in storage and log
+func (fs *FileStorage) MergeUser(fromUserID string, toUserID string) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	ids := fs.merge(fromUserID, toUserID)
+	records := make([]record, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, record{Op: recordOwner, ShortURL: id, User: toUserID})
+	}
+
+	err := fs.log.Append(records...)
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		for _, id := range ids {
+			fs.setOwner(id, fromUserID)
+		}
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// UpdateURL change destination of user short url in storage and log
+func (fs *FileStorage) UpdateURL(change URLChange) (URLChange, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	change, err := fs.MemoryStorage.UpdateURL(change)
+	if err != nil {
+		return URLChange{}, err
+	}
+
+	err = fs.log.Append(record{Op: recordUpdate, ShortURL: change.ShortURL, User: change.UserID, Change: &change})
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		fs.revertChange(change)
+		return URLChange{}, err
+	}
+
+	return change, nil
+}
+
+// RestoreURL undo deletion of user short url in storage and log
+func (fs *FileStorage) RestoreURL(id string, userID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.RestoreURL(id, userID); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordRestore, ShortURL: id, User: userID})
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		fs.delete(id)
+		return err
+	}
+
+	return nil
+}
+
+// TransferURL hand user short url over to other user in storage and log
+func (fs *FileStorage) TransferURL(id string, fromUserID string, toUserID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.MemoryStorage.TransferURL(id, fromUserID, toUserID); err != nil {
+		return err
+	}
+
+	err := fs.log.Append(record{Op: recordOwner, ShortURL: id, User: toUserID})
+	if err != nil {
+		log.Println("DB Save items error: ", err.Error())
+		fs.setOwner(id, fromUserID)
+		return err
+	}
+
+	return nil
+}
+
+// Ping check storage log is still available
+func (fs *FileStorage) Ping() error {
+	return fs.log.Check()
+}
+
+// SaveClicks store recorded redirects in memory and clicks log
+func (fs *FileStorage) SaveClicks(clicks []Click) error {
+	records := make([]record, 0, len(clicks))
+	for i := range clicks {
+		records = append(records, record{Op: recordClick, ShortURL: clicks[i].ShortURL, Click: &clicks[i]})
+	}
+
+	err := fs.clickLog.Append(records...)
+	if err != nil {
+		log.Println("Clicks save error: ", err.Error())
+		return err
+	}
+
+	return fs.MemoryStorage.SaveClicks(clicks)
+}
+
+// RestoreItems replay log records to in-mem storage
+func (fs *FileStorage) RestoreItems() error {
+	if err := fs.log.Replay(fs.apply); err != nil {
+		return err
+	}
+
+	return fs.clickLog.Replay(fs.apply)
+}
+
+// apply log record to in-mem storage
+func (fs *FileStorage) apply(rec record) {
+	switch rec.Op {
+	case recordInsert:
+		item := &Item{ShortURL: rec.ShortURL, OriginalURL: rec.OriginalURL, User: rec.User}
+		if rec.ExpiresAt != nil {
+			item.ExpiresAt = *rec.ExpiresAt
+		}
+		fs.put(item)
+	case recordDelete:
+		fs.delete(rec.ShortURL)
+	case recordClick:
+		if rec.Click != nil {
+			fs.MemoryStorage.SaveClicks([]Click{*rec.Click})
+		}
+	case recordAccount:
+		if rec.Account != nil {
+			fs.MemoryStorage.CreateAccount(*rec.Account)
+		}
+	case recordOwner:
+		fs.setOwner(rec.ShortURL, rec.User)
+	case recordAPIKey:
+		if rec.APIKey != nil {
+			fs.MemoryStorage.CreateAPIKey(*rec.APIKey)
+		}
+	case recordRevoke:
+		if rec.APIKey != nil {
+			fs.MemoryStorage.RevokeAPIKey(rec.APIKey.ID, rec.User)
+		}
+	case recordDisable:
+		if rec.Moderation != nil {
+			fs.MemoryStorage.DisableURL(rec.ShortURL, *rec.Moderation)
+		}
+	case recordEnable:
+		fs.MemoryStorage.EnableURL(rec.ShortURL)
+	case recordUpdate:
+		if rec.Change != nil {
+			fs.applyChange(*rec.Change)
+		}
+	case recordRestore:
+		fs.undelete(rec.ShortURL)
+	default:
+		log.Printf("Storage log unknown record op: %s", rec.Op)
+	}
+}
+
+// Compact rewrite log with the current state only. Unless forced the log is
+// compacted when it has grown at least twice as large as the live data
+func (fs *FileStorage) Compact(force bool) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	records := fs.log.Records()
+	if !force && (records < compactMinRecords || records <= 2*fs.URLCount()) {
+		return nil
+	}
+
+	snapshot := make([]record, 0, records)
+	for _, item := range fs.snapshot() {
+		snapshot = append(snapshot, record{Op: recordInsert, ShortURL: item.ShortURL, OriginalURL: item.OriginalURL, User: item.User, ExpiresAt: expiryRef(item.ExpiresAt)})
+		if item.Deleted {
+			snapshot = append(snapshot, record{Op: recordDelete, ShortURL: item.ShortURL})
+		}
+	}
+	for _, account := range fs.accountsSnapshot() {
+		account := account
+		snapshot = append(snapshot, record{Op: recordAccount, User: account.UserID, Account: &account})
+	}
+	for _, key := range fs.apiKeysSnapshot() {
+		key := key
+		snapshot = append(snapshot, record{Op: recordAPIKey, User: key.UserID, APIKey: &key})
+	}
+	history := fs.historySnapshot()
+	changed := make([]string, 0, len(history))
+	for id := range history {
+		changed = append(changed, id)
+	}
+	sort.Strings(changed)
+	for _, id := range changed {
+		for _, change := range history[id] {
+			change := change
+			snapshot = append(snapshot, record{Op: recordUpdate, ShortURL: id, User: change.UserID, Change: &change})
+		}
+	}
+	moderation := fs.moderationSnapshot()
+	disabled := make([]string, 0, len(moderation))
+	for id := range moderation {
+		disabled = append(disabled, id)
+	}
+	sort.Strings(disabled)
+	for _, id := range disabled {
+		m := moderation[id]
+		snapshot = append(snapshot, record{Op: recordDisable, ShortURL: id, Moderation: &m})
+	}
+
+	err := fs.log.Compact(snapshot)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Storage log compacted from %d to %d records", records, len(snapshot))
+	return nil
+}
+
+// expiryRef log representation of expiry time, nil if link never expires
+func expiryRef(expiresAt time.Time) *time.Time {
+	if expiresAt.IsZero() {
+		return nil
+	}
+
+	return &expiresAt
+}
+
+// Close stop background tasks, flush and close log
+func (fs *FileStorage) Close() error {
+	close(fs.done)
+	fs.wg.Wait()
+
+	if err := fs.clickLog.Close(); err != nil {
+		log.Printf("Clicks log close error: %v", err)
+	}
+
+	return fs.log.Close()
+}
@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStorage_Insert(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	got, err := fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestShortID, got)
+
+	url, err := fs.Get(common.TestShortID)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL, url)
+
+	got, err = fs.Insert(strings.ToUpper(common.TestURL[:7])+common.TestURL[7:]+"/", testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
+	assert.Equal(t, common.TestShortID, got)
+	assert.NoError(t, fs.Close())
+
+	fs, err = NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	url, err = fs.Get(common.TestShortID)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL, url, "duplicate insert keeps original url")
+
+	fs.gen = shortid.NewRandom(shortid.DefaultLength)
+	got, err = fs.Insert(common.TestURL+"/", testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "restored items are deduplicated")
+	assert.Equal(t, common.TestShortID, got)
+	assert.NoError(t, fs.Close())
+}
+
+func TestFileStorage_RestoreItems(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	_, err = fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+
+	restored, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer restored.Close()
+
+	url, err := restored.Get(common.TestShortID)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL, url)
+
+	_, err = restored.Get(common.TestURL)
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+}
+
+func TestFileStorage_RestoreFailedBatch(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	_, err = fs.CreateItems([]BatchItemRequest{
+		{CorrelationID: testItemID, OriginalURL: common.TestURL},
+		{CorrelationID: testItemID, OriginalURL: "not a url"},
+	}, testUserID)
+	assert.ErrorIs(t, err, common.ErrInvalidURL)
+	assert.Zero(t, fs.log.Records(), "failed batch is not logged")
+	require.NoError(t, fs.Close())
+
+	restored, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer restored.Close()
+
+	_, err = restored.Get(common.TestShortID)
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+
+	id, err := restored.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err, "url of failed batch is not a duplicate")
+	assert.Equal(t, common.TestShortID, id)
+}
+
+func TestFileStorage_UpdateItems(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer fs.Close()
+
+	_, err = fs.CreateItems([]BatchItemRequest{{
+		CorrelationID: testItemID,
+		OriginalURL:   common.TestURL,
+	}}, testUserID)
+	require.NoError(t, err)
+
+	result, err := fs.UpdateItems([]string{common.TestShortID, "unknown"}, testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{common.TestShortID}, result.Deleted)
+	assert.Equal(t, 1, fs.log.Records()-1)
+
+	_, err = fs.Get(common.TestShortID)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+	assert.NoError(t, fs.Ping())
+}
+
+func TestFileStorage_FailedAppend(t *testing.T) {
+	fs, err := NewFileStorage(filepath.Join(t.TempDir(), common.TestDBName), FileStorageOptions{})
+	require.NoError(t, err)
+	defer fs.Close()
+
+	id, err := fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	deletedID, err := fs.Insert(common.TestURL+"/deleted", testUserID, InsertOptions{})
+	require.NoError(t, err)
+	_, err = fs.UpdateItems([]string{deletedID}, testUserID)
+	require.NoError(t, err)
+
+	// every append fails from now on
+	require.NoError(t, fs.log.file.Close())
+
+	_, err = fs.Insert(common.TestURL+"/new", testUserID, InsertOptions{})
+	assert.Error(t, err)
+	_, err = fs.UpdateItems([]string{id}, testUserID)
+	assert.Error(t, err)
+	_, err = fs.UpdateURL(URLChange{ShortURL: id, UserID: testUserID, OriginalURL: common.TestURL + "/fixed"})
+	assert.Error(t, err)
+	assert.Error(t, fs.RestoreURL(deletedID, testUserID))
+	assert.Error(t, fs.TransferURL(id, testUserID, "other"))
+	_, err = fs.MergeUser(testUserID, "other")
+	assert.Error(t, err)
+
+	urls, err := fs.GetUserURL(testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, []*UserItem{{ShortURL: id, OriginalURL: common.TestURL}}, urls, "failed changes are undone")
+	other, err := fs.GetUserURL("other")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+	history, err := fs.URLHistory(id, testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	_, err = fs.Get(deletedID)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+
+	same, err := fs.MemoryStorage.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "url stays deduplicated")
+	assert.Equal(t, id, same)
+}
+
+func TestFileStorage_RestoreDeleted(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{SyncPolicy: SyncNever})
+	require.NoError(t, err)
+
+	_, err = fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	_, err = fs.Insert(common.TestURL+"/2", testUserID, InsertOptions{})
+	require.NoError(t, err)
+	_, err = fs.UpdateItems([]string{common.TestShortID}, testUserID)
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+
+	restored, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer restored.Close()
+
+	_, err = restored.Get(common.TestShortID)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+
+	userURLs, err := restored.GetUserURL(testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserItem{{ShortURL: testShortID(common.TestURL + "/2"), OriginalURL: common.TestURL + "/2"}}, userURLs)
+}
+
+func TestFileStorage_Compact(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	_, err = fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	_, err = fs.UpdateItems([]string{common.TestShortID}, testUserID)
+	require.NoError(t, err)
+	require.NoError(t, fs.RestoreURL(common.TestShortID, testUserID))
+	_, err = fs.UpdateItems([]string{common.TestShortID}, testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, fs.log.Records())
+
+	require.NoError(t, fs.Compact(false))
+	assert.Equal(t, 4, fs.log.Records())
+
+	require.NoError(t, fs.Compact(true))
+	assert.Equal(t, 2, fs.log.Records())
+
+	_, err = fs.Insert(common.TestURL+"/2", testUserID, InsertOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+
+	restored, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer restored.Close()
+
+	_, err = restored.Get(common.TestShortID)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+	assert.Equal(t, 2, restored.URLCount())
+}
+
+func TestFileStorage_PingDuringCompact(t *testing.T) {
+	fs, err := NewFileStorage(filepath.Join(t.TempDir(), common.TestDBName), FileStorageOptions{})
+	require.NoError(t, err)
+	defer fs.Close()
+
+	_, err = fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			assert.NoError(t, fs.Compact(true))
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			assert.NoError(t, fs.Ping())
+		}
+	}
+}
+
+func TestFileStorage_RestoreExpiry(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	now := time.Now()
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	expired, err := fs.Insert(common.TestURL, testUserID, InsertOptions{ExpiresAt: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	alive, err := fs.Insert(common.TestURL+"/2", testUserID, InsertOptions{ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+
+	restored, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	_, err = restored.Get(expired)
+	assert.ErrorIs(t, err, common.ErrURLExpired)
+	_, err = restored.Get(alive)
+	assert.NoError(t, err)
+
+	purged, err := restored.PurgeExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	require.NoError(t, restored.Close())
+
+	restored, err = NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer restored.Close()
+
+	_, err = restored.Get(expired)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+}
+
+func TestFileStorage_RestoreClicks(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	id, err := fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fs.SaveClicks([]Click{{ShortURL: id, Time: time.Now(), IP: "10.0.0.0"}}))
+	require.NoError(t, fs.Compact(true))
+	require.NoError(t, fs.Close())
+
+	restored, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	defer restored.Close()
+
+	stats, err := restored.GetStats(id, testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalClicks)
+}
+
+func TestFileStorage_RestoreAccounts(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	account := Account{Email: "user@example.com", PasswordHash: "hash", UserID: testUserID, CreatedAt: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)}
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+
+	_, err = fs.Insert(common.TestURL, "anonymous", InsertOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fs.CreateAccount(account))
+	moved, err := fs.MergeUser("anonymous", testUserID)
+	require.NoError(t, err)
+	require.Equal(t, 1, moved)
+	require.NoError(t, fs.Close())
+
+	for _, compact := range []bool{false, true} {
+		restored, err := NewFileStorage(fileName, FileStorageOptions{})
+		require.NoError(t, err)
+
+		got, err := restored.GetUserAccount(testUserID)
+		assert.NoError(t, err)
+		assert.Equal(t, account, got)
+
+		urls, err := restored.GetUserURL(testUserID)
+		assert.NoError(t, err)
+		assert.Equal(t, []*UserItem{{ShortURL: common.TestShortID, OriginalURL: common.TestURL}}, urls)
+
+		if !compact {
+			require.NoError(t, restored.Compact(true))
+		}
+		require.NoError(t, restored.Close())
+	}
+}
+
+func TestFileStorage_RestoreAPIKeys(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	kept := APIKey{ID: "1", UserID: testUserID, Name: "ci", Prefix: "usk_abcdef", Hash: "hash1", Scopes: []string{"read"}, CreatedAt: created}
+	revoked := APIKey{ID: "2", UserID: testUserID, Hash: "hash2", Scopes: []string{"write"}, CreatedAt: created}
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fs.CreateAPIKey(kept))
+	require.NoError(t, fs.CreateAPIKey(revoked))
+	require.NoError(t, fs.RevokeAPIKey(revoked.ID, testUserID))
+	require.NoError(t, fs.Close())
+
+	for _, compact := range []bool{false, true} {
+		restored, err := NewFileStorage(fileName, FileStorageOptions{})
+		require.NoError(t, err)
+
+		keys, err := restored.ListAPIKeys(testUserID)
+		assert.NoError(t, err)
+		assert.Equal(t, []APIKey{kept}, keys)
+		_, err = restored.GetAPIKey(revoked.Hash)
+		assert.ErrorIs(t, err, common.ErrAPIKeyNotFound)
+
+		if !compact {
+			require.NoError(t, restored.Compact(true))
+		}
+		require.NoError(t, restored.Close())
+	}
+}
+
+func TestFileStorage_RestoreModeration(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	disabledAt := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	disabled, err := fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	enabled, err := fs.Insert(common.TestURL+"/1", testUserID, InsertOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fs.DisableURL(disabled, Moderation{Reason: "court order", Legal: true, DisabledAt: disabledAt}))
+	require.NoError(t, fs.DisableURL(enabled, Moderation{Reason: "spam", DisabledAt: disabledAt}))
+	require.NoError(t, fs.EnableURL(enabled))
+	require.NoError(t, fs.Close())
+
+	for _, compact := range []bool{false, true} {
+		restored, err := NewFileStorage(fileName, FileStorageOptions{})
+		require.NoError(t, err)
+
+		_, err = restored.Get(disabled)
+		var moderationErr *ModerationError
+		require.ErrorAs(t, err, &moderationErr)
+		assert.Equal(t, Moderation{Reason: "court order", Legal: true, DisabledAt: disabledAt}, moderationErr.Moderation)
+		_, err = restored.Get(enabled)
+		assert.NoError(t, err)
+
+		if !compact {
+			require.NoError(t, restored.Compact(true))
+		}
+		require.NoError(t, restored.Close())
+	}
+}
+
+func TestFileStorage_RestoreOwnership(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	changedAt := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	fs, err := NewFileStorage(fileName, FileStorageOptions{})
+	require.NoError(t, err)
+	id, err := fs.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	first, err := fs.UpdateURL(URLChange{ShortURL: id, OriginalURL: common.TestURL + "/1", UserID: testUserID, ChangedAt: changedAt})
+	require.NoError(t, err)
+	require.NoError(t, fs.TransferURL(id, testUserID, "other"))
+	second, err := fs.UpdateURL(URLChange{ShortURL: id, OriginalURL: common.TestURL + "/2", UserID: "other", ChangedAt: changedAt.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = fs.UpdateItems([]string{id}, "other")
+	require.NoError(t, err)
+	require.NoError(t, fs.RestoreURL(id, "other"))
+	require.NoError(t, fs.Close())
+
+	for _, compact := range []bool{false, true} {
+		restored, err := NewFileStorage(fileName, FileStorageOptions{})
+		require.NoError(t, err)
+
+		got, err := restored.Get(id)
+		assert.NoError(t, err)
+		assert.Equal(t, common.TestURL+"/2", got)
+
+		history, err := restored.URLHistory(id, "other")
+		assert.NoError(t, err)
+		assert.Equal(t, []URLChange{first, second}, history)
+		_, err = restored.URLHistory(id, testUserID)
+		assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+
+		if !compact {
+			require.NoError(t, restored.Compact(true))
+		}
+		require.NoError(t, restored.Close())
+	}
+}
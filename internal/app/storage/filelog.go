@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SyncPolicy defines when appended log records are flushed to disk
+type SyncPolicy string
+
+const (
+	// SyncAlways fsync after every append
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsync periodically in background
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leave fsync to the operating system
+	SyncNever SyncPolicy = "never"
+)
+
+const (
+	recordInsert  = "insert"
+	recordDelete  = "delete"
+	recordClick   = "click"
+	recordAccount = "account"
+	recordOwner   = "owner"
+	recordAPIKey  = "api_key"
+	recordRevoke  = "revoke"
+	recordDisable = "disable"
+	recordEnable  = "enable"
+	recordUpdate  = "update"
+	recordRestore = "restore"
+)
+
+// record single entry of the append-only storage log
+type record struct {
+	Op          string      `json:"op"`
+	ShortURL    string      `json:"short_url"`
+	OriginalURL string      `json:"original_url,omitempty"`
+	User        string      `json:"user_id,omitempty"`
+	ExpiresAt   *time.Time  `json:"expires_at,omitempty"`
+	Click       *Click      `json:"click,omitempty"`
+	Account     *Account    `json:"account,omitempty"`
+	APIKey      *APIKey     `json:"api_key,omitempty"`
+	Moderation  *Moderation `json:"moderation,omitempty"`
+	Change      *URLChange  `json:"change,omitempty"`
+}
+
+// recordLog append-only file of JSON records, one per line
+type recordLog struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	policy  SyncPolicy
+	dirty   bool
+	records int
+}
+
+// openRecordLog open log file for append, creating it with parent directories if needed
+func openRecordLog(path string, policy SyncPolicy) (*recordLog, error) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	switch policy {
+	case SyncAlways, SyncInterval, SyncNever:
+	default:
+		policy = SyncAlways
+	}
+
+	return &recordLog{
+		path:   path,
+		file:   file,
+		policy: policy,
+	}, nil
+}
+
+// Replay read every record from the log in order. Corrupted records are skipped,
+// a torn record at the end of the file is truncated
+func (l *recordLog) Replay(apply func(rec record)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset, goodOffset int64
+	tornTail := false
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			offset += int64(len(line))
+			complete := line[len(line)-1] == '\n'
+
+			records, decodeErr := decodeRecords(line)
+			if decodeErr != nil || !complete {
+				log.Printf("Storage log corrupted record at offset %d: %v", offset-int64(len(line)), decodeErr)
+				tornTail = true
+			} else {
+				for _, rec := range records {
+					apply(rec)
+				}
+				l.records += len(records)
+				goodOffset = offset
+				tornTail = false
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if tornTail {
+		log.Printf("Storage log torn tail truncated from %d to %d bytes", offset, goodOffset)
+		return l.file.Truncate(goodOffset)
+	}
+
+	return nil
+}
+
+// decodeRecords decode log line. Lines written before the record log are
+// whole map[shortURL]originalURL snapshots and restored as inserts
+func decodeRecords(line []byte) ([]record, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	var rec record
+	err := json.Unmarshal(line, &rec)
+	if err == nil && rec.Op != "" {
+		return []record{rec}, nil
+	}
+
+	legacy := make(map[string]string)
+	if err = json.Unmarshal(line, &legacy); err != nil {
+		return nil, err
+	}
+
+	records := make([]record, 0, len(legacy))
+	for shortURL, originalURL := range legacy {
+		records = append(records, record{Op: recordInsert, ShortURL: shortURL, OriginalURL: originalURL})
+	}
+
+	return records, nil
+}
+
+// Append write records to the end of the log with a single write
+func (l *recordLog) Append(records ...record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	l.records += len(records)
+
+	if l.policy == SyncAlways {
+		return l.file.Sync()
+	}
+	l.dirty = true
+
+	return nil
+}
+
+// Sync flush appended records to disk
+func (l *recordLog) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.sync()
+}
+
+func (l *recordLog) sync() error {
+	if !l.dirty {
+		return nil
+	}
+	l.dirty = false
+
+	return l.file.Sync()
+}
+
+// Check log file is still available
+func (l *recordLog) Check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := l.file.Stat()
+	return err
+}
+
+// Records number of records in the log
+func (l *recordLog) Records() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.records
+}
+
+// Compact replace log content by snapshot records. Snapshot is written to a
+// temporary file first so a crash leaves either the old or the new log
+func (l *recordLog) Compact(snapshot []record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tmpPath := l.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, rec := range snapshot {
+		if err = encoder.Encode(rec); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err = writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(l.path))
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+
+	if err = l.file.Close(); err != nil {
+		log.Printf("Storage log close error: %v", err)
+	}
+
+	l.file = file
+	l.records = len(snapshot)
+	l.dirty = false
+
+	return nil
+}
+
+// syncDir persist directory entry after rename
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	if err = d.Sync(); err != nil {
+		log.Printf("Storage log directory sync error: %v", err)
+	}
+}
+
+// Close flush and close log file
+func (l *recordLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.sync(); err != nil {
+		log.Printf("Storage log sync error: %v", err)
+	}
+
+	return l.file.Close()
+}
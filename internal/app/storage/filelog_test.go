@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func replayFile(t *testing.T, content string) ([]record, string) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0666))
+
+	recLog, err := openRecordLog(fileName, SyncAlways)
+	require.NoError(t, err)
+	defer recLog.Close()
+
+	var records []record
+	require.NoError(t, recLog.Replay(func(rec record) {
+		records = append(records, rec)
+	}))
+
+	b, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+
+	return records, string(b)
+}
+
+func TestRecordLog_Replay(t *testing.T) {
+	insert := `{"op":"insert","short_url":"1","original_url":"http://a.ru","user_id":"u"}` + "\n"
+	deleted := `{"op":"delete","short_url":"1"}` + "\n"
+
+	tests := []struct {
+		name     string
+		content  string
+		want     []record
+		wantFile string
+	}{
+		{
+			name:     "OK",
+			content:  insert + deleted,
+			want:     []record{{recordInsert, "1", "http://a.ru", "u", nil, nil, nil, nil, nil, nil}, {recordDelete, "1", "", "", nil, nil, nil, nil, nil, nil}},
+			wantFile: insert + deleted,
+		},
+		{
+			name:     "Torn tail truncated",
+			content:  insert + `{"op":"delete","sho`,
+			want:     []record{{recordInsert, "1", "http://a.ru", "u", nil, nil, nil, nil, nil, nil}},
+			wantFile: insert,
+		},
+		{
+			name:     "Corrupted tail line truncated",
+			content:  insert + "garbage\n",
+			want:     []record{{recordInsert, "1", "http://a.ru", "u", nil, nil, nil, nil, nil, nil}},
+			wantFile: insert,
+		},
+		{
+			name:     "Corrupted record skipped",
+			content:  insert + "garbage\n" + deleted,
+			want:     []record{{recordInsert, "1", "http://a.ru", "u", nil, nil, nil, nil, nil, nil}, {recordDelete, "1", "", "", nil, nil, nil, nil, nil, nil}},
+			wantFile: insert + "garbage\n" + deleted,
+		},
+		{
+			name:     "Legacy snapshot",
+			content:  `{"1":"http://a.ru"}` + "\n",
+			want:     []record{{recordInsert, "1", "http://a.ru", "", nil, nil, nil, nil, nil, nil}},
+			wantFile: `{"1":"http://a.ru"}` + "\n",
+		},
+		{
+			name:     "Empty",
+			content:  "",
+			want:     nil,
+			wantFile: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, file := replayFile(t, tt.content)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantFile, file)
+		})
+	}
+}
+
+func TestRecordLog_AppendAfterTruncate(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), common.TestDBName)
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"op":"ins`), 0666))
+
+	recLog, err := openRecordLog(fileName, SyncInterval)
+	require.NoError(t, err)
+	require.NoError(t, recLog.Replay(func(rec record) {}))
+	require.NoError(t, recLog.Append(record{Op: recordDelete, ShortURL: "1"}))
+	require.NoError(t, recLog.Close())
+
+	b, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, `{"op":"delete","short_url":"1"}`+"\n", string(b))
+}
@@ -0,0 +1,429 @@
+package storage
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/urlnorm"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shardCount number of independently locked shards of in-memory index
+const shardCount = 32
+
+type itemShard struct {
+	mu    sync.RWMutex
+	items map[string]*Item //map[shortURL]Item
+}
+
+type userShard struct {
+	mu    sync.RWMutex
+	items map[string][]userLink //map[userID][]userLink
+}
+
+// userLink short url linked to the user. Links are ordered by item sequence number
+type userLink struct {
+	seq uint64
+	id  string
+}
+
+type canonicalShard struct {
+	mu  sync.Mutex
+	ids map[string]string //map[userID+canonical url]shortURL
+}
+
+// memoryIndex concurrency-safe items index keyed by short url, by user ID and by user canonical url.
+// Keys are spread over shards so unrelated writes do not contend for one lock
+type memoryIndex struct {
+	seq       uint64 // sequence number of the last added item
+	items     [shardCount]itemShard
+	users     [shardCount]userShard
+	canonical [shardCount]canonicalShard
+}
+
+func newMemoryIndex() *memoryIndex {
+	idx := &memoryIndex{}
+	for i := 0; i < shardCount; i++ {
+		idx.items[i].items = make(map[string]*Item)
+		idx.users[i].items = make(map[string][]userLink)
+		idx.canonical[i].ids = make(map[string]string)
+	}
+
+	return idx
+}
+
+// canonicalKey key of user url in canonical form
+func canonicalKey(userID string, canonical string) string {
+	return userID + "\x00" + canonical
+}
+
+// lockCanonical lock user canonical url, so checking it has no live item and saving one is atomic
+func (idx *memoryIndex) lockCanonical(userID string, canonical string) func() {
+	c := &idx.canonical[shard(canonicalKey(userID, canonical))]
+	c.mu.Lock()
+	return c.mu.Unlock
+}
+
+// lookupCanonical short url of user live item with url of canonical form. Expired items are not live even before
+// they are purged. Caller holds canonical lock
+func (idx *memoryIndex) lookupCanonical(userID string, canonical string) (string, bool) {
+	key := canonicalKey(userID, canonical)
+	id, ok := idx.canonical[shard(key)].ids[key]
+	if !ok {
+		return "", false
+	}
+
+	item, found := idx.get(id)
+	if !found || item.Deleted || item.Expired(time.Now()) || item.User != userID || urlnorm.Canonical(item.OriginalURL) != canonical {
+		return "", false
+	}
+
+	return id, true
+}
+
+// rememberCanonical point user canonical url at short url. Caller holds canonical lock
+func (idx *memoryIndex) rememberCanonical(userID string, canonical string, id string) {
+	key := canonicalKey(userID, canonical)
+	idx.canonical[shard(key)].ids[key] = id
+}
+
+// indexCanonical add live item to canonical index unless user has other live item with the same url.
+// Entries of deleted, changed or transferred items are checked on lookup, so they are never removed
+func (idx *memoryIndex) indexCanonical(item Item) {
+	if item.Deleted || item.Expired(time.Now()) {
+		return
+	}
+
+	canonical := urlnorm.Canonical(item.OriginalURL)
+	unlock := idx.lockCanonical(item.User, canonical)
+	defer unlock()
+
+	if _, ok := idx.lookupCanonical(item.User, canonical); !ok {
+		idx.rememberCanonical(item.User, canonical, item.ShortURL)
+	}
+}
+
+// shard pick shard number by key
+func shard(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32() % shardCount
+}
+
+// put store item, replacing stored one with the same short url. Replaced item keeps its sequence number and clicks,
+// new items are numbered and linked to the owner
+func (idx *memoryIndex) put(item *Item) {
+	s := &idx.items[shard(item.ShortURL)]
+	s.mu.Lock()
+	existing, exists := s.items[item.ShortURL]
+	stored := *item
+	if exists {
+		stored.Seq, stored.Clicks = existing.Seq, existing.Clicks
+	} else {
+		stored.Seq = atomic.AddUint64(&idx.seq, 1)
+	}
+	s.items[item.ShortURL] = &stored
+	s.mu.Unlock()
+
+	if !exists {
+		idx.link(item.User, item.ShortURL, stored.Seq)
+	}
+}
+
+// insert store item unless short url is already taken. Returns the stored item on conflict
+func (idx *memoryIndex) insert(item *Item) (Item, bool) {
+	s := &idx.items[shard(item.ShortURL)]
+	s.mu.Lock()
+	if existing, ok := s.items[item.ShortURL]; ok {
+		found := *existing
+		s.mu.Unlock()
+		return found, false
+	}
+	stored := *item
+	stored.Seq = atomic.AddUint64(&idx.seq, 1)
+	s.items[item.ShortURL] = &stored
+	s.mu.Unlock()
+
+	idx.link(item.User, item.ShortURL, stored.Seq)
+	return stored, true
+}
+
+// link add short url to the user items at place of its sequence number
+func (idx *memoryIndex) link(userID string, id string, seq uint64) {
+	u := &idx.users[shard(userID)]
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	links := u.items[userID]
+	i := sort.Search(len(links), func(i int) bool {
+		return links[i].seq > seq
+	})
+	links = append(links, userLink{})
+	copy(links[i+1:], links[i:])
+	links[i] = userLink{seq: seq, id: id}
+	u.items[userID] = links
+}
+
+// unlink remove short url from the user items
+func (idx *memoryIndex) unlink(userID string, id string) {
+	u := &idx.users[shard(userID)]
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	links := u.items[userID]
+	for i, linked := range links {
+		if linked.id == id {
+			links = append(links[:i:i], links[i+1:]...)
+			break
+		}
+	}
+	if len(links) == 0 {
+		delete(u.items, userID)
+		return
+	}
+	u.items[userID] = links
+}
+
+// remove drop item and unlink it from the owner, as if it was never stored
+func (idx *memoryIndex) remove(id string) {
+	s := &idx.items[shard(id)]
+	s.mu.Lock()
+	item, ok := s.items[id]
+	delete(s.items, id)
+	s.mu.Unlock()
+
+	if ok {
+		idx.unlink(item.User, id)
+	}
+}
+
+// relink change item owner and move short url to the new owner items. Returns false if there
+// is no item with such short url
+func (idx *memoryIndex) relink(id string, userID string) bool {
+	var owner string
+	var seq uint64
+	if !idx.update(id, func(item *Item) bool {
+		owner, seq = item.User, item.Seq
+		item.User = userID
+		return true
+	}) {
+		return false
+	}
+
+	if owner != userID {
+		idx.unlink(owner, id)
+		idx.link(userID, id, seq)
+	}
+
+	return true
+}
+
+// transfer change owner of item owned by user and move short url to the new owner items. Returns false
+// if user has no item with such short url
+func (idx *memoryIndex) transfer(id string, fromUserID string, toUserID string) bool {
+	var seq uint64
+	if !idx.update(id, func(item *Item) bool {
+		if item.User != fromUserID {
+			return false
+		}
+		seq = item.Seq
+		item.User = toUserID
+		return true
+	}) {
+		return false
+	}
+
+	if fromUserID != toUserID {
+		idx.unlink(fromUserID, id)
+		idx.link(toUserID, id, seq)
+	}
+
+	return true
+}
+
+// reindex add item to canonical index after its url, owner or deleted state changed
+func (idx *memoryIndex) reindex(id string) {
+	if item, ok := idx.get(id); ok {
+		idx.indexCanonical(item)
+	}
+}
+
+// get copy of item by short url
+func (idx *memoryIndex) get(id string) (Item, bool) {
+	s := &idx.items[shard(id)]
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return Item{}, false
+	}
+
+	return *item, true
+}
+
+// update change stored item in place. Returns false if there is no item with such short url
+// or fn reports item was not changed
+func (idx *memoryIndex) update(id string, fn func(item *Item) bool) bool {
+	s := &idx.items[shard(id)]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return false
+	}
+
+	return fn(item)
+}
+
+// updateWhere change every stored item matching the filter in place. Returns short urls of changed items
+func (idx *memoryIndex) updateWhere(match func(item *Item) bool, fn func(item *Item)) []string {
+	var ids []string
+	for i := range idx.items {
+		s := &idx.items[i]
+		s.mu.Lock()
+		for id, item := range s.items {
+			if match(item) {
+				fn(item)
+				ids = append(ids, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return ids
+}
+
+// userItems copies of all items linked to the user, in order they were added
+func (idx *memoryIndex) userItems(userID string) ([]Item, bool) {
+	u := &idx.users[shard(userID)]
+	u.mu.RLock()
+	links, ok := u.items[userID]
+	links = append([]userLink(nil), links...)
+	u.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	items := make([]Item, 0, len(links))
+	for _, link := range links {
+		if item, found := idx.get(link.id); found {
+			items = append(items, item)
+		}
+	}
+
+	return items, true
+}
+
+// userRange call fn with copies of items linked to the user in order they were added, starting past the item
+// of sequence number after, zero starts at the first one. Desc walks backwards. Stops when fn returns false
+func (idx *memoryIndex) userRange(userID string, after uint64, desc bool, fn func(item Item) bool) {
+	u := &idx.users[shard(userID)]
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	links := u.items[userID]
+	if !desc {
+		i := sort.Search(len(links), func(i int) bool {
+			return links[i].seq > after
+		})
+		for ; i < len(links); i++ {
+			if item, ok := idx.get(links[i].id); ok && !fn(item) {
+				return
+			}
+		}
+		return
+	}
+
+	i := len(links)
+	if after != 0 {
+		i = sort.Search(len(links), func(i int) bool {
+			return links[i].seq >= after
+		})
+	}
+	for i--; i >= 0; i-- {
+		if item, ok := idx.get(links[i].id); ok && !fn(item) {
+			return
+		}
+	}
+}
+
+// userIDs short urls linked to the user starting at offset, in order they were added
+func (idx *memoryIndex) userIDs(userID string, offset int) []string {
+	u := &idx.users[shard(userID)]
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	links := u.items[userID]
+	if offset >= len(links) {
+		return nil
+	}
+
+	ids := make([]string, 0, len(links)-offset)
+	for _, link := range links[offset:] {
+		ids = append(ids, link.id)
+	}
+
+	return ids
+}
+
+// len number of stored items
+func (idx *memoryIndex) len() int {
+	count := 0
+	for i := range idx.items {
+		s := &idx.items[i]
+		s.mu.RLock()
+		count += len(s.items)
+		s.mu.RUnlock()
+	}
+
+	return count
+}
+
+// usersLen number of users having items
+func (idx *memoryIndex) usersLen() int {
+	count := 0
+	for i := range idx.users {
+		u := &idx.users[i]
+		u.mu.RLock()
+		count += len(u.items)
+		u.mu.RUnlock()
+	}
+
+	return count
+}
+
+// snapshot copies of all stored items, ordered as they were added
+func (idx *memoryIndex) snapshot() []Item {
+	var items []Item
+	seen := make(map[string]bool)
+	for i := range idx.users {
+		u := &idx.users[i]
+		u.mu.RLock()
+		users := make(map[string][]userLink, len(u.items))
+		for userID, links := range u.items {
+			users[userID] = append([]userLink(nil), links...)
+		}
+		u.mu.RUnlock()
+
+		for _, links := range users {
+			for _, link := range links {
+				if seen[link.id] {
+					continue
+				}
+				seen[link.id] = true
+				if item, ok := idx.get(link.id); ok {
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Seq < items[j].Seq
+	})
+
+	return items
+}
@@ -0,0 +1,835 @@
+package storage
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
+	"github.com/fd239/go_url_shortener/internal/app/urlnorm"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStorage in-memory repo, safe for concurrent use
+type MemoryStorage struct {
+	index *memoryIndex
+	seq   *shortid.Counter
+	gen   shortid.Generator
+
+	clicksMu sync.RWMutex
+	clicks   map[string][]Click //map[shortURL][]Click
+
+	accountsMu   sync.RWMutex
+	accounts     map[string]Account //map[email]Account
+	userAccounts map[string]string  //map[userID]email
+
+	apiKeysMu sync.RWMutex
+	apiKeys   map[string]APIKey //map[hash]APIKey
+	apiKeyIDs map[string]string //map[id]hash
+
+	moderationMu sync.RWMutex
+	moderation   map[string]Moderation //map[shortURL]Moderation
+
+	historyMu sync.RWMutex
+	history   map[string][]URLChange //map[shortURL][]URLChange
+}
+
+// NewMemoryStorage creating empty in-memory repo with hash short ID generator
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		index:        newMemoryIndex(),
+		seq:          shortid.NewCounter(0),
+		gen:          shortid.NewHash(shortid.DefaultLength),
+		clicks:       make(map[string][]Click),
+		accounts:     make(map[string]Account),
+		userAccounts: make(map[string]string),
+		apiKeys:      make(map[string]APIKey),
+		apiKeyIDs:    make(map[string]string),
+		moderation:   make(map[string]Moderation),
+		history:      make(map[string][]URLChange),
+	}
+}
+
+// Insert save short url and user ID to storage. Repeated insert of the same url by the same user returns
+// the existing short url with conflict error, urls are compared in canonical form, requested alias is not created
+// then. Taken short urls are regenerated
+func (m *MemoryStorage) Insert(item string, userID string, opts InsertOptions) (string, error) {
+	original, canonical, err := urlnorm.Normalize(item)
+	if err != nil {
+		return "", err
+	}
+	if opts.Alias != "" {
+		if err = shortid.ValidateAlias(opts.Alias); err != nil {
+			return "", err
+		}
+	}
+
+	unlock := m.index.lockCanonical(userID, canonical)
+	defer unlock()
+
+	if existing, found := m.index.lookupCanonical(userID, canonical); found {
+		return existing, common.ErrOriginalURLConflict
+	}
+
+	if opts.Alias != "" {
+		return m.insertAlias(original, canonical, userID, opts)
+	}
+
+	for attempt := 0; attempt < shortid.MaxAttempts; attempt++ {
+		id, err := m.gen.Generate(canonical, attempt)
+		if err != nil {
+			return "", err
+		}
+		if shortid.IsReserved(id) {
+			continue
+		}
+
+		if _, inserted := m.index.insert(&Item{
+			ShortURL:    id,
+			OriginalURL: original,
+			User:        userID,
+			ExpiresAt:   opts.ExpiresAt,
+		}); inserted {
+			m.index.rememberCanonical(userID, canonical, id)
+			return id, nil
+		}
+	}
+
+	return "", common.ErrShortIDCollision
+}
+
+// insertAlias save url under custom alias
+func (m *MemoryStorage) insertAlias(original string, canonical string, userID string, opts InsertOptions) (string, error) {
+	if _, inserted := m.index.insert(&Item{
+		ShortURL:    opts.Alias,
+		OriginalURL: original,
+		User:        userID,
+		ExpiresAt:   opts.ExpiresAt,
+	}); !inserted {
+		return "", common.ErrAliasConflict
+	}
+
+	m.index.rememberCanonical(userID, canonical, opts.Alias)
+
+	return opts.Alias, nil
+}
+
+// put store item and link it to the owner
+func (m *MemoryStorage) put(item *Item) {
+	m.index.put(item)
+	m.index.indexCanonical(*item)
+}
+
+// delete mark item as deleted. Returns false if there is no item with such short url
+func (m *MemoryStorage) delete(id string) bool {
+	return m.index.update(id, func(item *Item) bool {
+		item.Deleted = true
+		return true
+	})
+}
+
+// undelete mark item as not deleted and index its url again
+func (m *MemoryStorage) undelete(id string) {
+	m.index.update(id, func(item *Item) bool {
+		item.Deleted = false
+		return true
+	})
+	m.index.reindex(id)
+}
+
+// setOwner move item to user whoever owns it now and index its url again
+func (m *MemoryStorage) setOwner(id string, userID string) {
+	m.index.relink(id, userID)
+	m.index.reindex(id)
+}
+
+// snapshot all stored items, ordered as they were added for each user
+func (m *MemoryStorage) snapshot() []Item {
+	return m.index.snapshot()
+}
+
+// Get URL by id from storage
+func (m *MemoryStorage) Get(id string) (string, error) {
+	item, ok := m.index.get(id)
+	if !ok {
+		return "", common.ErrUnableToFindURL
+	}
+
+	m.moderationMu.RLock()
+	moderation, disabled := m.moderation[id]
+	m.moderationMu.RUnlock()
+	if disabled {
+		return "", &ModerationError{Moderation: moderation}
+	}
+
+	if item.Deleted {
+		return "", common.ErrURLDeleted
+	}
+
+	if item.Expired(time.Now()) {
+		return "", common.ErrURLExpired
+	}
+
+	return item.OriginalURL, nil
+}
+
+// GetUserURL receive all live user urls by userID, deleted and expired urls are skipped
+func (m *MemoryStorage) GetUserURL(userID string) ([]*UserItem, error) {
+	items, ok := m.index.userItems(userID)
+	if !ok {
+		return nil, nil
+	}
+
+	now := time.Now()
+	userURLs := make([]*UserItem, 0, len(items))
+	for _, item := range items {
+		if item.Deleted || item.Expired(now) {
+			continue
+		}
+		userURLs = append(userURLs, &UserItem{
+			ShortURL:    item.ShortURL,
+			OriginalURL: item.OriginalURL,
+		})
+	}
+
+	return userURLs, nil
+}
+
+// ListUserURLs page of user urls matching query. Deleted and expired items are skipped. Pages in default order
+// are read from the user index past the cursor, total is counted with the first page and carried by the cursor
+func (m *MemoryStorage) ListUserURLs(userID string, query UserURLQuery) (UserURLPage, error) {
+	l, err := query.parse()
+	if err != nil {
+		return UserURLPage{}, err
+	}
+
+	now := time.Now()
+	live := func(item Item) bool {
+		return !item.Deleted && !item.Expired(now) && l.match(item.OriginalURL)
+	}
+
+	page := UserURLPage{Items: make([]*UserItem, 0, l.limit), Total: l.cursor.Total}
+	if l.cursor.ShortURL == "" {
+		m.index.userRange(userID, 0, false, func(item Item) bool {
+			if live(item) {
+				page.Total++
+			}
+			return true
+		})
+	}
+
+	next := make([]Item, 0, l.limit+1)
+	if l.field == SortCreated {
+		m.index.userRange(userID, l.cursor.Seq, l.desc, func(item Item) bool {
+			if live(item) {
+				next = append(next, item)
+			}
+			return len(next) <= l.limit
+		})
+	} else {
+		next = m.topUserURLs(userID, l, live)
+	}
+
+	if len(next) > l.limit {
+		next = next[:l.limit]
+		last := next[len(next)-1]
+		page.NextCursor = cursor{Seq: last.Seq, Clicks: last.Clicks, ShortURL: last.ShortURL, Sort: l.sort, Total: page.Total}.encode()
+	}
+	for _, item := range next {
+		page.Items = append(page.Items, &UserItem{ShortURL: item.ShortURL, OriginalURL: item.OriginalURL})
+	}
+
+	return page, nil
+}
+
+// topUserURLs first limit+1 live user items past the cursor in listing order. Only that many items are kept
+// in a heap while user items are scanned
+func (m *MemoryStorage) topUserURLs(userID string, l listing, live func(item Item) bool) []Item {
+	last := Item{ShortURL: l.cursor.ShortURL, Seq: l.cursor.Seq, Clicks: l.cursor.Clicks}
+	h := &itemHeap{before: l.before}
+	m.index.userRange(userID, 0, false, func(item Item) bool {
+		if !live(item) || (last.ShortURL != "" && !l.before(last, item)) {
+			return true
+		}
+		if h.Len() <= l.limit {
+			heap.Push(h, item)
+		} else if l.before(item, h.items[0]) {
+			h.items[0] = item
+			heap.Fix(h, 0)
+		}
+		return true
+	})
+
+	sort.Slice(h.items, func(i, j int) bool {
+		return l.before(h.items[i], h.items[j])
+	})
+
+	return h.items
+}
+
+// itemHeap heap of items with the last one in listing order on top
+type itemHeap struct {
+	items  []Item
+	before func(a Item, b Item) bool
+}
+
+func (h *itemHeap) Len() int           { return len(h.items) }
+func (h *itemHeap) Less(i, j int) bool { return h.before(h.items[j], h.items[i]) }
+func (h *itemHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *itemHeap) Push(x interface{}) { h.items = append(h.items, x.(Item)) }
+func (h *itemHeap) Pop() interface{} {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
+
+// before item a goes before b in in-memory listing: by sequence number or by clicks, ties are broken by short url
+func (l listing) before(a Item, b Item) bool {
+	if l.desc {
+		a, b = b, a
+	}
+	if l.field == SortClicks && a.Clicks != b.Clicks {
+		return a.Clicks < b.Clicks
+	}
+	if l.field == SortCreated && a.Seq != b.Seq {
+		return a.Seq < b.Seq
+	}
+
+	return a.ShortURL < b.ShortURL
+}
+
+// CreateItems batch insert items to storage. Url the user already has is returned with existing short url,
+// unless item requests another alias for it. Batch is saved as a whole, nothing is saved if any item fails
+func (m *MemoryStorage) CreateItems(items []BatchItemRequest, userID string) ([]BatchItemResponse, error) {
+	batchItemsResponse, _, err := m.createItems(items, userID)
+	return batchItemsResponse, err
+}
+
+// createItems batch insert items. Returns short urls of newly saved items, items saved before a failed one are removed
+func (m *MemoryStorage) createItems(items []BatchItemRequest, userID string) ([]BatchItemResponse, []string, error) {
+	batchItemsResponse := make([]BatchItemResponse, 0, len(items))
+	created := make([]string, 0, len(items))
+	for _, item := range items {
+		shortURL, err := m.createItem(item, userID)
+		if err != nil && !existingItem(item, shortURL, err) {
+			m.removeItems(created)
+			return nil, nil, err
+		}
+		if err == nil {
+			created = append(created, shortURL)
+		}
+
+		batchItemsResponse = append(batchItemsResponse, BatchItemResponse{
+			CorrelationID: item.CorrelationID,
+			ShortURL:      fmt.Sprintf("%s/%s", config.Cfg.BaseURL, shortURL),
+		})
+	}
+
+	return batchItemsResponse, created, nil
+}
+
+// createItem insert batch item
+func (m *MemoryStorage) createItem(item BatchItemRequest, userID string) (string, error) {
+	expiresAt, err := item.Deadline(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return m.Insert(item.OriginalURL, userID, InsertOptions{Alias: item.Alias, ExpiresAt: expiresAt})
+}
+
+// removeItems drop items of unsaved batch
+func (m *MemoryStorage) removeItems(ids []string) {
+	for _, id := range ids {
+		m.index.remove(id)
+	}
+}
+
+// existingItem insert error means batch item is already saved: url is stored under requested alias or no alias is requested
+func existingItem(item BatchItemRequest, shortURL string, err error) bool {
+	return errors.Is(err, common.ErrOriginalURLConflict) && (item.Alias == "" || item.Alias == shortURL)
+}
+
+// deleteOwned mark item as deleted if it belongs to user. Returns false if user has no item with such short url
+func (m *MemoryStorage) deleteOwned(id string, userID string) bool {
+	return m.index.update(id, func(item *Item) bool {
+		if item.User != userID {
+			return false
+		}
+		item.Deleted = true
+		return true
+	})
+}
+
+// UpdateItems batch mark user items as deleted
+func (m *MemoryStorage) UpdateItems(itemsIDs []string, userID string) (DeleteResult, error) {
+	deleted := make(map[string]bool, len(itemsIDs))
+	for _, id := range itemsIDs {
+		if m.deleteOwned(id, userID) {
+			deleted[id] = true
+		}
+	}
+
+	return newDeleteResult(itemsIDs, deleted), nil
+}
+
+// DeleteBatch mark items of several users as deleted
+func (m *MemoryStorage) DeleteBatch(requests []DeleteRequest) ([]DeleteResult, error) {
+	results := make([]DeleteResult, 0, len(requests))
+	for _, req := range requests {
+		result, err := m.UpdateItems(req.IDs, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// PurgeExpired mark items expired by now as deleted
+func (m *MemoryStorage) PurgeExpired(now time.Time) (int, error) {
+	return len(m.expire(now)), nil
+}
+
+// expire mark items expired by now as deleted. Returns short urls of purged items
+func (m *MemoryStorage) expire(now time.Time) []string {
+	return m.index.updateWhere(func(item *Item) bool {
+		return !item.Deleted && item.Expired(now)
+	}, func(item *Item) {
+		item.Deleted = true
+	})
+}
+
+// SaveClicks store recorded redirects
+func (m *MemoryStorage) SaveClicks(clicks []Click) error {
+	m.clicksMu.Lock()
+	defer m.clicksMu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, click := range clicks {
+		m.clicks[click.ShortURL] = append(m.clicks[click.ShortURL], click)
+		counts[click.ShortURL]++
+	}
+	for id, count := range counts {
+		m.index.update(id, func(item *Item) bool {
+			item.Clicks += count
+			return true
+		})
+	}
+
+	return nil
+}
+
+// GetStats click statistics of short url owned by user
+func (m *MemoryStorage) GetStats(id string, userID string) (*LinkStats, error) {
+	item, ok := m.index.get(id)
+	if !ok || item.User != userID {
+		return nil, common.ErrUnableToFindURL
+	}
+
+	m.clicksMu.RLock()
+	clicks := append([]Click(nil), m.clicks[id]...)
+	m.clicksMu.RUnlock()
+
+	return NewLinkStats(id, clicks), nil
+}
+
+// CreateAccount register account
+func (m *MemoryStorage) CreateAccount(account Account) error {
+	m.accountsMu.Lock()
+	defer m.accountsMu.Unlock()
+
+	if _, ok := m.accounts[account.Email]; ok {
+		return common.ErrAccountExists
+	}
+	if _, ok := m.userAccounts[account.UserID]; ok {
+		return common.ErrAccountExists
+	}
+
+	m.accounts[account.Email] = account
+	m.userAccounts[account.UserID] = account.Email
+
+	return nil
+}
+
+// GetAccount account by email
+func (m *MemoryStorage) GetAccount(email string) (Account, error) {
+	m.accountsMu.RLock()
+	defer m.accountsMu.RUnlock()
+
+	account, ok := m.accounts[email]
+	if !ok {
+		return Account{}, common.ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+// GetUserAccount account of user
+func (m *MemoryStorage) GetUserAccount(userID string) (Account, error) {
+	m.accountsMu.RLock()
+	email, ok := m.userAccounts[userID]
+	m.accountsMu.RUnlock()
+
+	if !ok {
+		return Account{}, common.ErrAccountNotFound
+	}
+
+	return m.GetAccount(email)
+}
+
+// accountsSnapshot copies of all registered accounts
+func (m *MemoryStorage) accountsSnapshot() []Account {
+	m.accountsMu.RLock()
+	defer m.accountsMu.RUnlock()
+
+	accounts := make([]Account, 0, len(m.accounts))
+	for _, account := range m.accounts {
+		accounts = append(accounts, account)
+	}
+
+	return accounts
+}
+
+// MergeUser move all urls of one user to another
+func (m *MemoryStorage) MergeUser(fromUserID string, toUserID string) (int, error) {
+	return len(m.merge(fromUserID, toUserID)), nil
+}
+
+// merge move urls of one user to another. Live urls the other user already has are kept by the first user.
+// Returns short urls of moved items
+func (m *MemoryStorage) merge(fromUserID string, toUserID string) []string {
+	if fromUserID == toUserID {
+		return nil
+	}
+
+	var moved []string
+	for _, id := range m.index.userIDs(fromUserID, 0) {
+		if m.mergeItem(id, toUserID) {
+			moved = append(moved, id)
+		}
+	}
+
+	return moved
+}
+
+// mergeItem move item to other user unless that user has live item with the same url
+func (m *MemoryStorage) mergeItem(id string, toUserID string) bool {
+	item, ok := m.index.get(id)
+	if !ok {
+		return false
+	}
+	if item.Deleted {
+		return m.index.relink(id, toUserID)
+	}
+
+	canonical := urlnorm.Canonical(item.OriginalURL)
+	unlock := m.index.lockCanonical(toUserID, canonical)
+	defer unlock()
+
+	if _, taken := m.index.lookupCanonical(toUserID, canonical); taken || !m.index.relink(id, toUserID) {
+		return false
+	}
+	m.index.rememberCanonical(toUserID, canonical, id)
+
+	return true
+}
+
+// UpdateURL change destination of user short url and keep change in history. Returns ErrOriginalURLConflict
+// if user has other live short url of the new destination
+func (m *MemoryStorage) UpdateURL(change URLChange) (URLChange, error) {
+	original, canonical, err := urlnorm.Normalize(change.OriginalURL)
+	if err != nil {
+		return URLChange{}, err
+	}
+	change.OriginalURL = original
+
+	item, ok := m.index.get(change.ShortURL)
+	if !ok || item.User != change.UserID {
+		return URLChange{}, common.ErrUnableToFindURL
+	}
+	if item.Deleted {
+		return URLChange{}, common.ErrURLDeleted
+	}
+
+	unlock := m.index.lockCanonical(change.UserID, canonical)
+	defer unlock()
+
+	if existing, found := m.index.lookupCanonical(change.UserID, canonical); found && existing != change.ShortURL {
+		return URLChange{}, common.ErrOriginalURLConflict
+	}
+
+	if !m.index.update(change.ShortURL, func(item *Item) bool {
+		if item.User != change.UserID || item.Deleted {
+			return false
+		}
+		change.PreviousURL = item.OriginalURL
+		item.OriginalURL = change.OriginalURL
+		return true
+	}) {
+		return URLChange{}, common.ErrUnableToFindURL
+	}
+	m.index.rememberCanonical(change.UserID, canonical, change.ShortURL)
+
+	m.appendHistory(change)
+	return change, nil
+}
+
+// applyChange set destination of item and keep change in history, whoever owns the item now
+func (m *MemoryStorage) applyChange(change URLChange) {
+	m.index.update(change.ShortURL, func(item *Item) bool {
+		item.OriginalURL = change.OriginalURL
+		return true
+	})
+	m.index.reindex(change.ShortURL)
+	m.appendHistory(change)
+}
+
+// revertChange set previous destination of item and drop the change from history
+func (m *MemoryStorage) revertChange(change URLChange) {
+	m.index.update(change.ShortURL, func(item *Item) bool {
+		item.OriginalURL = change.PreviousURL
+		return true
+	})
+	m.index.reindex(change.ShortURL)
+
+	m.historyMu.Lock()
+	defer m.historyMu.Unlock()
+
+	if changes := m.history[change.ShortURL]; len(changes) > 1 {
+		m.history[change.ShortURL] = changes[:len(changes)-1]
+	} else {
+		delete(m.history, change.ShortURL)
+	}
+}
+
+// appendHistory keep destination change of short url
+func (m *MemoryStorage) appendHistory(change URLChange) {
+	m.historyMu.Lock()
+	defer m.historyMu.Unlock()
+
+	m.history[change.ShortURL] = append(m.history[change.ShortURL], change)
+}
+
+// RestoreURL undo deletion of user short url. Returns ErrOriginalURLConflict if user has other live short url
+// of the same url
+func (m *MemoryStorage) RestoreURL(id string, userID string) error {
+	item, ok := m.index.get(id)
+	if !ok || item.User != userID {
+		return common.ErrUnableToFindURL
+	}
+
+	canonical := urlnorm.Canonical(item.OriginalURL)
+	unlock := m.index.lockCanonical(userID, canonical)
+	defer unlock()
+
+	if existing, found := m.index.lookupCanonical(userID, canonical); found && existing != id {
+		return common.ErrOriginalURLConflict
+	}
+
+	if !m.index.update(id, func(item *Item) bool {
+		if item.User != userID {
+			return false
+		}
+		item.Deleted = false
+		return true
+	}) {
+		return common.ErrUnableToFindURL
+	}
+	m.index.rememberCanonical(userID, canonical, id)
+
+	return nil
+}
+
+// TransferURL hand user short url over to other user. Returns ErrOriginalURLConflict if other user already has
+// live short url of the same url
+func (m *MemoryStorage) TransferURL(id string, fromUserID string, toUserID string) error {
+	item, ok := m.index.get(id)
+	if !ok || item.User != fromUserID {
+		return common.ErrUnableToFindURL
+	}
+	if item.Deleted {
+		if !m.index.transfer(id, fromUserID, toUserID) {
+			return common.ErrUnableToFindURL
+		}
+		return nil
+	}
+
+	canonical := urlnorm.Canonical(item.OriginalURL)
+	unlock := m.index.lockCanonical(toUserID, canonical)
+	defer unlock()
+
+	if existing, found := m.index.lookupCanonical(toUserID, canonical); found && existing != id {
+		return common.ErrOriginalURLConflict
+	}
+
+	if !m.index.transfer(id, fromUserID, toUserID) {
+		return common.ErrUnableToFindURL
+	}
+	m.index.rememberCanonical(toUserID, canonical, id)
+
+	return nil
+}
+
+// URLHistory destination changes of user short url, oldest first
+func (m *MemoryStorage) URLHistory(id string, userID string) ([]URLChange, error) {
+	item, ok := m.index.get(id)
+	if !ok || item.User != userID {
+		return nil, common.ErrUnableToFindURL
+	}
+
+	m.historyMu.RLock()
+	defer m.historyMu.RUnlock()
+
+	return append(make([]URLChange, 0, len(m.history[id])), m.history[id]...), nil
+}
+
+// historySnapshot destination changes of all short urls
+func (m *MemoryStorage) historySnapshot() map[string][]URLChange {
+	m.historyMu.RLock()
+	defer m.historyMu.RUnlock()
+
+	snapshot := make(map[string][]URLChange, len(m.history))
+	for id, changes := range m.history {
+		snapshot[id] = append([]URLChange(nil), changes...)
+	}
+
+	return snapshot
+}
+
+// CreateAPIKey save api key of user
+func (m *MemoryStorage) CreateAPIKey(key APIKey) error {
+	m.apiKeysMu.Lock()
+	defer m.apiKeysMu.Unlock()
+
+	m.apiKeys[key.Hash] = key
+	m.apiKeyIDs[key.ID] = key.Hash
+
+	return nil
+}
+
+// GetAPIKey api key by hash
+func (m *MemoryStorage) GetAPIKey(hash string) (APIKey, error) {
+	m.apiKeysMu.RLock()
+	defer m.apiKeysMu.RUnlock()
+
+	key, ok := m.apiKeys[hash]
+	if !ok {
+		return APIKey{}, common.ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys api keys of user, in order they were created
+func (m *MemoryStorage) ListAPIKeys(userID string) ([]APIKey, error) {
+	m.apiKeysMu.RLock()
+	keys := make([]APIKey, 0)
+	for _, key := range m.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	m.apiKeysMu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// RevokeAPIKey delete api key of user
+func (m *MemoryStorage) RevokeAPIKey(id string, userID string) error {
+	m.apiKeysMu.Lock()
+	defer m.apiKeysMu.Unlock()
+
+	hash, ok := m.apiKeyIDs[id]
+	if !ok || m.apiKeys[hash].UserID != userID {
+		return common.ErrAPIKeyNotFound
+	}
+
+	delete(m.apiKeys, hash)
+	delete(m.apiKeyIDs, id)
+
+	return nil
+}
+
+// apiKeysSnapshot copies of all api keys
+func (m *MemoryStorage) apiKeysSnapshot() []APIKey {
+	m.apiKeysMu.RLock()
+	defer m.apiKeysMu.RUnlock()
+
+	keys := make([]APIKey, 0, len(m.apiKeys))
+	for _, key := range m.apiKeys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// DisableURL disable short url by moderator
+func (m *MemoryStorage) DisableURL(id string, moderation Moderation) error {
+	if _, ok := m.index.get(id); !ok {
+		return common.ErrUnableToFindURL
+	}
+
+	m.moderationMu.Lock()
+	defer m.moderationMu.Unlock()
+
+	m.moderation[id] = moderation
+	return nil
+}
+
+// EnableURL lift moderation of short url
+func (m *MemoryStorage) EnableURL(id string) error {
+	m.moderationMu.Lock()
+	defer m.moderationMu.Unlock()
+
+	if _, ok := m.moderation[id]; !ok {
+		return common.ErrUnableToFindURL
+	}
+
+	delete(m.moderation, id)
+	return nil
+}
+
+// moderationSnapshot moderation of all disabled short urls
+func (m *MemoryStorage) moderationSnapshot() map[string]Moderation {
+	m.moderationMu.RLock()
+	defer m.moderationMu.RUnlock()
+
+	snapshot := make(map[string]Moderation, len(m.moderation))
+	for id, moderation := range m.moderation {
+		snapshot[id] = moderation
+	}
+
+	return snapshot
+}
+
+// Ping in-memory storage is always available
+func (m *MemoryStorage) Ping() error {
+	return nil
+}
+
+// URLCount get saved url in storage
+func (m *MemoryStorage) URLCount() int {
+	return m.index.len()
+}
+
+// UserCount get uniq user count in storage
+func (m *MemoryStorage) UserCount() int {
+	return m.index.usersLen()
+}
+
+// Close nothing to release for in-memory storage
+func (m *MemoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,821 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorage_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []*Item
+		id      string
+		want    string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "OK",
+			items:   []*Item{{ShortURL: common.TestShortID, OriginalURL: common.TestURL, User: testUserID}},
+			id:      common.TestShortID,
+			want:    common.TestURL,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Error not found",
+			items:   []*Item{},
+			id:      common.TestURL,
+			want:    "",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "Error deleted",
+			items:   []*Item{{ShortURL: common.TestShortID, OriginalURL: common.TestURL, Deleted: true, User: testUserID}},
+			id:      common.TestShortID,
+			want:    "",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryStorage()
+			for _, item := range tt.items {
+				m.put(item)
+			}
+
+			got, err := m.Get(tt.id)
+			if !tt.wantErr(t, err, fmt.Sprintf("Get(%v)", tt.id)) {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "Get(%v)", tt.id)
+		})
+	}
+}
+
+func TestMemoryStorage_Insert(t *testing.T) {
+	m := NewMemoryStorage()
+
+	got, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestShortID, got)
+
+	url, err := m.Get(got)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL, url)
+}
+
+func TestMemoryStorage_InsertCanonical(t *testing.T) {
+	m := NewMemoryStorage()
+
+	first, err := m.Insert(" https://Example.com:443/a/ ", testUserID, InsertOptions{})
+	assert.NoError(t, err)
+
+	same, err := m.Insert("https://example.com/%61", testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
+	assert.Equal(t, first, same, "urls with the same canonical form are duplicates")
+
+	url, err := m.Get(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://Example.com:443/a/", url, "first original url is kept")
+
+	other, err := m.Insert("https://example.com/a", "other", InsertOptions{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	for _, invalid := range []string{"", "javascript:alert(1)", "example.com", "https://exa mple.com"} {
+		_, err = m.Insert(invalid, testUserID, InsertOptions{})
+		assert.ErrorIs(t, err, common.ErrInvalidURL, invalid)
+	}
+}
+
+func TestMemoryStorage_InsertManyUsers(t *testing.T) {
+	m := NewMemoryStorage()
+
+	ids := make(map[string]bool)
+	for i := 0; i < 2*shortid.MaxAttempts; i++ {
+		id, err := m.Insert(common.TestURL, fmt.Sprintf("user%d", i), InsertOptions{})
+		require.NoError(t, err, "user %d", i)
+		ids[id] = true
+	}
+	assert.Len(t, ids, 2*shortid.MaxAttempts, "every user gets own short url")
+}
+
+func TestMemoryStorage_InsertDedupeModes(t *testing.T) {
+	generators := map[string]func(m *MemoryStorage) shortid.Generator{
+		"hash":     func(*MemoryStorage) shortid.Generator { return shortid.NewHash(shortid.DefaultLength) },
+		"random":   func(*MemoryStorage) shortid.Generator { return shortid.NewRandom(shortid.DefaultLength) },
+		"sequence": func(m *MemoryStorage) shortid.Generator { return shortid.NewSequence(m.seq) },
+	}
+
+	for mode, gen := range generators {
+		t.Run(mode, func(t *testing.T) {
+			m := NewMemoryStorage()
+			m.gen = gen(m)
+
+			first, err := m.Insert("https://Example.com/a", testUserID, InsertOptions{})
+			require.NoError(t, err)
+			same, err := m.Insert("https://example.com/a/", testUserID, InsertOptions{})
+			assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
+			assert.Equal(t, first, same)
+
+			other, err := m.Insert("https://example.com/a", "other", InsertOptions{})
+			assert.NoError(t, err, "other user gets own link")
+			assert.NotEqual(t, first, other)
+
+			alias, err := m.Insert("https://example.com/b", testUserID, InsertOptions{Alias: "b-link"})
+			require.NoError(t, err)
+			same, err = m.Insert("https://EXAMPLE.com/b", testUserID, InsertOptions{})
+			assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
+			assert.Equal(t, alias, same, "plain insert after alias insert")
+
+			_, err = m.UpdateItems([]string{first}, testUserID)
+			require.NoError(t, err)
+			renewed, err := m.Insert("https://example.com/a", testUserID, InsertOptions{})
+			assert.NoError(t, err, "deleted link is not a duplicate")
+			assert.NotEqual(t, first, renewed)
+
+			urls, err := m.GetUserURL(testUserID)
+			require.NoError(t, err)
+			assert.Len(t, urls, 2)
+		})
+	}
+}
+
+func TestMemoryStorage_InsertAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		userID  string
+		alias   string
+		want    string
+		wantErr error
+	}{
+		{name: "OK", url: common.TestURL, userID: testUserID, alias: "spring-sale", want: "spring-sale"},
+		{name: "Repeated by owner", url: common.TestURL, userID: testUserID, alias: "spring-sale", wantErr: common.ErrOriginalURLConflict},
+		{name: "Conflict other url", url: common.TestURL + "/2", userID: testUserID, alias: "spring-sale", wantErr: common.ErrAliasConflict},
+		{name: "Conflict other user", url: common.TestURL, userID: "other", alias: "spring-sale", wantErr: common.ErrAliasConflict},
+		{name: "Invalid charset", url: common.TestURL, userID: testUserID, alias: "spring sale", wantErr: common.ErrInvalidAlias},
+		{name: "Reserved", url: common.TestURL, userID: testUserID, alias: "API", wantErr: common.ErrInvalidAlias},
+	}
+
+	m := NewMemoryStorage()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Insert(tt.url, tt.userID, InsertOptions{Alias: tt.alias})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			url, err := m.Get(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.url, url)
+		})
+	}
+}
+
+func TestMemoryStorage_Expiry(t *testing.T) {
+	m := NewMemoryStorage()
+	now := time.Now()
+
+	expired, err := m.Insert(common.TestURL, testUserID, InsertOptions{ExpiresAt: now.Add(-time.Minute)})
+	assert.NoError(t, err)
+	alive, err := m.Insert(common.TestURL+"/2", testUserID, InsertOptions{ExpiresAt: now.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	_, err = m.Get(expired)
+	assert.ErrorIs(t, err, common.ErrURLExpired)
+
+	url, err := m.Get(alive)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL+"/2", url)
+
+	userURLs, err := m.GetUserURL(testUserID)
+	assert.NoError(t, err)
+	assert.Len(t, userURLs, 1)
+
+	renewed, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, expired, renewed)
+
+	purged, err := m.PurgeExpired(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = m.Get(expired)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+
+	purged, err = m.PurgeExpired(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+}
+
+func TestMemoryStorage_GetStats(t *testing.T) {
+	m := NewMemoryStorage()
+	id, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, m.SaveClicks([]Click{
+		{ShortURL: id, Time: now, IP: "10.0.0.0"},
+		{ShortURL: id, Time: now, IP: "10.0.0.0"},
+		{ShortURL: "other", Time: now},
+	}))
+
+	stats, err := m.GetStats(id, testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalClicks)
+	assert.Equal(t, 1, stats.UniqueVisitors)
+
+	_, err = m.GetStats(id, "otherUser")
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+
+	_, err = m.GetStats("unknown", testUserID)
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+}
+
+func TestMemoryStorage_GetUserURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []*Item
+		userID  string
+		want    []*UserItem
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:   "OK",
+			items:  []*Item{{ShortURL: common.TestShortID, OriginalURL: common.TestURL, User: testUserID}},
+			userID: testUserID,
+			want: []*UserItem{{
+				ShortURL:    common.TestShortID,
+				OriginalURL: common.TestURL,
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Several user items",
+			items: []*Item{
+				{ShortURL: common.TestShortID, OriginalURL: common.TestURL, User: testUserID},
+				{ShortURL: "other", OriginalURL: common.TestURL + "/other", User: "otherUser"},
+				{ShortURL: "second", OriginalURL: common.TestURL + "/2", User: testUserID},
+			},
+			userID: testUserID,
+			want: []*UserItem{
+				{ShortURL: common.TestShortID, OriginalURL: common.TestURL},
+				{ShortURL: "second", OriginalURL: common.TestURL + "/2"},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Skip deleted",
+			items: []*Item{
+				{ShortURL: "deleted", OriginalURL: common.TestURL + "/1", Deleted: true, User: testUserID},
+				{ShortURL: common.TestShortID, OriginalURL: common.TestURL, User: testUserID},
+			},
+			userID: testUserID,
+			want: []*UserItem{{
+				ShortURL:    common.TestShortID,
+				OriginalURL: common.TestURL,
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "No user",
+			items:   []*Item{{ShortURL: common.TestShortID, OriginalURL: common.TestURL, User: testUserID}},
+			userID:  "unknown",
+			want:    nil,
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryStorage()
+			for _, item := range tt.items {
+				m.put(item)
+			}
+
+			got, err := m.GetUserURL(tt.userID)
+			if !tt.wantErr(t, err, fmt.Sprintf("GetUserURL(%v)", tt.userID)) {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "GetUserURL(%v)", tt.userID)
+		})
+	}
+}
+
+func TestMemoryStorage_ListUserURLs(t *testing.T) {
+	m := NewMemoryStorage()
+	for i := 0; i < 5; i++ {
+		_, err := m.Insert(fmt.Sprintf("%s/%d", common.TestURL, i), testUserID, InsertOptions{})
+		assert.NoError(t, err)
+	}
+	_, err := m.Insert(common.TestURL+"/other", "otherUser", InsertOptions{})
+	assert.NoError(t, err)
+	_, err = m.UpdateItems([]string{testShortID(common.TestURL + "/1")}, testUserID)
+	assert.NoError(t, err)
+
+	var got []string
+	cursor := ""
+	pages := 0
+	for {
+		page, err := m.ListUserURLs(testUserID, UserURLQuery{Cursor: cursor, Limit: 2})
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Items), 2)
+		for _, item := range page.Items {
+			got = append(got, item.OriginalURL)
+		}
+		assert.Equal(t, 4, page.Total)
+		pages++
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{common.TestURL + "/0", common.TestURL + "/2", common.TestURL + "/3", common.TestURL + "/4"}, got)
+
+	_, err = m.ListUserURLs(testUserID, UserURLQuery{Cursor: "not a cursor", Limit: 2})
+	assert.ErrorIs(t, err, common.ErrInvalidCursor)
+
+	page, err := m.ListUserURLs("unknown", UserURLQuery{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Items)
+	assert.Empty(t, page.NextCursor)
+	assert.Zero(t, page.Total)
+}
+
+func TestMemoryStorage_ListUserURLsStablePages(t *testing.T) {
+	m := NewMemoryStorage()
+	ids := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		id, err := m.Insert(fmt.Sprintf("%s/%d", common.TestURL, i), testUserID, InsertOptions{})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	for _, sort := range []string{"", "-created_at", "clicks", "-clicks"} {
+		t.Run("sort "+sort, func(t *testing.T) {
+			first, err := m.ListUserURLs(testUserID, UserURLQuery{Sort: sort, Limit: 2})
+			require.NoError(t, err)
+			require.Len(t, first.Items, 2)
+			seen := map[string]bool{first.Items[0].ShortURL: true, first.Items[1].ShortURL: true}
+
+			_, err = m.UpdateItems([]string{first.Items[0].ShortURL}, testUserID)
+			require.NoError(t, err)
+			added, err := m.Insert(common.TestURL+"/added"+sort, testUserID, InsertOptions{})
+			require.NoError(t, err)
+
+			next, err := m.ListUserURLs(testUserID, UserURLQuery{Sort: sort, Limit: 10, Cursor: first.NextCursor})
+			require.NoError(t, err)
+			for _, item := range next.Items {
+				assert.False(t, seen[item.ShortURL], "item is not repeated when previous page shrinks")
+				seen[item.ShortURL] = true
+			}
+			for _, id := range ids {
+				assert.True(t, seen[id] || id == first.Items[0].ShortURL, "item is not skipped")
+			}
+
+			require.NoError(t, m.RestoreURL(first.Items[0].ShortURL, testUserID))
+			_, err = m.UpdateItems([]string{added}, testUserID)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMemoryStorage_ListUserURLsOrder(t *testing.T) {
+	m := NewMemoryStorage()
+	transferred, err := m.Insert(common.TestURL+"/transferred", "other", InsertOptions{})
+	require.NoError(t, err)
+	for i := 0; i < 25; i++ {
+		id, err := m.Insert(fmt.Sprintf("%s/%d", common.TestURL, i), testUserID, InsertOptions{})
+		require.NoError(t, err)
+		clicks := make([]Click, i%4)
+		for j := range clicks {
+			clicks[j].ShortURL = id
+		}
+		require.NoError(t, m.SaveClicks(clicks))
+	}
+	require.NoError(t, m.TransferURL(transferred, "other", testUserID))
+
+	for _, sort := range []string{"", "-created_at", "clicks", "-clicks"} {
+		t.Run("sort "+sort, func(t *testing.T) {
+			all, err := m.ListUserURLs(testUserID, UserURLQuery{Sort: sort, Limit: MaxPageSize})
+			require.NoError(t, err)
+			require.Len(t, all.Items, 26)
+			if sort == "" {
+				assert.Equal(t, transferred, all.Items[0].ShortURL, "transferred url keeps its place")
+			}
+
+			var got []*UserItem
+			cursor := ""
+			for {
+				page, err := m.ListUserURLs(testUserID, UserURLQuery{Sort: sort, Limit: 4, Cursor: cursor})
+				require.NoError(t, err)
+				assert.Equal(t, 26, page.Total)
+				got = append(got, page.Items...)
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+			assert.Equal(t, all.Items, got)
+		})
+	}
+}
+
+func TestMemoryStorage_ListUserURLsQuery(t *testing.T) {
+	m := NewMemoryStorage()
+	urls := []string{"https://docs.example.com/a", "https://example.com/Report", "https://other.org/report", "https://notexample.com/"}
+	ids := make([]string, 0, len(urls))
+	for _, u := range urls {
+		id, err := m.Insert(u, testUserID, InsertOptions{})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	require.NoError(t, m.SaveClicks([]Click{{ShortURL: ids[2]}, {ShortURL: ids[2]}, {ShortURL: ids[0]}}))
+	require.NoError(t, m.SaveClicks([]Click{{ShortURL: ids[2]}, {ShortURL: ids[0]}, {ShortURL: ids[3]}}))
+
+	list := func(query UserURLQuery) ([]string, UserURLPage) {
+		page, err := m.ListUserURLs(testUserID, query)
+		require.NoError(t, err)
+		got := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			got = append(got, item.OriginalURL)
+		}
+		return got, page
+	}
+
+	tests := []struct {
+		name      string
+		query     UserURLQuery
+		want      []string
+		wantTotal int
+	}{
+		{name: "Newest first", query: UserURLQuery{Sort: "-created_at"}, want: []string{urls[3], urls[2], urls[1], urls[0]}, wantTotal: 4},
+		{name: "Most clicked first", query: UserURLQuery{Sort: "-clicks"}, want: []string{urls[2], urls[0], urls[3], urls[1]}, wantTotal: 4},
+		{name: "Least clicked first", query: UserURLQuery{Sort: "clicks"}, want: []string{urls[1], urls[3], urls[0], urls[2]}, wantTotal: 4},
+		{name: "Search", query: UserURLQuery{Search: "REPORT"}, want: []string{urls[1], urls[2]}, wantTotal: 2},
+		{name: "Domain", query: UserURLQuery{Domain: "Example.com"}, want: []string{urls[0], urls[1]}, wantTotal: 2},
+		{name: "Domain and search", query: UserURLQuery{Domain: "example.com", Search: "/a"}, want: []string{urls[0]}, wantTotal: 1},
+		{name: "Nothing matches", query: UserURLQuery{Domain: "missing.example"}, want: []string{}, wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, page := list(tt.query)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantTotal, page.Total)
+		})
+	}
+
+	got, page := list(UserURLQuery{Sort: "-clicks", Limit: 3})
+	assert.Equal(t, []string{urls[2], urls[0], urls[3]}, got)
+	require.NotEmpty(t, page.NextCursor)
+	got, page = list(UserURLQuery{Sort: "-clicks", Limit: 3, Cursor: page.NextCursor})
+	assert.Equal(t, []string{urls[1]}, got)
+	assert.Empty(t, page.NextCursor)
+
+	_, page = list(UserURLQuery{Sort: "-clicks", Limit: 3})
+	_, err := m.ListUserURLs(testUserID, UserURLQuery{Sort: "clicks", Cursor: page.NextCursor})
+	assert.ErrorIs(t, err, common.ErrInvalidCursor, "cursor of other sort")
+
+	_, err = m.ListUserURLs(testUserID, UserURLQuery{Sort: "title"})
+	assert.ErrorIs(t, err, common.ErrInvalidSort)
+}
+
+func TestMemoryStorage_CreateItems(t *testing.T) {
+	m := NewMemoryStorage()
+
+	got, err := m.CreateItems([]BatchItemRequest{{
+		CorrelationID: testItemID,
+		OriginalURL:   common.TestURL,
+	}}, testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []BatchItemResponse{{
+		CorrelationID: testItemID,
+		ShortURL:      "/" + common.TestShortID,
+	}}, got)
+
+	userURLs, err := m.GetUserURL(testUserID)
+	assert.NoError(t, err)
+	assert.Len(t, userURLs, 1)
+	assert.Equal(t, 1, m.URLCount())
+	assert.Equal(t, 1, m.UserCount())
+}
+
+func TestMemoryStorage_UpdateItems(t *testing.T) {
+	m := NewMemoryStorage()
+
+	_, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+
+	result, err := m.UpdateItems([]string{common.TestShortID}, "otherUser")
+	assert.NoError(t, err)
+	assert.Equal(t, DeleteResult{Deleted: []string{}, NotOwned: []string{common.TestShortID}}, result)
+
+	_, err = m.Get(common.TestShortID)
+	assert.NoError(t, err)
+
+	result, err = m.UpdateItems([]string{common.TestShortID, "unknown"}, testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, DeleteResult{Deleted: []string{common.TestShortID}, NotOwned: []string{"unknown"}}, result)
+
+	_, err = m.Get(common.TestShortID)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+
+	userURLs, err := m.GetUserURL(testUserID)
+	assert.NoError(t, err)
+	assert.Empty(t, userURLs)
+}
+
+func TestMemoryStorage_DeleteBatch(t *testing.T) {
+	m := NewMemoryStorage()
+
+	_, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+
+	results, err := m.DeleteBatch([]DeleteRequest{
+		{UserID: "otherUser", IDs: []string{common.TestShortID}},
+		{UserID: testUserID, IDs: []string{common.TestShortID, "unknown"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []DeleteResult{
+		{Deleted: []string{}, NotOwned: []string{common.TestShortID}},
+		{Deleted: []string{common.TestShortID}, NotOwned: []string{"unknown"}},
+	}, results)
+
+	_, err = m.Get(common.TestShortID)
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+}
+
+func TestMemoryStorage_Accounts(t *testing.T) {
+	m := NewMemoryStorage()
+	account := Account{Email: "user@example.com", PasswordHash: "hash", UserID: testUserID}
+
+	assert.NoError(t, m.CreateAccount(account))
+	assert.ErrorIs(t, m.CreateAccount(Account{Email: account.Email, UserID: "otherUser"}), common.ErrAccountExists)
+	assert.ErrorIs(t, m.CreateAccount(Account{Email: "other@example.com", UserID: testUserID}), common.ErrAccountExists)
+
+	got, err := m.GetAccount(account.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, account, got)
+
+	got, err = m.GetUserAccount(testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, account, got)
+
+	_, err = m.GetAccount("unknown@example.com")
+	assert.ErrorIs(t, err, common.ErrAccountNotFound)
+	_, err = m.GetUserAccount("otherUser")
+	assert.ErrorIs(t, err, common.ErrAccountNotFound)
+}
+
+func TestMemoryStorage_APIKeys(t *testing.T) {
+	m := NewMemoryStorage()
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	first := APIKey{ID: "1", UserID: testUserID, Hash: "hash1", Scopes: []string{"read"}, CreatedAt: created}
+	second := APIKey{ID: "2", UserID: testUserID, Hash: "hash2", Scopes: []string{"write"}, CreatedAt: created.Add(time.Second)}
+	other := APIKey{ID: "3", UserID: "otherUser", Hash: "hash3", CreatedAt: created}
+
+	for _, key := range []APIKey{second, other, first} {
+		assert.NoError(t, m.CreateAPIKey(key))
+	}
+
+	got, err := m.GetAPIKey("hash2")
+	assert.NoError(t, err)
+	assert.Equal(t, second, got)
+
+	keys, err := m.ListAPIKeys(testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []APIKey{first, second}, keys)
+
+	assert.ErrorIs(t, m.RevokeAPIKey(other.ID, testUserID), common.ErrAPIKeyNotFound)
+	assert.NoError(t, m.RevokeAPIKey(first.ID, testUserID))
+	assert.ErrorIs(t, m.RevokeAPIKey(first.ID, testUserID), common.ErrAPIKeyNotFound)
+
+	_, err = m.GetAPIKey("hash1")
+	assert.ErrorIs(t, err, common.ErrAPIKeyNotFound)
+	keys, err = m.ListAPIKeys(testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []APIKey{second}, keys)
+}
+
+func TestMemoryStorage_MergeUser(t *testing.T) {
+	m := NewMemoryStorage()
+
+	_, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+	_, err = m.Insert(common.TestURL+"/1", "anonymous", InsertOptions{})
+	assert.NoError(t, err)
+	_, err = m.Insert(common.TestURL+"/2", "anonymous", InsertOptions{})
+	assert.NoError(t, err)
+
+	moved, err := m.MergeUser("anonymous", testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, moved)
+
+	urls, err := m.GetUserURL(testUserID)
+	assert.NoError(t, err)
+	assert.Len(t, urls, 3)
+	assert.Equal(t, common.TestURL+"/2", urls[2].OriginalURL)
+
+	urls, err = m.GetUserURL("anonymous")
+	assert.NoError(t, err)
+	assert.Empty(t, urls)
+	assert.Equal(t, 1, m.UserCount())
+
+	moved, err = m.MergeUser(testUserID, testUserID)
+	assert.NoError(t, err)
+	assert.Zero(t, moved)
+
+	duplicate, err := m.Insert(common.TestURL, "anonymous", InsertOptions{})
+	require.NoError(t, err)
+	moved, err = m.MergeUser("anonymous", testUserID)
+	assert.NoError(t, err)
+	assert.Zero(t, moved, "url user already has is not moved")
+	item, _ := m.index.get(duplicate)
+	assert.Equal(t, "anonymous", item.User)
+}
+
+func TestMemoryStorage_Moderation(t *testing.T) {
+	m := NewMemoryStorage()
+
+	id, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, m.DisableURL("unknown", Moderation{}), common.ErrUnableToFindURL)
+	assert.ErrorIs(t, m.EnableURL(id), common.ErrUnableToFindURL, "url is not disabled")
+
+	require.NoError(t, m.DisableURL(id, Moderation{Reason: "phishing"}))
+	_, err = m.Get(id)
+	assert.ErrorIs(t, err, common.ErrURLDisabled)
+	assert.EqualError(t, err, "url disabled by moderator: phishing")
+
+	require.NoError(t, m.DisableURL(id, Moderation{Reason: "court order", Legal: true}))
+	_, err = m.Get(id)
+	assert.ErrorIs(t, err, common.ErrURLLegal)
+	var moderationErr *ModerationError
+	require.ErrorAs(t, err, &moderationErr)
+	assert.Equal(t, "court order", moderationErr.Moderation.Reason)
+
+	require.NoError(t, m.EnableURL(id))
+	got, err := m.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL, got)
+}
+
+func TestMemoryStorage_UpdateURL(t *testing.T) {
+	m := NewMemoryStorage()
+	changedAt := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	id, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+
+	_, err = m.UpdateURL(URLChange{ShortURL: id, OriginalURL: common.TestURL + "/new", UserID: "other"})
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+	_, err = m.UpdateURL(URLChange{ShortURL: id, OriginalURL: "not a url", UserID: testUserID})
+	assert.ErrorIs(t, err, common.ErrInvalidURL)
+
+	change, err := m.UpdateURL(URLChange{ShortURL: id, OriginalURL: " " + common.TestURL + "/new ", UserID: testUserID, ChangedAt: changedAt})
+	require.NoError(t, err)
+	assert.Equal(t, URLChange{ShortURL: id, PreviousURL: common.TestURL, OriginalURL: common.TestURL + "/new", UserID: testUserID, ChangedAt: changedAt}, change)
+
+	got, err := m.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL+"/new", got)
+
+	same, err := m.Insert(common.TestURL+"/new", testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "new destination is deduplicated")
+	assert.Equal(t, id, same)
+
+	history, err := m.URLHistory(id, testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []URLChange{change}, history)
+	_, err = m.URLHistory(id, "other")
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+
+	_, err = m.UpdateItems([]string{id}, testUserID)
+	require.NoError(t, err)
+	_, err = m.UpdateURL(URLChange{ShortURL: id, OriginalURL: common.TestURL + "/other", UserID: testUserID})
+	assert.ErrorIs(t, err, common.ErrURLDeleted)
+}
+
+func TestMemoryStorage_RestoreURL(t *testing.T) {
+	m := NewMemoryStorage()
+
+	id, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	_, err = m.UpdateItems([]string{id}, testUserID)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, m.RestoreURL(id, "other"), common.ErrUnableToFindURL)
+	assert.ErrorIs(t, m.RestoreURL("unknown", testUserID), common.ErrUnableToFindURL)
+
+	require.NoError(t, m.RestoreURL(id, testUserID))
+	got, err := m.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, common.TestURL, got)
+	assert.NoError(t, m.RestoreURL(id, testUserID), "restore of url which is not deleted")
+}
+
+func TestMemoryStorage_TransferURL(t *testing.T) {
+	m := NewMemoryStorage()
+
+	id, err := m.Insert(common.TestURL, testUserID, InsertOptions{})
+	require.NoError(t, err)
+	_, err = m.Insert(common.TestURL+"/kept", testUserID, InsertOptions{})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, m.TransferURL(id, "other", "third"), common.ErrUnableToFindURL)
+	require.NoError(t, m.TransferURL(id, testUserID, "other"))
+
+	urls, err := m.GetUserURL("other")
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserItem{{ShortURL: id, OriginalURL: common.TestURL}}, urls)
+	urls, err = m.GetUserURL(testUserID)
+	assert.NoError(t, err)
+	assert.Len(t, urls, 1)
+
+	assert.ErrorIs(t, m.TransferURL(id, testUserID, "other"), common.ErrUnableToFindURL, "url is not owned anymore")
+	_, err = m.URLHistory(id, "other")
+	assert.NoError(t, err)
+}
+
+func TestMemoryStorage_Concurrent(t *testing.T) {
+	m := NewMemoryStorage()
+
+	const workers = 8
+	const perWorker = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			userID := fmt.Sprintf("user%d", w)
+			for i := 0; i < perWorker; i++ {
+				url := fmt.Sprintf("%s/%d/%d", common.TestURL, w, i)
+				id, err := m.Insert(url, userID, InsertOptions{})
+				assert.NoError(t, err)
+
+				got, err := m.Get(id)
+				assert.NoError(t, err)
+				assert.Equal(t, url, got)
+
+				if i%2 == 0 {
+					_, err = m.UpdateItems([]string{id}, userID)
+					assert.NoError(t, err)
+				}
+				_, err = m.GetUserURL(userID)
+				assert.NoError(t, err)
+				m.URLCount()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, workers*perWorker, m.URLCount())
+	assert.Equal(t, workers, m.UserCount())
+	for w := 0; w < workers; w++ {
+		userURLs, err := m.GetUserURL(fmt.Sprintf("user%d", w))
+		assert.NoError(t, err)
+		assert.Len(t, userURLs, perWorker/2)
+	}
+}
+
+func BenchmarkGetURL_Map(b *testing.B) {
+	m := NewMemoryStorage()
+	for i := 0; i < 10000; i++ {
+		m.Insert(fmt.Sprintf("%s/%d", common.TestURL, i), testUserID, InsertOptions{})
+	}
+	id := testShortID(common.TestURL + "/5000")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Get(id)
+		}
+	})
+}
+
+func BenchmarkSaveURL_Map(b *testing.B) {
+	m := NewMemoryStorage()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		m.Insert(common.TestURL, testUserID, InsertOptions{})
+	}
+}
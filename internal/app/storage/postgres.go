@@ -0,0 +1,1016 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/fd239/go_url_shortener/config"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/migrate"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
+	"github.com/fd239/go_url_shortener/internal/app/urlnorm"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"log"
+	"sort"
+	"time"
+)
+
+// shortURLConstraint unique index on short url, violated on short ID collision
+const shortURLConstraint = "short_url_short_url_key"
+
+// canonicalURLConstraint unique index on user live canonical urls, violated when user would get second live link of url
+const canonicalURLConstraint = "short_url_user_canonical_url_key"
+
+// PostgresStorage postgres repo
+type PostgresStorage struct {
+	conn *sql.DB
+	gen  shortid.Generator
+}
+
+// pgSequence short ID sequence shared by all service instances
+type pgSequence struct {
+	conn *sql.DB
+}
+
+// Next get next value of postgres sequence
+func (s *pgSequence) Next() (n uint64, err error) {
+	err = s.conn.QueryRow(nextSequenceStmt).Scan(&n)
+	return
+}
+
+// isShortURLConflict check error is a short url unique index violation
+func isShortURLConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == shortURLConstraint
+}
+
+// isCanonicalURLConflict check error is a user canonical url unique index violation
+func isCanonicalURLConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == canonicalURLConstraint
+}
+
+// NewPostgresStorage connect to postgres by DSN. Pending schema migrations are applied if migrateSchema is set
+func NewPostgresStorage(dsn string, migrateSchema bool) (*PostgresStorage, error) {
+	conn, err := sql.Open("pgx", dsn)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return nil, err
+	}
+
+	if migrateSchema {
+		migrator, err := migrate.New(conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		if _, err = migrator.Up(context.Background()); err != nil {
+			log.Println("schema migration error: ", err.Error())
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return &PostgresStorage{
+		conn: conn,
+		gen:  shortid.NewHash(shortid.DefaultLength),
+	}, nil
+}
+
+// Insert save short url and user ID to storage. Taken short urls are regenerated
+func (p *PostgresStorage) Insert(item string, userID string, opts InsertOptions) (string, error) {
+	original, canonical, err := urlnorm.Normalize(item)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Alias != "" {
+		if err = shortid.ValidateAlias(opts.Alias); err != nil {
+			return "", err
+		}
+
+		shortURL, err := p.insert(original, canonical, opts.Alias, userID, opts.ExpiresAt)
+		if isShortURLConflict(err) {
+			return "", common.ErrAliasConflict
+		}
+		return shortURL, err
+	}
+
+	for attempt := 0; attempt < shortid.MaxAttempts; attempt++ {
+		hashString, err := p.gen.Generate(canonical, attempt)
+		if err != nil {
+			return "", err
+		}
+		if shortid.IsReserved(hashString) {
+			continue
+		}
+
+		shortURL, err := p.insert(original, canonical, hashString, userID, opts.ExpiresAt)
+		if isShortURLConflict(err) {
+			continue
+		}
+
+		return shortURL, err
+	}
+
+	return "", common.ErrShortIDCollision
+}
+
+// insert save item with given short url. Returns stored short url and conflict error if user already has live url
+// with the same canonical form. Expired duplicate which is not purged yet is marked deleted and insert is repeated
+func (p *PostgresStorage) insert(original string, canonical string, hashString string, userID string, expiresAt time.Time) (string, error) {
+	shortURL, ok, err := p.insertRow(original, canonical, hashString, userID, expiresAt)
+	if err != nil || ok {
+		return shortURL, err
+	}
+
+	if _, err = p.conn.Exec(purgeDuplicateStmt, userID, canonical); err != nil {
+		log.Println("PG Purge duplicate error: ", err.Error())
+		return "", err
+	}
+
+	shortURL, ok, err = p.insertRow(original, canonical, hashString, userID, expiresAt)
+	if err == nil && !ok {
+		return "", fmt.Errorf("url %s is neither inserted nor found", canonical)
+	}
+
+	return shortURL, err
+}
+
+// insertRow run insert statement. False if url is not inserted because of expired duplicate
+func (p *PostgresStorage) insertRow(original string, canonical string, hashString string, userID string, expiresAt time.Time) (string, bool, error) {
+	rows, err := p.conn.Query(insertStmt, original, canonical, hashString, userID, nullTime(expiresAt))
+	if err != nil {
+		log.Println("PG Save items error: ", err.Error())
+		return "", false, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			log.Println("PG Insert rows err error: ", err.Error())
+			return "", false, err
+		}
+		return "", false, nil
+	}
+
+	shortURL := ""
+	insertResult := 0
+	err = rows.Scan(&shortURL, &insertResult)
+	if err != nil {
+		log.Println("rows scan error: ", err)
+	}
+	if insertResult == PostgresSQLDuplicate {
+		return shortURL, true, common.ErrOriginalURLConflict
+	}
+
+	return hashString, true, nil
+}
+
+// Get URL by id from storage
+func (p *PostgresStorage) Get(id string) (string, error) {
+	var url string
+	var deleted bool
+	var expiresAt sql.NullTime
+	var disabledAt sql.NullTime
+	var moderation Moderation
+	err := p.conn.QueryRow(getOriginalURLStmt, id).Scan(&url, &deleted, &expiresAt, &disabledAt, &moderation.Reason, &moderation.Legal)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", common.ErrUnableToFindURL
+	}
+	if err != nil {
+		log.Println("PG Get short url query error: ", err.Error())
+		return "", err
+	}
+
+	if disabledAt.Valid {
+		moderation.DisabledAt = disabledAt.Time
+		return "", &ModerationError{Moderation: moderation}
+	}
+
+	if deleted {
+		return "", common.ErrURLDeleted
+	}
+
+	if expiresAt.Valid && !time.Now().Before(expiresAt.Time) {
+		return "", common.ErrURLExpired
+	}
+
+	return url, nil
+}
+
+// GetUserURL receive all live user urls by userID, deleted and expired urls are skipped
+func (p *PostgresStorage) GetUserURL(userID string) ([]*UserItem, error) {
+	userURLs := make([]*UserItem, 0)
+	rows, err := p.conn.Query(getUserURL, userID)
+
+	if err != nil {
+		log.Println("PG Get user urls query error: ", err.Error())
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		userItem := &UserItem{}
+		err = rows.Scan(&userItem.OriginalURL, &userItem.ShortURL)
+		if err != nil {
+			log.Println("PG Get user urls row scan error: ", err.Error())
+			return nil, err
+		}
+		userURLs = append(userURLs, userItem)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		log.Println("PG Get user urls rows err error: ", err.Error())
+		return nil, err
+	}
+
+	return userURLs, nil
+}
+
+// listUserURLsStmts user urls listing statements by sort, empty sort is default order
+var listUserURLsStmts = map[string]string{
+	"":                listUserURLsStmt,
+	"-" + SortCreated: listUserURLsDescStmt,
+	SortClicks:        listUserURLsByClicksStmt,
+	"-" + SortClicks:  listUserURLsByClicksDescStmt,
+}
+
+// ListUserURLs page of user urls matching query. Page is read past the last row of previous one
+func (p *PostgresStorage) ListUserURLs(userID string, query UserURLQuery) (UserURLPage, error) {
+	l, err := query.parse()
+	if err != nil {
+		return UserURLPage{}, err
+	}
+
+	now := time.Now()
+	page := UserURLPage{Items: make([]*UserItem, 0, l.limit)}
+	if err = p.conn.QueryRow(countUserURLsStmt, userID, now, l.search, l.domain).Scan(&page.Total); err != nil {
+		log.Println("PG Count user urls query error: ", err.Error())
+		return UserURLPage{}, err
+	}
+
+	var key interface{} = l.cursor.CreatedAt
+	if l.field == SortClicks {
+		key = l.cursor.Clicks
+	}
+	first := query.Cursor == ""
+
+	rows, err := p.conn.Query(listUserURLsStmts[l.sort], userID, now, l.search, l.domain, first, key, l.cursor.ShortURL, l.limit+1)
+	if err != nil {
+		log.Println("PG List user urls query error: ", err.Error())
+		return UserURLPage{}, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	last := cursor{Sort: l.sort}
+	for rows.Next() {
+		if len(page.Items) == l.limit {
+			page.NextCursor = last.encode()
+			break
+		}
+
+		userItem := &UserItem{}
+		if err = rows.Scan(&userItem.OriginalURL, &userItem.ShortURL, &last.CreatedAt, &last.Clicks); err != nil {
+			log.Println("PG List user urls row scan error: ", err.Error())
+			return UserURLPage{}, err
+		}
+		last.ShortURL = userItem.ShortURL
+		page.Items = append(page.Items, userItem)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("PG List user urls rows err error: ", err.Error())
+		return UserURLPage{}, err
+	}
+
+	return page, nil
+}
+
+// CreateItems batch insert items to postgres
+func (p *PostgresStorage) CreateItems(items []BatchItemRequest, userID string) ([]BatchItemResponse, error) {
+	ctx := context.Background()
+	tx, err := p.conn.Begin()
+	if err != nil {
+		log.Println("PG Context begin error: ", err.Error())
+		return nil, err
+	}
+
+	defer func(tx *sql.Tx) {
+		err = tx.Rollback()
+		if err != nil {
+			log.Println("transaction rollback error: ", err)
+		}
+	}(tx)
+
+	stmt, err := tx.PrepareContext(ctx, batchInsert)
+
+	if err != nil {
+		log.Println("PG prepare context error: ", err.Error())
+		return nil, err
+	}
+
+	defer func(stmt *sql.Stmt) {
+		err = stmt.Close()
+		if err != nil {
+			log.Println("statement close error: ", err)
+		}
+	}(stmt)
+
+	var batchItemsResponse []BatchItemResponse
+	for _, item := range items {
+		batchItemResponse := BatchItemResponse{}
+		shortURL, err := p.createItem(ctx, tx, stmt, item, userID)
+		if err != nil {
+			log.Println("PG exec context error: ", err.Error())
+			return nil, err
+		}
+
+		batchItemResponse.CorrelationID = item.CorrelationID
+		batchItemResponse.ShortURL = fmt.Sprintf("%s/%s", config.Cfg.BaseURL, shortURL)
+
+		batchItemsResponse = append(batchItemsResponse, batchItemResponse)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Println("PG tx commit error: ", err.Error())
+		return nil, err
+	}
+
+	return batchItemsResponse, nil
+}
+
+// createItem insert batch item inside transaction. Returns existing short url if url with the same canonical form is already saved,
+// conflict error if the item requests another alias for it
+func (p *PostgresStorage) createItem(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, item BatchItemRequest, userID string) (string, error) {
+	original, canonical, err := urlnorm.Normalize(item.OriginalURL)
+	if err != nil {
+		return "", err
+	}
+	if item.Alias != "" {
+		if err := shortid.ValidateAlias(item.Alias); err != nil {
+			return "", err
+		}
+	}
+
+	expiresAt, err := item.Deadline(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; attempt < shortid.MaxAttempts; attempt++ {
+		shortURL := item.Alias
+		if shortURL == "" {
+			if shortURL, err = p.gen.Generate(canonical, attempt); err != nil {
+				return "", err
+			}
+			if shortid.IsReserved(shortURL) {
+				continue
+			}
+		}
+
+		err = stmt.QueryRowContext(ctx, shortURL, original, canonical, userID, nullTime(expiresAt)).Scan(&shortURL)
+		if err == nil {
+			return shortURL, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+
+		err = tx.QueryRowContext(ctx, getShortURLStmt, userID, canonical).Scan(&shortURL)
+		if err == nil && item.Alias != "" && item.Alias != shortURL {
+			return "", common.ErrOriginalURLConflict
+		}
+		if err == nil {
+			return shortURL, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+
+		res, err := tx.ExecContext(ctx, purgeDuplicateStmt, userID, canonical)
+		if err != nil {
+			return "", err
+		}
+		if purged, _ := res.RowsAffected(); purged > 0 {
+			attempt--
+			continue
+		}
+		if item.Alias != "" {
+			return "", common.ErrAliasConflict
+		}
+	}
+
+	return "", common.ErrShortIDCollision
+}
+
+// UpdateItems batch mark user items as deleted in postgres
+func (p *PostgresStorage) UpdateItems(itemsIDs []string, userID string) (DeleteResult, error) {
+	ids := &pgtype.TextArray{}
+	if err := ids.Set(itemsIDs); err != nil {
+		return DeleteResult{}, err
+	}
+
+	rows, err := p.conn.Query(deleteItemsStmt, ids, userID)
+	if err != nil {
+		log.Printf("Items update error: %v\n", err)
+		return DeleteResult{}, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	deleted := make(map[string]bool, len(itemsIDs))
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			log.Println("PG Items update row scan error: ", err.Error())
+			return DeleteResult{}, err
+		}
+		deleted[id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("PG Items update rows err error: ", err.Error())
+		return DeleteResult{}, err
+	}
+
+	return newDeleteResult(itemsIDs, deleted), nil
+}
+
+// DeleteBatch mark items of several users as deleted with a single statement
+func (p *PostgresStorage) DeleteBatch(requests []DeleteRequest) ([]DeleteResult, error) {
+	var itemsIDs, userIDs []string
+	for _, req := range requests {
+		for _, id := range req.IDs {
+			itemsIDs = append(itemsIDs, id)
+			userIDs = append(userIDs, req.UserID)
+		}
+	}
+
+	ids, users := &pgtype.TextArray{}, &pgtype.TextArray{}
+	if err := ids.Set(itemsIDs); err != nil {
+		return nil, err
+	}
+	if err := users.Set(userIDs); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.conn.Query(deleteBatchStmt, ids, users)
+	if err != nil {
+		log.Printf("Items batch delete error: %v\n", err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	deleted := make(map[string]map[string]bool)
+	for rows.Next() {
+		var id, userID string
+		if err = rows.Scan(&id, &userID); err != nil {
+			log.Println("PG Items batch delete row scan error: ", err.Error())
+			return nil, err
+		}
+		if deleted[userID] == nil {
+			deleted[userID] = make(map[string]bool)
+		}
+		deleted[userID][id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("PG Items batch delete rows err error: ", err.Error())
+		return nil, err
+	}
+
+	results := make([]DeleteResult, 0, len(requests))
+	for _, req := range requests {
+		results = append(results, newDeleteResult(req.IDs, deleted[req.UserID]))
+	}
+
+	return results, nil
+}
+
+// PurgeExpired mark items expired by now as deleted
+func (p *PostgresStorage) PurgeExpired(now time.Time) (int, error) {
+	res, err := p.conn.Exec(purgeExpiredStmt, now)
+	if err != nil {
+		log.Printf("Expired items purge error: %v\n", err)
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	return int(purged), err
+}
+
+// SaveClicks batch insert recorded redirects
+func (p *PostgresStorage) SaveClicks(clicks []Click) error {
+	ctx := context.Background()
+	tx, err := p.conn.Begin()
+	if err != nil {
+		log.Println("PG Context begin error: ", err.Error())
+		return err
+	}
+
+	defer func(tx *sql.Tx) {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Println("transaction rollback error: ", err)
+		}
+	}(tx)
+
+	stmt, err := tx.PrepareContext(ctx, insertClickStmt)
+	if err != nil {
+		log.Println("PG prepare context error: ", err.Error())
+		return err
+	}
+
+	defer func(stmt *sql.Stmt) {
+		err = stmt.Close()
+		if err != nil {
+			log.Println("statement close error: ", err)
+		}
+	}(stmt)
+
+	counts := make(map[string]int64)
+	for _, click := range clicks {
+		if _, err = stmt.ExecContext(ctx, click.ShortURL, click.Time, click.Referrer, click.UserAgent, click.IP); err != nil {
+			log.Println("PG exec context error: ", err.Error())
+			return err
+		}
+		counts[click.ShortURL]++
+	}
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	clickCounts := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		clickCounts = append(clickCounts, counts[id])
+	}
+
+	urls, values := &pgtype.TextArray{}, &pgtype.Int8Array{}
+	if err = urls.Set(ids); err != nil {
+		return err
+	}
+	if err = values.Set(clickCounts); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, countClicksStmt, urls, values); err != nil {
+		log.Println("PG clicks count update error: ", err.Error())
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Println("PG tx commit error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetStats click statistics of short url owned by user
+func (p *PostgresStorage) GetStats(id string, userID string) (*LinkStats, error) {
+	if err := p.checkOwner(id, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.conn.Query(getClicksStmt, id)
+	if err != nil {
+		log.Println("PG Get clicks query error: ", err.Error())
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	var clicks []Click
+	for rows.Next() {
+		click := Click{ShortURL: id}
+		var referrer, userAgent, ip sql.NullString
+		if err = rows.Scan(&click.Time, &referrer, &userAgent, &ip); err != nil {
+			log.Println("PG Get clicks row scan error: ", err.Error())
+			return nil, err
+		}
+		click.Referrer, click.UserAgent, click.IP = referrer.String, userAgent.String, ip.String
+		clicks = append(clicks, click)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("PG Get clicks rows err error: ", err.Error())
+		return nil, err
+	}
+
+	return NewLinkStats(id, clicks), nil
+}
+
+// checkOwner ErrUnableToFindURL unless short url belongs to user
+func (p *PostgresStorage) checkOwner(id string, userID string) error {
+	var owner sql.NullString
+	err := p.conn.QueryRow(getURLOwnerStmt, id).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && owner.String != userID) {
+		return common.ErrUnableToFindURL
+	}
+	if err != nil {
+		log.Println("PG Get url owner query error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// CreateAccount register account in postgres
+func (p *PostgresStorage) CreateAccount(account Account) error {
+	_, err := p.conn.Exec(insertAccountStmt, account.Email, account.PasswordHash, account.UserID, account.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return common.ErrAccountExists
+	}
+	if err != nil {
+		log.Println("PG Save account error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetAccount account by email
+func (p *PostgresStorage) GetAccount(email string) (Account, error) {
+	return p.getAccount(getAccountStmt, email)
+}
+
+// GetUserAccount account of user
+func (p *PostgresStorage) GetUserAccount(userID string) (Account, error) {
+	return p.getAccount(getUserAccountStmt, userID)
+}
+
+// getAccount account selected by query with single argument
+func (p *PostgresStorage) getAccount(query string, arg string) (Account, error) {
+	var account Account
+	err := p.conn.QueryRow(query, arg).Scan(&account.Email, &account.PasswordHash, &account.UserID, &account.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Account{}, common.ErrAccountNotFound
+	}
+	if err != nil {
+		log.Println("PG Get account query error: ", err.Error())
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+// CreateAPIKey save api key of user in postgres
+func (p *PostgresStorage) CreateAPIKey(key APIKey) error {
+	scopes := &pgtype.TextArray{}
+	if err := scopes.Set(key.Scopes); err != nil {
+		return err
+	}
+
+	_, err := p.conn.Exec(insertAPIKeyStmt, key.ID, key.UserID, key.Name, key.Prefix, key.Hash, scopes, key.CreatedAt)
+	if err != nil {
+		log.Println("PG Save api key error: ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// rowScanner single row of query result, either *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey read api key columns of row
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var key APIKey
+	var scopes pgtype.TextArray
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt); err != nil {
+		return APIKey{}, err
+	}
+
+	if err := scopes.AssignTo(&key.Scopes); err != nil {
+		return APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// GetAPIKey api key by hash
+func (p *PostgresStorage) GetAPIKey(hash string) (APIKey, error) {
+	key, err := scanAPIKey(p.conn.QueryRow(getAPIKeyStmt, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, common.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		log.Println("PG Get api key query error: ", err.Error())
+		return APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys api keys of user, in order they were created
+func (p *PostgresStorage) ListAPIKeys(userID string) ([]APIKey, error) {
+	rows, err := p.conn.Query(listAPIKeysStmt, userID)
+	if err != nil {
+		log.Println("PG List api keys query error: ", err.Error())
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Println("PG List api keys row scan error: ", err.Error())
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("PG List api keys rows err error: ", err.Error())
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey delete api key of user
+func (p *PostgresStorage) RevokeAPIKey(id string, userID string) error {
+	res, err := p.conn.Exec(revokeAPIKeyStmt, id, userID)
+	if err != nil {
+		log.Printf("Api key revoke error: %v\n", err)
+		return err
+	}
+
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return common.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// DisableURL disable short url by moderator
+func (p *PostgresStorage) DisableURL(id string, moderation Moderation) error {
+	res, err := p.conn.Exec(disableURLStmt, id, moderation.DisabledAt, moderation.Reason, moderation.Legal)
+	if err != nil {
+		log.Printf("Url disable error: %v\n", err)
+		return err
+	}
+
+	return urlAffected(res)
+}
+
+// EnableURL lift moderation of short url
+func (p *PostgresStorage) EnableURL(id string) error {
+	res, err := p.conn.Exec(enableURLStmt, id)
+	if err != nil {
+		log.Printf("Url enable error: %v\n", err)
+		return err
+	}
+
+	return urlAffected(res)
+}
+
+// urlAffected ErrUnableToFindURL if statement changed no url
+func urlAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return common.ErrUnableToFindURL
+	}
+
+	return nil
+}
+
+// MergeUser move all urls of one user to another
+func (p *PostgresStorage) MergeUser(fromUserID string, toUserID string) (int, error) {
+	if fromUserID == toUserID {
+		return 0, nil
+	}
+
+	res, err := p.conn.Exec(mergeUserStmt, fromUserID, toUserID)
+	if err != nil {
+		log.Printf("User urls merge error: %v\n", err)
+		return 0, err
+	}
+
+	moved, err := res.RowsAffected()
+	return int(moved), err
+}
+
+// UpdateURL change destination of user short url and keep change in history table.
+// Returns ErrOriginalURLConflict if user has other live short url of the new destination
+func (p *PostgresStorage) UpdateURL(change URLChange) (URLChange, error) {
+	original, canonical, err := urlnorm.Normalize(change.OriginalURL)
+	if err != nil {
+		return URLChange{}, err
+	}
+	change.OriginalURL = original
+
+	tx, err := p.conn.Begin()
+	if err != nil {
+		log.Println("PG Context begin error: ", err.Error())
+		return URLChange{}, err
+	}
+
+	defer func(tx *sql.Tx) {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Println("transaction rollback error: ", err)
+		}
+	}(tx)
+
+	var deleted bool
+	err = tx.QueryRow(getOwnedURLStmt, change.ShortURL, change.UserID).Scan(&change.PreviousURL, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return URLChange{}, common.ErrUnableToFindURL
+	}
+	if err != nil {
+		log.Println("PG Get owned url query error: ", err.Error())
+		return URLChange{}, err
+	}
+	if deleted {
+		return URLChange{}, common.ErrURLDeleted
+	}
+
+	if _, err = tx.Exec(purgeDuplicateStmt, change.UserID, canonical); err != nil {
+		log.Println("PG Purge duplicate error: ", err.Error())
+		return URLChange{}, err
+	}
+
+	_, err = tx.Exec(updateURLStmt, change.ShortURL, original, canonical)
+	if isCanonicalURLConflict(err) {
+		return URLChange{}, common.ErrOriginalURLConflict
+	}
+	if err != nil {
+		log.Println("PG Update url error: ", err.Error())
+		return URLChange{}, err
+	}
+
+	_, err = tx.Exec(insertURLChangeStmt, change.ShortURL, change.PreviousURL, change.OriginalURL, change.UserID, change.ChangedAt)
+	if err != nil {
+		log.Println("PG Save url change error: ", err.Error())
+		return URLChange{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Println("PG tx commit error: ", err.Error())
+		return URLChange{}, err
+	}
+
+	return change, nil
+}
+
+// RestoreURL undo deletion of user short url. Returns ErrOriginalURLConflict if user has other live short url
+// of the same url
+func (p *PostgresStorage) RestoreURL(id string, userID string) error {
+	if _, err := p.conn.Exec(purgeDuplicateOfStmt, id, userID); err != nil {
+		log.Println("PG Purge duplicate error: ", err.Error())
+		return err
+	}
+
+	res, err := p.conn.Exec(restoreURLStmt, id, userID)
+	if isCanonicalURLConflict(err) {
+		return common.ErrOriginalURLConflict
+	}
+	if err != nil {
+		log.Printf("Url restore error: %v\n", err)
+		return err
+	}
+
+	return urlAffected(res)
+}
+
+// TransferURL hand user short url over to other user. Returns ErrOriginalURLConflict if other user already has
+// live short url of the same url
+func (p *PostgresStorage) TransferURL(id string, fromUserID string, toUserID string) error {
+	if _, err := p.conn.Exec(purgeDuplicateOfStmt, id, toUserID); err != nil {
+		log.Println("PG Purge duplicate error: ", err.Error())
+		return err
+	}
+
+	res, err := p.conn.Exec(transferURLStmt, id, fromUserID, toUserID)
+	if isCanonicalURLConflict(err) {
+		return common.ErrOriginalURLConflict
+	}
+	if err != nil {
+		log.Printf("Url transfer error: %v\n", err)
+		return err
+	}
+
+	return urlAffected(res)
+}
+
+// URLHistory destination changes of user short url, oldest first
+func (p *PostgresStorage) URLHistory(id string, userID string) ([]URLChange, error) {
+	if err := p.checkOwner(id, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.conn.Query(getURLHistoryStmt, id)
+	if err != nil {
+		log.Println("PG Get url history query error: ", err.Error())
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			log.Println("rows close error: ", err)
+		}
+	}(rows)
+
+	changes := make([]URLChange, 0)
+	for rows.Next() {
+		var change URLChange
+		if err = rows.Scan(&change.ShortURL, &change.PreviousURL, &change.OriginalURL, &change.UserID, &change.ChangedAt); err != nil {
+			log.Println("PG Get url history rows scan error: ", err.Error())
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("PG Get url history rows err error: ", err.Error())
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// nullTime nullable column value of expiry time, zero time is NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// Ping postgres health check
+func (p *PostgresStorage) Ping() error {
+	return p.conn.Ping()
+}
+
+// URLCount get saved url in storage
+func (p *PostgresStorage) URLCount() (counter int) {
+	p.conn.QueryRow(urlCountStmt).Scan(&counter)
+	return
+}
+
+// UserCount get uniq user count in storage
+func (p *PostgresStorage) UserCount() (counter int) {
+	p.conn.QueryRow(userCountStmt).Scan(&counter)
+	return
+}
+
+// Close postgres connection
+func (p *PostgresStorage) Close() error {
+	return p.conn.Close()
+}
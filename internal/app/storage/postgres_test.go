@@ -0,0 +1,1100 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupTestDatabase(t *testing.T) (*PostgresStorage, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return &PostgresStorage{conn: conn, gen: shortid.NewHash(shortid.DefaultLength)}, mock
+}
+
+func TestInsertURLOK(t *testing.T) {
+	var res string
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer conn.Close()
+
+	rows := sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLSuccessful)
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).WillReturnRows(rows)
+
+	var db = &PostgresStorage{conn: conn, gen: shortid.NewHash(shortid.DefaultLength)}
+
+	if res, err = db.Insert(common.TestURL, testUserID, InsertOptions{}); err != nil {
+		t.Errorf("error was not expected while inserting: %s", err)
+	}
+
+	assert.Equal(t, res, common.TestShortID)
+
+	// we make sure that all expectations were met
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertInvalidURL(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &PostgresStorage{conn: conn, gen: shortid.NewHash(shortid.DefaultLength)}
+
+	_, err = db.Insert("javascript:alert(1)", testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrInvalidURL)
+	assert.NoError(t, mock.ExpectationsWereMet(), "invalid url never reaches database")
+}
+
+func TestInsertCanonical(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	original := " HTTP://CJDR17AFEIHMK.biz:80/kdni9/z9womotrbk/ "
+	rows := sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLDuplicate)
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(strings.TrimSpace(original), common.TestURL, common.TestShortID, testUserID, nil).WillReturnRows(rows)
+
+	db := &PostgresStorage{conn: conn, gen: shortid.NewHash(shortid.DefaultLength)}
+
+	res, err := db.Insert(original, testUserID, InsertOptions{})
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
+	assert.Equal(t, common.TestShortID, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertDuplicateErr(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer conn.Close()
+
+	rows := sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLDuplicate)
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).WillReturnRows(rows)
+
+	var db = &PostgresStorage{conn: conn, gen: shortid.NewHash(shortid.DefaultLength)}
+
+	if _, err = db.Insert(common.TestURL, testUserID, InsertOptions{}); err == nil {
+		t.Error("error was expected while inserting")
+	}
+
+	assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
+
+	// we make sure that all expectations were met
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertShortURLCollision(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	nextID := testShortIDAttempt(common.TestURL, 1)
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).
+		WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: shortURLConstraint})
+	rows := sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(nextID, PostgresSQLSuccessful)
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, nextID, testUserID, nil).WillReturnRows(rows)
+
+	res, err := testDB.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, nextID, res)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertHashCandidatesTaken(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	for attempt := 0; attempt < shortid.HashAttempts; attempt++ {
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, testShortIDAttempt(common.TestURL, attempt), testUserID, nil).
+			WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: shortURLConstraint})
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, sqlmock.AnyArg(), testUserID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow("", PostgresSQLSuccessful))
+
+	res, err := testDB.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err, "url shortened by many users gets random short url")
+	assert.Len(t, res, shortid.DefaultLength)
+	assert.NotEqual(t, testShortIDAttempt(common.TestURL, shortid.HashAttempts), res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertExpiredDuplicate(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"shortURL", "insertResult"}))
+	mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, common.TestURL).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLSuccessful))
+
+	res, err := testDB.Insert(common.TestURL, testUserID, InsertOptions{})
+	assert.NoError(t, err, "expired link of the same url does not block insert")
+	assert.Equal(t, common.TestShortID, res)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertAliasConflict(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, "spring-sale", testUserID, nil).
+		WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: shortURLConstraint})
+
+	_, err := testDB.Insert(common.TestURL, testUserID, InsertOptions{Alias: "spring-sale"})
+	assert.ErrorIs(t, err, common.ErrAliasConflict)
+
+	_, err = testDB.Insert(common.TestURL, testUserID, InsertOptions{Alias: "debug"})
+	assert.ErrorIs(t, err, common.ErrInvalidAlias)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurgeExpiredPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	now := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta(purgeExpiredStmt)).WithArgs(now).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	purged, err := testDB.PurgeExpired(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSaveClicksPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta(insertClickStmt)).ExpectExec().
+		WithArgs(common.TestShortID, now, "https://example.com", "agent", "10.0.0.0").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(countClicksStmt)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := testDB.SaveClicks([]Click{{ShortURL: common.TestShortID, Time: now, Referrer: "https://example.com", UserAgent: "agent", IP: "10.0.0.0"}})
+	assert.NoError(t, err)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetStatsPostgres(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		userID    string
+		initMock  func(mock sqlmock.Sqlmock)
+		wantTotal int
+		wantErr   error
+	}{
+		{
+			name:   "OK",
+			userID: testUserID,
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getURLOwnerStmt)).WithArgs(common.TestShortID).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(testUserID))
+				mock.ExpectQuery(regexp.QuoteMeta(getClicksStmt)).WithArgs(common.TestShortID).
+					WillReturnRows(sqlmock.NewRows([]string{"clicked_at", "referrer", "user_agent", "ip"}).
+						AddRow(now, nil, "agent", "10.0.0.0").
+						AddRow(now, "https://example.com", "agent", "10.0.0.0"))
+			},
+			wantTotal: 2,
+		},
+		{
+			name:   "Not owner",
+			userID: "otherUser",
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getURLOwnerStmt)).WithArgs(common.TestShortID).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(testUserID))
+			},
+			wantErr: common.ErrUnableToFindURL,
+		},
+		{
+			name:   "Not found",
+			userID: testUserID,
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getURLOwnerStmt)).WithArgs(common.TestShortID).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			wantErr: common.ErrUnableToFindURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, mock := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			tt.initMock(mock)
+
+			stats, err := testDB.GetStats(common.TestShortID, tt.userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantTotal, stats.TotalClicks)
+				assert.Equal(t, 1, stats.UniqueVisitors)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetUrlPostgres(t *testing.T) {
+	type args struct {
+		id string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
+		want     string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			args: args{id: common.TestShortID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"url", "deleted", "expires_at", "disabled_at", "moderation_reason", "moderation_legal"}).AddRow(common.TestURL, false, nil, nil, "", false)
+				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
+				return mock
+			},
+			want:    common.TestURL,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Error. Deleted",
+			args: args{id: common.TestShortID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"url", "deleted", "expires_at", "disabled_at", "moderation_reason", "moderation_legal"}).AddRow(common.TestURL, true, nil, nil, "", false)
+				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
+				return mock
+			},
+			want:    "",
+			wantErr: assert.Error,
+		},
+		{
+			name: "OK not expired yet",
+			args: args{id: common.TestShortID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"url", "deleted", "expires_at", "disabled_at", "moderation_reason", "moderation_legal"}).AddRow(common.TestURL, false, time.Now().Add(time.Hour), nil, "", false)
+				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
+				return mock
+			},
+			want:    common.TestURL,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Error. Expired",
+			args: args{id: common.TestShortID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"url", "deleted", "expires_at", "disabled_at", "moderation_reason", "moderation_legal"}).AddRow(common.TestURL, false, time.Now().Add(-time.Hour), nil, "", false)
+				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
+				return mock
+			},
+			want: "",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, common.ErrURLExpired, i...)
+			},
+		},
+		{
+			name: "Error. Disabled",
+			args: args{id: common.TestShortID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"url", "deleted", "expires_at", "disabled_at", "moderation_reason", "moderation_legal"}).AddRow(common.TestURL, false, nil, time.Now(), "court order", true)
+				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
+				return mock
+			},
+			want: "",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, common.ErrURLLegal, i...)
+			},
+		},
+		{
+			name: "Query error",
+			args: args{id: common.TestShortID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnError(errors.New("test error"))
+				return mock
+			},
+			want:    "",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, mock := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			tt.initMock(mock)
+
+			got, err := testDB.Get(tt.args.id)
+
+			if !tt.wantErr(t, err, fmt.Sprintf("Get(%v)", tt.args.id)) {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "Get(%v, %v)", tt.args.id)
+
+			// we make sure that all expectations were met
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+
+		})
+	}
+
+}
+
+func TestGetUserUrlPostgres(t *testing.T) {
+	type args struct {
+		userID string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
+		want     []*UserItem
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			args: args{userID: testUserID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"OriginalURL", "ShortURL"}).AddRow(common.TestURL, common.TestShortID)
+				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
+				return mock
+			},
+			want: []*UserItem{{
+				ShortURL:    common.TestShortID,
+				OriginalURL: common.TestURL,
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Error. Rows scan error",
+			args: args{userID: testUserID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"test"}).AddRow("test")
+				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "Error. Query error",
+			args: args{userID: testUserID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnError(fmt.Errorf("some error"))
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "Row error",
+			args: args{userID: testUserID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"OriginalURL", "ShortURL"}).AddRow(common.TestURL, common.TestShortID)
+				rows.RowError(0, errors.New("test error"))
+				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "Row error",
+			args: args{userID: testUserID},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				rows := sqlmock.NewRows([]string{"OriginalURL", "ShortURL"}).AddRow(common.TestURL, common.TestShortID)
+				rows.RowError(0, errors.New("test error"))
+				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, mock := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			tt.initMock(mock)
+
+			got, err := testDB.GetUserURL(tt.args.userID)
+
+			if !tt.wantErr(t, err, "GetUserURL()") {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "GetUserURL()")
+
+			// we make sure that all expectations were met
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+}
+
+func TestCreateItemsPostgres(t *testing.T) {
+	type args struct {
+		items  []BatchItemRequest
+		userID string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
+		want     []BatchItemResponse
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				ShortURL:      common.TestShortID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+				mock.ExpectCommit()
+				return mock
+			},
+			want: []BatchItemResponse{{
+				CorrelationID: testItemID,
+				ShortURL:      "/" + common.TestShortID,
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "OK existing original url",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				mock.ExpectQuery(regexp.QuoteMeta(getShortURLStmt)).WithArgs(testUserID, common.TestURL).WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("existing"))
+				mock.ExpectCommit()
+				return mock
+			},
+			want: []BatchItemResponse{{
+				CorrelationID: testItemID,
+				ShortURL:      "/existing",
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "OK short url collision",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				nextID, _ := shortid.NewHash(shortid.DefaultLength).Generate(common.TestURL, 1)
+				mock.ExpectBegin()
+				prepare := mock.ExpectPrepare(regexp.QuoteMeta(batchInsert))
+				prepare.ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				mock.ExpectQuery(regexp.QuoteMeta(getShortURLStmt)).WithArgs(testUserID, common.TestURL).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, common.TestURL).WillReturnResult(sqlmock.NewResult(0, 0))
+				prepare.ExpectQuery().WithArgs(nextID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(nextID))
+				mock.ExpectCommit()
+				return mock
+			},
+			want: []BatchItemResponse{{
+				CorrelationID: testItemID,
+				ShortURL:      "/" + testShortIDAttempt(common.TestURL, 1),
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "OK expired duplicate",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin()
+				prepare := mock.ExpectPrepare(regexp.QuoteMeta(batchInsert))
+				prepare.ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				mock.ExpectQuery(regexp.QuoteMeta(getShortURLStmt)).WithArgs(testUserID, common.TestURL).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, common.TestURL).WillReturnResult(sqlmock.NewResult(0, 1))
+				prepare.ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+				mock.ExpectCommit()
+				return mock
+			},
+			want: []BatchItemResponse{{
+				CorrelationID: testItemID,
+				ShortURL:      "/" + common.TestShortID,
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Expect begin error",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				ShortURL:      common.TestShortID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin().WillReturnError(errTest)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "Expect prepare error",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				ShortURL:      common.TestShortID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).WillReturnError(errTest)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "Expect context error",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				ShortURL:      common.TestShortID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnError(errTest)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "Commit error",
+			args: args{userID: testUserID, items: []BatchItemRequest{{
+				CorrelationID: testItemID,
+				ShortURL:      common.TestShortID,
+				OriginalURL:   common.TestURL,
+			}}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+				mock.ExpectCommit().WillReturnError(errTest)
+				return mock
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, mock := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			tt.initMock(mock)
+
+			got, err := testDB.CreateItems(tt.args.items, tt.args.userID)
+
+			if !tt.wantErr(t, err, fmt.Sprintf("Create items(%v, %v)", tt.args.userID, tt.args.items)) {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "Create items(%v, %v)", tt.args.userID, tt.args.items)
+
+			// we make sure that all expectations were met
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+
+		})
+	}
+}
+
+func TestCreateItemsSameCorrelationIDPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	for _, userID := range []string{testUserID, "other"} {
+		mock.ExpectBegin()
+		mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, userID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+		mock.ExpectCommit()
+	}
+
+	for _, userID := range []string{testUserID, "other"} {
+		got, err := testDB.CreateItems([]BatchItemRequest{{CorrelationID: testItemID, OriginalURL: common.TestURL}}, userID)
+		require.NoError(t, err, "correlation id is not stored, so it may repeat")
+		assert.Equal(t, []BatchItemResponse{{CorrelationID: testItemID, ShortURL: "/" + common.TestShortID}}, got)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateItemsPostgres(t *testing.T) {
+	type args struct {
+		itemsIDs []string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
+		want     DeleteResult
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			args: args{itemsIDs: []string{testItemID, "other"}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectQuery(regexp.QuoteMeta(deleteItemsStmt)).WithArgs(`{123,other}`, testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(testItemID))
+				return mock
+			},
+			want:    DeleteResult{Deleted: []string{testItemID}, NotOwned: []string{"other"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Injection is a plain value",
+			args: args{itemsIDs: []string{"'); DROP TABLE short_url; --"}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectQuery(regexp.QuoteMeta(deleteItemsStmt)).WithArgs(`{'); DROP TABLE short_url; --}`, testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				return mock
+			},
+			want:    DeleteResult{Deleted: []string{}, NotOwned: []string{"'); DROP TABLE short_url; --"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Query error",
+			args: args{itemsIDs: []string{testItemID}},
+			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
+				mock.ExpectQuery(regexp.QuoteMeta(deleteItemsStmt)).WillReturnError(errTest)
+				return mock
+			},
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, mock := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			tt.initMock(mock)
+
+			got, err := testDB.UpdateItems(tt.args.itemsIDs, testUserID)
+
+			if !tt.wantErr(t, err, "UpdateItems()") {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			// we make sure that all expectations were met
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestListUserURLsPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"original_url", "short_url", "created_at", "click_count"}
+	mock.ExpectQuery(regexp.QuoteMeta(countUserURLsStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(listUserURLsStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "", "", true, time.Time{}, "", 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(common.TestURL+"/1", "first", created, 0).
+			AddRow(common.TestURL+"/2", "second", created, 0).
+			AddRow(common.TestURL+"/3", "third", created, 0))
+
+	page, err := testDB.ListUserURLs(testUserID, UserURLQuery{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserItem{
+		{ShortURL: "first", OriginalURL: common.TestURL + "/1"},
+		{ShortURL: "second", OriginalURL: common.TestURL + "/2"},
+	}, page.Items)
+	assert.Equal(t, 3, page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+
+	mock.ExpectQuery(regexp.QuoteMeta(countUserURLsStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(listUserURLsStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "", "", false, created, "second", 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(common.TestURL+"/3", "third", created, 0))
+
+	page, err = testDB.ListUserURLs(testUserID, UserURLQuery{Cursor: page.NextCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserItem{{ShortURL: "third", OriginalURL: common.TestURL + "/3"}}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = testDB.ListUserURLs(testUserID, UserURLQuery{Cursor: "%%%", Limit: 2})
+	assert.ErrorIs(t, err, common.ErrInvalidCursor)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListUserURLsQueryPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"original_url", "short_url", "created_at", "click_count"}
+	query := UserURLQuery{Limit: 1, Sort: "-clicks", Search: " Report ", Domain: "Example.com."}
+	mock.ExpectQuery(regexp.QuoteMeta(countUserURLsStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "report", "example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(listUserURLsByClicksDescStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "report", "example.com", true, int64(0), "", 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("https://example.com/report", "first", created, 7).
+			AddRow("https://docs.example.com/report", "second", created, 2))
+
+	page, err := testDB.ListUserURLs(testUserID, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserItem{{ShortURL: "first", OriginalURL: "https://example.com/report"}}, page.Items)
+	assert.Equal(t, 2, page.Total)
+
+	query.Cursor = page.NextCursor
+	mock.ExpectQuery(regexp.QuoteMeta(countUserURLsStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "report", "example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(listUserURLsByClicksDescStmt)).
+		WithArgs(testUserID, sqlmock.AnyArg(), "report", "example.com", false, int64(7), "first", 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("https://docs.example.com/report", "second", created, 2))
+
+	page, err = testDB.ListUserURLs(testUserID, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserItem{{ShortURL: "second", OriginalURL: "https://docs.example.com/report"}}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	query.Sort = "created_at"
+	_, err = testDB.ListUserURLs(testUserID, query)
+	assert.ErrorIs(t, err, common.ErrInvalidCursor, "cursor of other sort")
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteBatchPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(deleteBatchStmt)).WithArgs(`{123,other,123}`, `{user1,user1,user2}`).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "user_id"}).AddRow(testItemID, "user1"))
+
+	got, err := testDB.DeleteBatch([]DeleteRequest{
+		{UserID: "user1", IDs: []string{testItemID, "other"}},
+		{UserID: "user2", IDs: []string{testItemID}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []DeleteResult{
+		{Deleted: []string{testItemID}, NotOwned: []string{"other"}},
+		{Deleted: []string{}, NotOwned: []string{testItemID}},
+	}, got)
+
+	mock.ExpectQuery(regexp.QuoteMeta(deleteBatchStmt)).WillReturnError(errTest)
+	_, err = testDB.DeleteBatch([]DeleteRequest{{UserID: "user1", IDs: []string{testItemID}}})
+	assert.ErrorIs(t, err, errTest)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccountsPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	account := Account{Email: "user@example.com", PasswordHash: "hash", UserID: testUserID, CreatedAt: created}
+	columns := []string{"email", "password_hash", "user_id", "created_at"}
+
+	mock.ExpectExec(regexp.QuoteMeta(insertAccountStmt)).WithArgs(account.Email, account.PasswordHash, testUserID, created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	assert.NoError(t, testDB.CreateAccount(account))
+
+	mock.ExpectExec(regexp.QuoteMeta(insertAccountStmt)).WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+	assert.ErrorIs(t, testDB.CreateAccount(account), common.ErrAccountExists)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getAccountStmt)).WithArgs(account.Email).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(account.Email, account.PasswordHash, testUserID, created))
+	got, err := testDB.GetAccount(account.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, account, got)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getUserAccountStmt)).WithArgs("anonymous").WillReturnRows(sqlmock.NewRows(columns))
+	_, err = testDB.GetUserAccount("anonymous")
+	assert.ErrorIs(t, err, common.ErrAccountNotFound)
+
+	mock.ExpectExec(regexp.QuoteMeta(mergeUserStmt)).WithArgs("anonymous", testUserID).WillReturnResult(sqlmock.NewResult(0, 2))
+	moved, err := testDB.MergeUser("anonymous", testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, moved)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAPIKeysPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	key := APIKey{ID: "1", UserID: testUserID, Name: "ci", Prefix: "usk_abcdef", Hash: "hash1", Scopes: []string{"read", "write"}, CreatedAt: created}
+	columns := []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "created_at"}
+
+	mock.ExpectExec(regexp.QuoteMeta(insertAPIKeyStmt)).WithArgs(key.ID, testUserID, key.Name, key.Prefix, key.Hash, `{read,write}`, created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	assert.NoError(t, testDB.CreateAPIKey(key))
+
+	mock.ExpectQuery(regexp.QuoteMeta(getAPIKeyStmt)).WithArgs(key.Hash).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(key.ID, testUserID, key.Name, key.Prefix, key.Hash, `{read,write}`, created))
+	got, err := testDB.GetAPIKey(key.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getAPIKeyStmt)).WithArgs("unknown").WillReturnRows(sqlmock.NewRows(columns))
+	_, err = testDB.GetAPIKey("unknown")
+	assert.ErrorIs(t, err, common.ErrAPIKeyNotFound)
+
+	mock.ExpectQuery(regexp.QuoteMeta(listAPIKeysStmt)).WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(key.ID, testUserID, key.Name, key.Prefix, key.Hash, `{read,write}`, created))
+	keys, err := testDB.ListAPIKeys(testUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []APIKey{key}, keys)
+
+	mock.ExpectExec(regexp.QuoteMeta(revokeAPIKeyStmt)).WithArgs(key.ID, testUserID).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, testDB.RevokeAPIKey(key.ID, testUserID))
+	mock.ExpectExec(regexp.QuoteMeta(revokeAPIKeyStmt)).WithArgs(key.ID, testUserID).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, testDB.RevokeAPIKey(key.ID, testUserID), common.ErrAPIKeyNotFound)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestModerationPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	moderation := Moderation{Reason: "spam", DisabledAt: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)}
+
+	mock.ExpectExec(regexp.QuoteMeta(disableURLStmt)).WithArgs(common.TestShortID, moderation.DisabledAt, moderation.Reason, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, testDB.DisableURL(common.TestShortID, moderation))
+	mock.ExpectExec(regexp.QuoteMeta(disableURLStmt)).WithArgs("unknown", moderation.DisabledAt, moderation.Reason, false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, testDB.DisableURL("unknown", moderation), common.ErrUnableToFindURL)
+
+	mock.ExpectExec(regexp.QuoteMeta(enableURLStmt)).WithArgs(common.TestShortID).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, testDB.EnableURL(common.TestShortID))
+	mock.ExpectExec(regexp.QuoteMeta(enableURLStmt)).WithArgs(common.TestShortID).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, testDB.EnableURL(common.TestShortID), common.ErrUnableToFindURL)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateURLPostgres(t *testing.T) {
+	changedAt := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	newURL := common.TestURL + "/new"
+	change := URLChange{ShortURL: common.TestShortID, OriginalURL: newURL, UserID: testUserID, ChangedAt: changedAt}
+
+	tests := []struct {
+		name     string
+		initMock func(sqlmock.Sqlmock)
+		want     URLChange
+		wantErr  error
+	}{
+		{
+			name: "OK",
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getOwnedURLStmt)).WithArgs(common.TestShortID, testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"original_url", "deleted"}).AddRow(common.TestURL, false))
+				mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, newURL).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(updateURLStmt)).WithArgs(common.TestShortID, newURL, newURL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(insertURLChangeStmt)).WithArgs(common.TestShortID, common.TestURL, newURL, testUserID, changedAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: URLChange{ShortURL: common.TestShortID, PreviousURL: common.TestURL, OriginalURL: newURL, UserID: testUserID, ChangedAt: changedAt},
+		},
+		{
+			name: "Not owned",
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getOwnedURLStmt)).WithArgs(common.TestShortID, testUserID).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: common.ErrUnableToFindURL,
+		},
+		{
+			name: "Deleted",
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getOwnedURLStmt)).WithArgs(common.TestShortID, testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"original_url", "deleted"}).AddRow(common.TestURL, true))
+				mock.ExpectRollback()
+			},
+			wantErr: common.ErrURLDeleted,
+		},
+		{
+			name: "Destination already shortened",
+			initMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getOwnedURLStmt)).WithArgs(common.TestShortID, testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"original_url", "deleted"}).AddRow(common.TestURL, false))
+				mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, newURL).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(updateURLStmt)).WithArgs(common.TestShortID, newURL, newURL).
+					WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: canonicalURLConstraint})
+				mock.ExpectRollback()
+			},
+			wantErr: common.ErrOriginalURLConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, mock := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			tt.initMock(mock)
+
+			got, err := testDB.UpdateURL(change)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestOwnershipPostgres(t *testing.T) {
+	testDB, mock := setupTestDatabase(t)
+	defer testDB.conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateOfStmt)).WithArgs(common.TestShortID, testUserID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(restoreURLStmt)).WithArgs(common.TestShortID, testUserID).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, testDB.RestoreURL(common.TestShortID, testUserID))
+	mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateOfStmt)).WithArgs(common.TestShortID, "other").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(restoreURLStmt)).WithArgs(common.TestShortID, "other").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, testDB.RestoreURL(common.TestShortID, "other"), common.ErrUnableToFindURL)
+
+	mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateOfStmt)).WithArgs(common.TestShortID, "other").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(transferURLStmt)).WithArgs(common.TestShortID, testUserID, "other").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, testDB.TransferURL(common.TestShortID, testUserID, "other"))
+	mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateOfStmt)).WithArgs(common.TestShortID, "other").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(transferURLStmt)).WithArgs(common.TestShortID, testUserID, "other").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, testDB.TransferURL(common.TestShortID, testUserID, "other"), common.ErrUnableToFindURL)
+
+	changedAt := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(getURLOwnerStmt)).WithArgs(common.TestShortID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("other"))
+	mock.ExpectQuery(regexp.QuoteMeta(getURLHistoryStmt)).WithArgs(common.TestShortID).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "previous_url", "original_url", "user_id", "changed_at"}).
+			AddRow(common.TestShortID, common.TestURL, common.TestURL+"/new", testUserID, changedAt))
+	history, err := testDB.URLHistory(common.TestShortID, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, []URLChange{{ShortURL: common.TestShortID, PreviousURL: common.TestURL, OriginalURL: common.TestURL + "/new", UserID: testUserID, ChangedAt: changedAt}}, history)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getURLOwnerStmt)).WithArgs(common.TestShortID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("other"))
+	_, err = testDB.URLHistory(common.TestShortID, testUserID)
+	assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPostgresStorage_Ping(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "OK",
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB, _ := setupTestDatabase(t)
+			defer testDB.conn.Close()
+
+			err := testDB.Ping()
+			if !tt.wantErr(t, err, "InitDB()") {
+				return
+			}
+		})
+	}
+
+}
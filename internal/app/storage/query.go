@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"net/url"
+	"strings"
+)
+
+// Sort fields of user urls listing. Field prefixed with "-" sorts in descending order
+const (
+	SortCreated = "created_at"
+	SortClicks  = "clicks"
+)
+
+// UserURLQuery user urls listing settings. Zero query is the first page of all user urls in order they were created
+type UserURLQuery struct {
+	// Cursor next cursor of previous page
+	Cursor string
+	// Limit page size, DefaultPageSize if not set
+	Limit int
+	// Sort field, created_at or clicks
+	Sort string
+	// Search case insensitive substring of original url
+	Search string
+	// Domain host of original url, its subdomains are matched too
+	Domain string
+}
+
+// listing parsed user urls query
+type listing struct {
+	sort   string
+	field  string
+	desc   bool
+	cursor cursor
+	limit  int
+	search string
+	domain string
+}
+
+// parse validate query and decode its cursor
+func (q UserURLQuery) parse() (listing, error) {
+	l := listing{
+		field:  SortCreated,
+		limit:  pageLimit(q.Limit),
+		search: strings.ToLower(strings.TrimSpace(q.Search)),
+		domain: strings.Trim(strings.ToLower(strings.TrimSpace(q.Domain)), "."),
+	}
+
+	if q.Sort != "" {
+		l.desc = strings.HasPrefix(q.Sort, "-")
+		l.field = strings.TrimPrefix(q.Sort, "-")
+		if l.field != SortCreated && l.field != SortClicks {
+			return listing{}, common.ErrInvalidSort
+		}
+	}
+	if l.field != SortCreated || l.desc {
+		l.sort = q.Sort
+	}
+
+	c, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return listing{}, err
+	}
+	if q.Cursor != "" && c.Sort != l.sort {
+		return listing{}, common.ErrInvalidCursor
+	}
+	l.cursor = c
+
+	return l, nil
+}
+
+// match original url passes search and domain filters
+func (l listing) match(originalURL string) bool {
+	if l.search != "" && !strings.Contains(strings.ToLower(originalURL), l.search) {
+		return false
+	}
+	if l.domain == "" {
+		return true
+	}
+
+	u, err := url.Parse(originalURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	return host == l.domain || strings.HasSuffix(host, "."+l.domain)
+}
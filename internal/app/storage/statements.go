@@ -1,9 +1,12 @@
 package storage
 
+// pgUniqueViolation postgres unique_violation error code
+const pgUniqueViolation = "23505"
+
 const insertStmt = `WITH e AS (
-			INSERT INTO short_url (original_url, short_url, user_id)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (original_url) DO NOTHING
+			INSERT INTO short_url (original_url, canonical_url, short_url, user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, canonical_url) WHERE deleted IS NOT TRUE DO NOTHING
 		RETURNING short_url
 		)
 		SELECT short_url, 100000
@@ -11,17 +14,83 @@ const insertStmt = `WITH e AS (
 		UNION ALL
 		SELECT short_url, 100001
 		FROM short_url
-		WHERE original_url=$1`
+		WHERE user_id=$4 AND canonical_url=$2 AND deleted IS NOT TRUE AND (expires_at IS NULL OR expires_at > now())`
+
+const getOriginalURLStmt = `select original_url, deleted, expires_at, disabled_at, moderation_reason, moderation_legal from short_url where short_url=$1`
+const getUserURL = `select original_url, short_url from short_url
+		where user_id=$1 and deleted is not true and (expires_at is null or expires_at > now()) order by created_at, short_url`
+
+// userURLsFilter conditions of user urls listing: $1 user, $2 current time, $3 lowercase search substring
+// and $4 domain. Domain matches url host and its subdomains
+const userURLsFilter = `user_id = $1 AND deleted IS NOT TRUE AND (expires_at IS NULL OR expires_at > $2)
+		AND ($3 = '' OR strpos(lower(original_url), $3) > 0)
+		AND ($4 = '' OR right('.' || lower(substring(original_url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')), length($4) + 1) = '.' || $4)`
+const countUserURLsStmt = `SELECT count(*) FROM short_url WHERE ` + userURLsFilter
+
+// user urls listing pages are read past the last row of previous page, $5 is true for the first page,
+// $6 and $7 are sort key and short url of the last row, $8 is page size
+const listUserURLsStmt = `SELECT original_url, short_url, created_at, click_count FROM short_url
+		WHERE ` + userURLsFilter + `
+		AND ($5 OR (created_at, short_url) > ($6, $7))
+		ORDER BY created_at, short_url
+		LIMIT $8`
+const listUserURLsDescStmt = `SELECT original_url, short_url, created_at, click_count FROM short_url
+		WHERE ` + userURLsFilter + `
+		AND ($5 OR (created_at, short_url) < ($6, $7))
+		ORDER BY created_at DESC, short_url DESC
+		LIMIT $8`
+const listUserURLsByClicksStmt = `SELECT original_url, short_url, created_at, click_count FROM short_url
+		WHERE ` + userURLsFilter + `
+		AND ($5 OR (click_count, short_url) > ($6, $7))
+		ORDER BY click_count, short_url
+		LIMIT $8`
+const listUserURLsByClicksDescStmt = `SELECT original_url, short_url, created_at, click_count FROM short_url
+		WHERE ` + userURLsFilter + `
+		AND ($5 OR (click_count, short_url) < ($6, $7))
+		ORDER BY click_count DESC, short_url DESC
+		LIMIT $8`
 
-const getOriginalURLStmt = `select original_url, deleted from short_url where short_url=$1`
-const getUserURL = `select original_url, short_url from short_url where user_id=$1`
-const batchInsert = `INSERT INTO short_url(id, short_url, original_url, user_id) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET id = excluded.id RETURNING id;`
-const initStmt = `CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
-			CREATE TABLE IF NOT EXISTS short_url
-		(
-			id           UUID PRIMARY KEY         DEFAULT uuid_generate_v4(),
-			deleted      bool         DEFAULT    false,
-			original_url varchar(150) UNIQUE     NOT NULL,
-			short_url    varchar(50)             NOT NULL,
-			user_id      varchar(50)
-		)`
+// batchInsert save batch item. Correlation id of the item is only echoed in response, row id is generated
+const batchInsert = `INSERT INTO short_url(short_url, original_url, canonical_url, user_id, expires_at)
+		SELECT $1::text, $2::text, $3::text, $4::text, $5::timestamptz
+		WHERE NOT EXISTS (SELECT 1 FROM short_url WHERE short_url = $1)
+		ON CONFLICT (user_id, canonical_url) WHERE deleted IS NOT TRUE DO NOTHING RETURNING short_url;`
+const getShortURLStmt = `select short_url from short_url
+		where user_id=$1 and canonical_url=$2 and deleted is not true and (expires_at is null or expires_at > now())`
+const purgeDuplicateStmt = `UPDATE short_url SET deleted = true
+		WHERE user_id = $1 AND canonical_url = $2 AND deleted IS NOT TRUE AND expires_at <= now()`
+const purgeDuplicateOfStmt = `UPDATE short_url SET deleted = true
+		WHERE user_id = $2 AND canonical_url = (SELECT canonical_url FROM short_url WHERE short_url = $1)
+		AND deleted IS NOT TRUE AND expires_at <= now()`
+const nextSequenceStmt = `select nextval('short_url_seq')`
+const deleteItemsStmt = `UPDATE short_url SET deleted = true WHERE short_url = ANY($1) AND user_id = $2 RETURNING short_url`
+const deleteBatchStmt = `UPDATE short_url SET deleted = true
+		FROM unnest($1::text[], $2::text[]) AS d (short_url, user_id)
+		WHERE short_url.short_url = d.short_url AND short_url.user_id = d.user_id
+		RETURNING short_url.short_url, short_url.user_id`
+const purgeExpiredStmt = `UPDATE short_url SET deleted = true WHERE expires_at <= $1 AND NOT deleted`
+const insertClickStmt = `INSERT INTO clicks (short_url, clicked_at, referrer, user_agent, ip) VALUES ($1, $2, $3, $4, $5)`
+const countClicksStmt = `UPDATE short_url SET click_count = click_count + c.count
+		FROM unnest($1::text[], $2::bigint[]) AS c (short_url, count)
+		WHERE short_url.short_url = c.short_url`
+const getURLOwnerStmt = `select user_id from short_url where short_url=$1`
+const getClicksStmt = `select clicked_at, referrer, user_agent, ip from clicks where short_url=$1 order by clicked_at`
+const insertAccountStmt = `INSERT INTO accounts (email, password_hash, user_id, created_at) VALUES ($1, $2, $3, $4)`
+const getAccountStmt = `select email, password_hash, user_id, created_at from accounts where email=$1`
+const getUserAccountStmt = `select email, password_hash, user_id, created_at from accounts where user_id=$1`
+const mergeUserStmt = `UPDATE short_url SET user_id = $2 WHERE user_id = $1 AND (deleted IS TRUE OR NOT EXISTS (
+		SELECT 1 FROM short_url t WHERE t.user_id = $2 AND t.canonical_url = short_url.canonical_url AND t.deleted IS NOT TRUE))`
+const insertAPIKeyStmt = `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+const getAPIKeyStmt = `select id, user_id, name, prefix, key_hash, scopes, created_at from api_keys where key_hash=$1`
+const listAPIKeysStmt = `select id, user_id, name, prefix, key_hash, scopes, created_at from api_keys where user_id=$1 order by created_at, id`
+const revokeAPIKeyStmt = `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`
+const disableURLStmt = `UPDATE short_url SET disabled_at = $2, moderation_reason = $3, moderation_legal = $4 WHERE short_url = $1`
+const enableURLStmt = `UPDATE short_url SET disabled_at = NULL, moderation_reason = '', moderation_legal = false WHERE short_url = $1 AND disabled_at IS NOT NULL`
+const getOwnedURLStmt = `SELECT original_url, deleted FROM short_url WHERE short_url = $1 AND user_id = $2 FOR UPDATE`
+const updateURLStmt = `UPDATE short_url SET original_url = $2, canonical_url = $3 WHERE short_url = $1`
+const insertURLChangeStmt = `INSERT INTO url_history (short_url, previous_url, original_url, user_id, changed_at) VALUES ($1, $2, $3, $4, $5)`
+const getURLHistoryStmt = `select short_url, previous_url, original_url, user_id, changed_at from url_history where short_url=$1 order by changed_at, id`
+const restoreURLStmt = `UPDATE short_url SET deleted = false WHERE short_url = $1 AND user_id = $2`
+const transferURLStmt = `UPDATE short_url SET user_id = $3 WHERE short_url = $1 AND user_id = $2`
+const urlCountStmt = `SELECT count(*) FROM short_url`
+const userCountStmt = `SELECT count(DISTINCT user_id) FROM short_url`
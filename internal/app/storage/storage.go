@@ -2,29 +2,50 @@ package storage
 
 import (
 	"context"
-	"crypto/md5"
-	"database/sql"
-	"encoding/json"
-	"fmt"
 	"github.com/fd239/go_url_shortener/config"
 	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"log"
-	"os"
-	"path/filepath"
-	"strings"
+	"sort"
+	"time"
 )
 
-//PostgresSQLSuccessful insert statement have no duplicate by original url
+// PostgresSQLSuccessful insert statement have no duplicate by original url
 const PostgresSQLSuccessful = 100000
 
-//PostgresSQLDuplicate insert statement have duplicate by original url
+// PostgresSQLDuplicate insert statement have duplicate by original url
 const PostgresSQLDuplicate = 100001
 
+// Expiration requested link lifetime, either TTL in seconds or absolute expiry time
+type Expiration struct {
+	TTL       int64      `json:"ttl,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Deadline link expiry time counted from now. Zero time means the link never expires
+func (e Expiration) Deadline(now time.Time) (time.Time, error) {
+	switch {
+	case e.TTL < 0, e.TTL > 0 && e.ExpiresAt != nil:
+		return time.Time{}, common.ErrInvalidExpiry
+	case e.TTL > 0:
+		return now.Add(time.Duration(e.TTL) * time.Second), nil
+	case e.ExpiresAt != nil:
+		if !e.ExpiresAt.After(now) {
+			return time.Time{}, common.ErrInvalidExpiry
+		}
+		return *e.ExpiresAt, nil
+	}
+
+	return time.Time{}, nil
+}
+
 type BatchItemRequest struct {
 	CorrelationID string `json:"correlation_id" db:"id"`
 	ShortURL      string `json:"short_url" db:"short_url"`
 	OriginalURL   string `json:"original_url" db:"original_url"`
+	Alias         string `json:"alias,omitempty"`
+	Expiration
 }
 
 type BatchItemResponse struct {
@@ -37,404 +58,313 @@ type UserItem struct {
 	OriginalURL string `json:"original_url" db:"original_url"`
 }
 
+// InsertOptions optional settings of saved short url
+type InsertOptions struct {
+	// Alias custom short url used instead of generated one
+	Alias string
+	// ExpiresAt time after which the link is gone, zero means never
+	ExpiresAt time.Time
+}
+
 type Item struct {
 	ShortURL    string
 	OriginalURL string
 	Deleted     bool
 	User        string
+	ExpiresAt   time.Time
+	// Seq order in which in-memory items were added
+	Seq uint64
+	// Clicks number of recorded redirects
+	Clicks int64
 }
 
-//Database repo struct with all possible options: im-mem, file and pg
-type Database struct {
-	Items       map[string]string
-	UserItems   map[string][]*UserItem //map[userID][]UserItem
-	ArrayItems  []*Item
-	PGConn      *sql.DB
-	Filename    string
-	StoreInFile bool
-	StoreInPg   bool
-	StoreInArr  bool
-	Producer    *producer
-	Consumer    *consumer
+// Expired check item has expiry time and it has passed
+func (i Item) Expired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && !now.Before(i.ExpiresAt)
 }
 
-type consumer struct {
-	file    *os.File
-	decoder *json.Decoder
+// DeleteResult outcome of batch deletion. Not owned are short urls which are
+// unknown or belong to another user
+type DeleteResult struct {
+	Deleted  []string `json:"deleted"`
+	NotOwned []string `json:"not_owned"`
 }
 
-//NewConsumer creating consumer by filename
-func NewConsumer(fileName string) (*consumer, error) {
-	err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm)
+// DeleteRequest user request to delete short urls
+type DeleteRequest struct {
+	UserID string   `json:"user_id"`
+	IDs    []string `json:"ids"`
+}
 
-	if err != nil {
-		return nil, err
+// newDeleteResult split requested short urls to deleted and not owned ones
+func newDeleteResult(itemsIDs []string, deleted map[string]bool) DeleteResult {
+	result := DeleteResult{
+		Deleted:  make([]string, 0, len(deleted)),
+		NotOwned: make([]string, 0, len(itemsIDs)-len(deleted)),
 	}
-
-	file, err := os.OpenFile(fileName, os.O_RDONLY|os.O_CREATE, 0777)
-
-	if err != nil {
-		return nil, err
+	for _, id := range itemsIDs {
+		if deleted[id] {
+			result.Deleted = append(result.Deleted, id)
+		} else {
+			result.NotOwned = append(result.NotOwned, id)
+		}
 	}
 
-	return &consumer{
-		file:    file,
-		decoder: json.NewDecoder(file),
-	}, err
+	return result
 }
 
-func (c *consumer) Close() error {
-	return c.file.Close()
+// URLChange destination change of short url
+type URLChange struct {
+	ShortURL    string    `json:"short_url"`
+	PreviousURL string    `json:"previous_url"`
+	OriginalURL string    `json:"original_url"`
+	UserID      string    `json:"user_id"`
+	ChangedAt   time.Time `json:"changed_at"`
 }
 
-type producer struct {
-	file    *os.File
-	encoder *json.Encoder
+// Moderation reason short url was disabled by moderator. Legal takedowns are reported as unavailable for legal reasons
+type Moderation struct {
+	Reason     string    `json:"reason"`
+	Legal      bool      `json:"legal"`
+	DisabledAt time.Time `json:"disabled_at"`
 }
 
-//NewConsumer creating producer by filename
-func NewProducer(fileName string) (*producer, error) {
-	err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm)
+// ModerationError returned by Get for disabled short url, it unwraps to ErrURLLegal or ErrURLDisabled
+type ModerationError struct {
+	Moderation Moderation
+}
 
-	if err != nil {
-		return nil, err
+func (e *ModerationError) Error() string {
+	if e.Moderation.Reason == "" {
+		return e.Unwrap().Error()
 	}
 
-	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0777)
+	return e.Unwrap().Error() + ": " + e.Moderation.Reason
+}
 
-	if err != nil {
-		return nil, err
+func (e *ModerationError) Unwrap() error {
+	if e.Moderation.Legal {
+		return common.ErrURLLegal
 	}
 
-	return &producer{
-		file:    file,
-		encoder: json.NewEncoder(file),
-	}, err
+	return common.ErrURLDisabled
 }
 
-// SaveItems save from records from memory to file
-func (db *Database) SaveItems() error {
-	return db.Producer.encoder.Encode(db.Items)
+// Account registered user credentials. User ID of account is the ID its owner had as anonymous user
+type Account struct {
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"password_hash"`
+	UserID       string    `json:"user_id"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
-// Close save file
-func (p *producer) Close() error {
-	return p.file.Close()
+// APIKey credential of programmatic client acting as user. Only hash of the key is stored
+type APIKey struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	Hash      string    `json:"hash"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
-// getShortItem func for short url make
-func (db *Database) getShortItem(item string) string {
-	data := []byte(item)
-	return fmt.Sprintf("%x", md5.Sum(data))
+// Click single redirect by short url
+type Click struct {
+	ShortURL  string    `json:"short_url"`
+	Time      time.Time `json:"time"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"` // anonymized client IP
 }
 
-// Insert save short url and user ID to storage
-func (db *Database) Insert(item string, userID string) (string, error) {
-	hashString := db.getShortItem(item)
-
-	db.Items[hashString] = item
-
-	db.UserItems[userID] = append(db.UserItems[userID], &UserItem{
-		ShortURL:    hashString,
-		OriginalURL: item,
-	})
+// StatsPoint clicks count of a time series bucket
+type StatsPoint struct {
+	Time   time.Time `json:"time"`
+	Clicks int       `json:"clicks"`
+}
 
-	if db.StoreInFile {
-		err := db.SaveItems()
-		if err != nil {
-			log.Println("DB Save items error: ", err.Error())
-			return "", err
-		}
-	}
+// LinkStats short url click statistics
+type LinkStats struct {
+	ShortURL       string       `json:"short_url"`
+	TotalClicks    int          `json:"total_clicks"`
+	UniqueVisitors int          `json:"unique_visitors"`
+	Hourly         []StatsPoint `json:"hourly"`
+	Daily          []StatsPoint `json:"daily"`
+}
 
-	if db.StoreInArr {
-		db.ArrayItems = append(db.ArrayItems, &Item{
-			ShortURL:    hashString,
-			OriginalURL: item,
-			Deleted:     false,
-			User:        userID,
-		})
+// NewLinkStats aggregate clicks of short url. Visitors are told apart by anonymized IP and user agent
+func NewLinkStats(id string, clicks []Click) *LinkStats {
+	stats := &LinkStats{
+		ShortURL:    id,
+		TotalClicks: len(clicks),
+		Hourly:      make([]StatsPoint, 0),
+		Daily:       make([]StatsPoint, 0),
 	}
 
-	if db.StoreInPg {
-		rows, err := db.PGConn.Query(insertStmt, item, hashString, userID)
-		if err != nil {
-			log.Println("PG Save items error: ", err.Error())
-			return "", err
-		}
-
-		defer func(rows *sql.Rows) {
-			err = rows.Close()
-			if err != nil {
-				log.Println("rows close error: ", err)
-			}
-		}(rows)
-
-		if rows.Next() {
-			shortURL := ""
-			insertResult := 0
-			err = rows.Scan(&shortURL, &insertResult)
-			if err != nil {
-				log.Println("rows scan error: ", err)
-			}
-			if insertResult == PostgresSQLDuplicate {
-				return shortURL, common.ErrOriginalURLConflict
-			}
-		}
+	visitors := make(map[string]bool)
+	hourly := make(map[time.Time]int)
+	daily := make(map[time.Time]int)
+	for _, click := range clicks {
+		visitors[click.IP+"|"+click.UserAgent] = true
 
-		err = rows.Err()
-		if err != nil {
-			log.Println("PG Insert rows err error: ", err.Error())
-			return "", err
-		}
+		t := click.Time.UTC()
+		hourly[t.Truncate(time.Hour)]++
+		daily[time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)]++
 	}
+	stats.UniqueVisitors = len(visitors)
+	stats.Hourly = appendPoints(stats.Hourly, hourly)
+	stats.Daily = appendPoints(stats.Daily, daily)
 
-	return hashString, nil
+	return stats
 }
 
-// Get URL by id from storage
-func (db *Database) Get(id string) (string, error) {
-	if db.StoreInPg {
-		var url string
-		var deleted bool
-		err := db.PGConn.QueryRow(getOriginalURLStmt, id).Scan(&url, &deleted)
-		if err != nil {
-			log.Println("PG Get short url query error: ", err.Error())
-			return "", err
-		}
-
-		if deleted {
-			return "", common.ErrURLDeleted
-		}
-
-		return url, nil
-	}
-
-	if db.StoreInArr {
-		for _, item := range db.ArrayItems {
-			if item.ShortURL == id {
-				if item.Deleted {
-					return "", common.ErrURLDeleted
-				}
-				return item.OriginalURL, nil
-			}
-		}
-		return "", common.ErrUnableToFindURL
+// appendPoints add time series buckets to points in chronological order
+func appendPoints(points []StatsPoint, buckets map[time.Time]int) []StatsPoint {
+	for t, clicks := range buckets {
+		points = append(points, StatsPoint{Time: t, Clicks: clicks})
 	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
 
-	if result, ok := db.Items[id]; ok {
-		return result, nil
-	}
+	return points
+}
 
-	return "", common.ErrUnableToFindURL
+// Repository short url storage. Implemented by in-memory, file and postgres backends
+type Repository interface {
+	// Insert save short url and user ID to storage. Returns ErrInvalidURL if url is not absolute http(s) url,
+	// ErrAliasConflict if requested alias is taken. Urls are deduplicated by canonical form: url the user already has
+	// is returned with ErrOriginalURLConflict and its existing short url, requested alias is not created then
+	Insert(item string, userID string, opts InsertOptions) (string, error)
+	// Get URL by id from storage. Disabled url is reported with ModerationError
+	Get(id string) (string, error)
+	// GetUserURL receive all live user urls by userID, deleted and expired urls are skipped
+	GetUserURL(userID string) ([]*UserItem, error)
+	// ListUserURLs page of user urls matching query, in order they were created unless query sorts them
+	ListUserURLs(userID string, query UserURLQuery) (UserURLPage, error)
+	// CreateItems batch insert items to storage, items may request aliases. Url the user already has is returned
+	// with existing short url, item requesting another alias for it fails the batch with ErrOriginalURLConflict.
+	// Batch is saved as a whole, nothing is saved if any item fails
+	CreateItems(items []BatchItemRequest, userID string) ([]BatchItemResponse, error)
+	// UpdateItems batch mark user items as deleted. Items of other users are left intact
+	UpdateItems(itemsIDs []string, userID string) (DeleteResult, error)
+	// DeleteBatch mark items of several users as deleted at once. Results are in order of requests
+	DeleteBatch(requests []DeleteRequest) ([]DeleteResult, error)
+	// PurgeExpired mark items expired by now as deleted. Returns number of purged items
+	PurgeExpired(now time.Time) (int, error)
+	// SaveClicks store recorded redirects
+	SaveClicks(clicks []Click) error
+	// GetStats click statistics of short url owned by user
+	GetStats(id string, userID string) (*LinkStats, error)
+	// CreateAccount register account. Returns ErrAccountExists if email or user ID is taken
+	CreateAccount(account Account) error
+	// GetAccount account by email. Returns ErrAccountNotFound if there is none
+	GetAccount(email string) (Account, error)
+	// GetUserAccount account of user. Returns ErrAccountNotFound for anonymous user
+	GetUserAccount(userID string) (Account, error)
+	// MergeUser move urls of one user to another. Live urls the other user already has in the same canonical form
+	// stay with the first user. Returns number of moved urls
+	MergeUser(fromUserID string, toUserID string) (int, error)
+	// UpdateURL change destination of short url owned by change user and keep change in history. Previous url
+	// of change is filled by storage. Returns ErrUnableToFindURL if user has no such url, ErrURLDeleted if it is deleted
+	UpdateURL(change URLChange) (URLChange, error)
+	// RestoreURL undo deletion of short url owned by user. Returns ErrUnableToFindURL if user has no such url
+	RestoreURL(id string, userID string) error
+	// TransferURL hand short url over to other user. Returns ErrUnableToFindURL if user has no such url
+	TransferURL(id string, fromUserID string, toUserID string) error
+	// URLHistory destination changes of short url owned by user, oldest first
+	URLHistory(id string, userID string) ([]URLChange, error)
+	// CreateAPIKey save api key of user
+	CreateAPIKey(key APIKey) error
+	// GetAPIKey api key by hash. Returns ErrAPIKeyNotFound if there is none
+	GetAPIKey(hash string) (APIKey, error)
+	// ListAPIKeys api keys of user, in order they were created
+	ListAPIKeys(userID string) ([]APIKey, error)
+	// RevokeAPIKey delete api key of user. Returns ErrAPIKeyNotFound if user has no such key
+	RevokeAPIKey(id string, userID string) error
+	// DisableURL disable short url by moderator. Returns ErrUnableToFindURL if there is no such url
+	DisableURL(id string, moderation Moderation) error
+	// EnableURL lift moderation of short url. Returns ErrUnableToFindURL if url is not disabled
+	EnableURL(id string) error
+	// Ping storage health check
+	Ping() error
+	// URLCount get saved url in storage
+	URLCount() int
+	// UserCount get uniq user count in storage
+	UserCount() int
+	// Close release storage resources
+	Close() error
 }
 
-// GetUserURL receive all user urls by userID
-func (db *Database) GetUserURL(userID string) ([]*UserItem, error) {
-	if db.StoreInPg {
-		userURLs := make([]*UserItem, 0)
-		rows, err := db.PGConn.Query(getUserURL, userID)
+// newGenerator create short ID generator configured by mode and length from config
+func newGenerator(seq shortid.Sequence) (shortid.Generator, error) {
+	return shortid.New(config.Cfg.ShortIDMode, config.Cfg.ShortIDLength, seq)
+}
 
+// InitDB create repo by config: postgres if DSN is set, file if storage path is set, in-memory otherwise
+func InitDB() (Repository, error) {
+	if len(config.Cfg.DatabaseDSN) > 0 {
+		pgStorage, err := NewPostgresStorage(config.Cfg.DatabaseDSN, config.Cfg.DatabaseAutoMigrate)
 		if err != nil {
-			log.Println("PG Get user urls query error: ", err.Error())
 			return nil, err
 		}
 
-		defer func(rows *sql.Rows) {
-			err = rows.Close()
-			if err != nil {
-				log.Println("rows close error: ", err)
-			}
-		}(rows)
-
-		for rows.Next() {
-			userItem := &UserItem{}
-			err = rows.Scan(&userItem.OriginalURL, &userItem.ShortURL)
-			if err != nil {
-				log.Println("PG Get user urls row scan error: ", err.Error())
-				return nil, err
-			}
-			userURLs = append(userURLs, userItem)
-		}
-
-		err = rows.Err()
+		pgStorage.gen, err = newGenerator(&pgSequence{conn: pgStorage.conn})
 		if err != nil {
-			log.Println("PG Get user urls rows err error: ", err.Error())
+			pgStorage.Close()
 			return nil, err
 		}
-
-		return userURLs, nil
+		return pgStorage, nil
 	}
 
-	if db.StoreInArr {
-		userURLs := make([]*UserItem, 0)
-		for _, item := range db.ArrayItems {
-			if item.User == userID {
-				if !item.Deleted {
-					userURLs = append(userURLs, &UserItem{
-						ShortURL:    item.ShortURL,
-						OriginalURL: item.OriginalURL,
-					})
-				}
-				return userURLs, nil
-			}
-		}
-		return nil, nil
-	}
-
-	return db.UserItems[userID], nil
-}
-
-// RestoreItems restore items from file to in-mem storage
-func (db *Database) RestoreItems() error {
-	err := db.Consumer.decoder.Decode(&db.Items)
-	return err
-}
-
-// Ping postgres health check
-func (db *Database) Ping() error {
-	return db.PGConn.Ping()
-}
-
-// CreateItems batch insert items to postgres
-func (db *Database) CreateItems(items []BatchItemRequest, userID string) ([]BatchItemResponse, error) {
-	ctx := context.Background()
-	tx, err := db.PGConn.Begin()
-	if err != nil {
-		log.Println("PG Context begin error: ", err.Error())
-		return nil, err
-	}
-
-	defer func(tx *sql.Tx) {
-		err = tx.Rollback()
+	if len(config.Cfg.FileStoragePath) > 0 {
+		fileStorage, err := NewFileStorage(config.Cfg.FileStoragePath, FileStorageOptions{
+			SyncPolicy:      SyncPolicy(config.Cfg.FileSyncPolicy),
+			SyncInterval:    config.Cfg.FileSyncInterval,
+			CompactInterval: config.Cfg.FileCompactInterval,
+		})
 		if err != nil {
-			log.Println("transaction rollback error: ", err)
+			log.Println("File storage creation error: ", err.Error())
+			return nil, err
 		}
-	}(tx)
 
-	stmt, err := tx.PrepareContext(ctx, batchInsert)
-
-	if err != nil {
-		log.Println("PG prepare context error: ", err.Error())
-		tx.Rollback()
-		return nil, err
-	}
-
-	defer func(stmt *sql.Stmt) {
-		err = stmt.Close()
+		fileStorage.gen, err = newGenerator(fileStorage.seq)
 		if err != nil {
-			log.Println("statement close error: ", err)
-		}
-	}(stmt)
-
-	var batchItemsResponse []BatchItemResponse
-	for _, item := range items {
-		batchItemResponse := BatchItemResponse{}
-		shortURL := db.getShortItem(item.OriginalURL)
-
-		if _, err = stmt.ExecContext(ctx, item.CorrelationID, shortURL, item.OriginalURL, userID); err != nil {
-			log.Println("PG exec context error: ", err.Error())
+			fileStorage.Close()
 			return nil, err
 		}
-
-		batchItemResponse.CorrelationID = item.CorrelationID
-		batchItemResponse.ShortURL = fmt.Sprintf("%s/%s", config.Cfg.BaseURL, shortURL)
-
-		batchItemsResponse = append(batchItemsResponse, batchItemResponse)
-	}
-
-	if err = tx.Commit(); err != nil {
-		log.Println("PG tx commit error: ", err.Error())
-		return nil, err
-	}
-
-	return batchItemsResponse, nil
-}
-
-// UpdateItems batch update items in postgres
-func (db *Database) UpdateItems(itemsIDs []string) error {
-	formattedItems := make([]string, 0, len(itemsIDs))
-
-	for _, item := range itemsIDs {
-		formattedItem := fmt.Sprintf("('%s')", item)
-		formattedItems = append(formattedItems, formattedItem)
+		return fileStorage, nil
 	}
 
-	stmt := "UPDATE short_url SET deleted = true FROM ( VALUES " + strings.Join(formattedItems, ",") + ") AS update_values (shortURL) WHERE short_url.short_url = update_values.shortURL;"
-	_, err := db.PGConn.Exec(stmt)
-
+	memoryStorage := NewMemoryStorage()
+	var err error
+	memoryStorage.gen, err = newGenerator(memoryStorage.seq)
 	if err != nil {
-		log.Printf("Items update error: %v\n", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return memoryStorage, nil
 }
 
-//InitDB create DB repo and initialize it by config
-func InitDB() (*Database, error) {
-	storeInPg := len(config.Cfg.DatabaseDSN) > 0
-	storeInFile := len(config.Cfg.FileStoragePath) > 0
-
-	DB := Database{
-		StoreInFile: storeInFile,
-		StoreInPg:   storeInPg,
-		Items:       make(map[string]string),
-		ArrayItems:  make([]*Item, 0),
-		UserItems:   make(map[string][]*UserItem),
-		Filename:    config.Cfg.FileStoragePath,
-	}
-
-	if storeInFile {
-		dataBaseProducer, err := NewProducer(config.Cfg.FileStoragePath)
-
-		if err != nil {
-			log.Println("Error producer creation: ", err.Error())
-		}
-
-		dataBaseConsumer, err := NewConsumer(config.Cfg.FileStoragePath)
-
-		if err != nil {
-			log.Println("Error consumer creation: ", err.Error())
-		}
-
-		DB.Producer = dataBaseProducer
-		DB.Consumer = dataBaseConsumer
-
-		err = DB.RestoreItems()
-		if err != nil {
-			log.Println("Error db file decode: ", err.Error())
-		}
-
-	}
-
-	if storeInPg {
-		conn, err := sql.Open("pgx", config.Cfg.DatabaseDSN)
-		if err != nil {
-			log.Printf("Unable to connect to database: %v\n", err)
-		}
+// RunSweeper purge expired items every interval until context is done
+func RunSweeper(ctx context.Context, repo Repository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		DB.PGConn = conn
-		_, err = DB.PGConn.Exec(initStmt)
-
-		if err != nil {
-			log.Println("short url table creation error: ", err.Error())
-			return nil, err
+	for {
+		select {
+		case <-ticker.C:
+			purged, err := repo.PurgeExpired(time.Now())
+			if err != nil {
+				log.Printf("Expired urls purge error: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Expired urls purged: %d", purged)
+			}
+		case <-ctx.Done():
+			return
 		}
-
 	}
-
-	return &DB, nil
-}
-
-// URLCount get saved url in storage
-func (db *Database) URLCount() (counter int) {
-	db.PGConn.QueryRow("SELECT count(*) FROM short_url").Scan(&counter)
-	return
-}
-
-// UserCount get uniq url count in storage
-func (db *Database) UserCount() (counter int) {
-	db.PGConn.QueryRow("SELECT count(DISTINCT user_id) FROM short_url").Scan(&counter)
-	return
 }
@@ -1,750 +1,208 @@
 package storage
 
 import (
-	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/fd239/go_url_shortener/config"
 	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/fd239/go_url_shortener/internal/app/shortid"
+	"github.com/jackc/pgconn"
 	"github.com/stretchr/testify/assert"
-	"log"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 )
 
 var testUserID = "testUser"
 var testItemID = "123"
-var testFilePath = "./TEST_DB_1.txt"
+var testFilePath = "TEST_DB_1.txt"
 var errTest = errors.New("test error")
 
-func getProducer() *producer {
-	prod, err := NewProducer(common.TestDBName)
-	if err != nil {
-		log.Println("Consumer creation error: ")
-	}
-	return prod
-}
-
-func getConsumer() *consumer {
-	cons, err := NewConsumer(common.TestDBName)
-	if err != nil {
-		log.Println("Consumer creation error: ", err.Error())
-	}
-	return cons
-}
-
-func setupTestDatabase(t *testing.T) (*Database, sqlmock.Sqlmock) {
-	conn, mock, err := sqlmock.New()
-
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-
-	var db = &Database{
-		Items:       map[string]string{},
-		UserItems:   map[string][]*UserItem{},
-		PGConn:      conn,
-		Filename:    "",
-		StoreInFile: false,
-		StoreInPg:   true,
-		Producer:    nil,
-		Consumer:    nil,
-	} // now we execute our method
-
-	return db, mock
-}
-
-func TestDatabase_SaveShortRoute(t *testing.T) {
-	type fields struct {
-		Items       map[string]string
-		Filename    string
-		StoreInFile bool
-		StoreInPg   bool
-		StoreInArr  bool
-		ArrayItems  []*Item
-		Producer    *producer
-		Consumer    *consumer
-	}
-	type args struct {
-		url string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    string
-		wantErr assert.ErrorAssertionFunc
-	}{
-		{
-			"OK",
-			fields{
-				Items:       map[string]string{common.TestURL: common.TestShortID},
-				Filename:    common.TestDBName,
-				StoreInFile: true,
-				StoreInPg:   false,
-				Producer:    getProducer(),
-				Consumer:    getConsumer(),
-			},
-			args{common.TestURL},
-			common.TestShortID,
-			assert.NoError,
-		},
-		{
-			"OK Arr",
-			fields{
-				ArrayItems:  []*Item{{common.TestShortID, common.TestURL, false, testUserID}},
-				Filename:    common.TestDBName,
-				StoreInFile: false,
-				StoreInArr:  true,
-				Producer:    nil,
-				Consumer:    nil,
-			},
-			args{common.TestShortID},
-			common.TestURL,
-			assert.NoError,
-		},
-		{
-			"Error Arr deleted",
-			fields{
-				ArrayItems:  []*Item{{common.TestShortID, common.TestURL, true, testUserID}},
-				Filename:    common.TestDBName,
-				StoreInFile: false,
-				StoreInArr:  true,
-				Producer:    nil,
-				Consumer:    nil,
-			},
-			args{common.TestShortID},
-			"",
-			assert.Error,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &Database{
-				Items:       tt.fields.Items,
-				Filename:    tt.fields.Filename,
-				StoreInFile: tt.fields.StoreInFile,
-				Producer:    tt.fields.Producer,
-				Consumer:    tt.fields.Consumer,
-				ArrayItems:  tt.fields.ArrayItems,
-				StoreInArr:  tt.fields.StoreInArr,
-			}
-
-			got, err := db.Get(tt.args.url)
-			if !tt.wantErr(t, err, fmt.Sprintf("SaveShortRoute(%v)", tt.args.url)) {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "SaveShortRoute(%v)", tt.args.url)
-		})
-	}
-}
-
-func TestDatabase_GetShortRoute(t *testing.T) {
-	type fields struct {
-		Items       map[string]string
-		Filename    string
-		StoreInFile bool
-		StoreInPg   bool
-		Producer    *producer
-		Consumer    *consumer
-	}
-	type args struct {
-		routeID string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    string
-		wantErr assert.ErrorAssertionFunc
-	}{
-		{
-			"OK",
-			fields{
-				Items:       map[string]string{common.TestShortID: common.TestURL},
-				Filename:    common.TestDBName,
-				StoreInFile: true,
-				StoreInPg:   false,
-				Producer:    getProducer(),
-				Consumer:    getConsumer(),
-			},
-			args{common.TestShortID},
-			common.TestURL,
-			assert.NoError,
-		},
-		{
-			"Error",
-			fields{
-				Items:       map[string]string{},
-				Filename:    common.TestDBName,
-				StoreInFile: true,
-				StoreInPg:   false,
-				Producer:    getProducer(),
-				Consumer:    getConsumer(),
-			},
-			args{common.TestURL},
-			"",
-			assert.Error,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &Database{
-				Items:       tt.fields.Items,
-				Filename:    tt.fields.Filename,
-				StoreInFile: tt.fields.StoreInFile,
-				Producer:    tt.fields.Producer,
-				Consumer:    tt.fields.Consumer,
-			}
-			got, err := db.Get(tt.args.routeID)
-			if !tt.wantErr(t, err, fmt.Sprintf("GetShortRoute(%v)", tt.args.routeID)) {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "GetShortRoute(%v)", tt.args.routeID)
-		})
-	}
-}
-
-func TestDatabase_Insert(t *testing.T) {
-	type fields struct {
-		Items       map[string]string
-		UserItems   map[string][]*UserItem
-		ArrayItems  []*Item
-		PGConn      *sql.DB
-		Filename    string
-		StoreInFile bool
-		StoreInPg   bool
-		StoreInArr  bool
-		Producer    *producer
-		Consumer    *consumer
-	}
-	type args struct {
-		item   string
-		userID string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    string
-		wantErr assert.ErrorAssertionFunc
-	}{
-		{
-			name: "OK IN memory",
-			fields: fields{
-				Items:       map[string]string{},
-				UserItems:   map[string][]*UserItem{},
-				PGConn:      nil,
-				Filename:    "",
-				StoreInFile: false,
-				StoreInPg:   false,
-				Producer:    nil,
-				Consumer:    nil,
-			},
-			args: args{
-				item:   common.TestURL,
-				userID: "test",
-			},
-			want:    common.TestShortID,
-			wantErr: assert.NoError,
-		},
-		{
-			name: "OK IN file",
-			fields: fields{
-				Items:       map[string]string{},
-				UserItems:   map[string][]*UserItem{},
-				PGConn:      nil,
-				Filename:    "",
-				StoreInFile: true,
-				StoreInPg:   false,
-				Producer:    getProducer(),
-				Consumer:    nil,
-			},
-			args: args{
-				item:   common.TestURL,
-				userID: "test",
-			},
-			want:    common.TestShortID,
-			wantErr: assert.NoError,
-		},
-		{
-			name: "OK IN array",
-			fields: fields{
-				Items:       map[string]string{},
-				UserItems:   map[string][]*UserItem{},
-				PGConn:      nil,
-				Filename:    "",
-				StoreInFile: false,
-				StoreInPg:   false,
-				StoreInArr:  true,
-				Producer:    nil,
-				Consumer:    nil,
-			},
-			args: args{
-				item:   common.TestURL,
-				userID: testUserID,
-			},
-			want:    common.TestShortID,
-			wantErr: assert.NoError,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db := &Database{
-				Items:       tt.fields.Items,
-				UserItems:   tt.fields.UserItems,
-				PGConn:      tt.fields.PGConn,
-				Filename:    tt.fields.Filename,
-				StoreInFile: tt.fields.StoreInFile,
-				StoreInPg:   tt.fields.StoreInPg,
-				Producer:    tt.fields.Producer,
-				Consumer:    tt.fields.Consumer,
-				StoreInArr:  tt.fields.StoreInArr,
-			}
-			got, err := db.Insert(tt.args.item, tt.args.userID)
-			if !tt.wantErr(t, err, fmt.Sprintf("Insert(%v, %v)", tt.args.item, tt.args.userID)) {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "Insert(%v, %v)", tt.args.item, tt.args.userID)
-		})
-	}
+// testShortID short url generated for url by default generator
+func testShortID(url string) string {
+	return testShortIDAttempt(url, 0)
 }
 
-func TestInsertURLOK(t *testing.T) {
-	var res string
-
-	conn, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer conn.Close()
-
-	rows := sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLSuccessful)
-	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestShortID, testUserID).WillReturnRows(rows)
-
-	var db = &Database{
-		Items:       map[string]string{},
-		UserItems:   map[string][]*UserItem{},
-		PGConn:      conn,
-		Filename:    "",
-		StoreInFile: false,
-		StoreInPg:   true,
-		Producer:    nil,
-		Consumer:    nil,
-	} // now we execute our method
-
-	if res, err = db.Insert(common.TestURL, testUserID); err != nil {
-		t.Errorf("error was not expected while inserting: %s", err)
-	}
-
-	assert.Equal(t, res, common.TestShortID)
-
-	// we make sure that all expectations were met
-	if err = mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
-	}
+// testShortIDAttempt short url generated for url by default generator after collisions
+func testShortIDAttempt(url string, attempt int) string {
+	id, _ := shortid.NewHash(shortid.DefaultLength).Generate(url, attempt)
+	return id
 }
 
-func TestInsertDuplicateErr(t *testing.T) {
-	conn, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer conn.Close()
-
-	rows := sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLDuplicate)
-	mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestShortID, testUserID).WillReturnRows(rows)
-
-	var db = &Database{
-		Items:       map[string]string{},
-		UserItems:   map[string][]*UserItem{},
-		PGConn:      conn,
-		Filename:    "",
-		StoreInFile: false,
-		StoreInPg:   true,
-		Producer:    nil,
-		Consumer:    nil,
-	} // now we execute our method
-
-	if _, err = db.Insert(common.TestURL, testUserID); err == nil {
-		t.Error("error was expected while inserting")
-	}
-
-	assert.ErrorIs(t, err, common.ErrOriginalURLConflict)
-
-	// we make sure that all expectations were met
-	if err = mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
-	}
+// forEachBackend run the same test against memory, file and postgres storage. Postgres runs on mocked database
+// with expectations set by expect
+func forEachBackend(t *testing.T, expect func(mock sqlmock.Sqlmock), test func(t *testing.T, repo Repository)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStorage())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		fs, err := NewFileStorage(filepath.Join(t.TempDir(), common.TestDBName), FileStorageOptions{})
+		require.NoError(t, err)
+		defer fs.Close()
+		test(t, fs)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		testDB, mock := setupTestDatabase(t)
+		defer testDB.conn.Close()
+		expect(mock)
+		test(t, testDB)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
-func TestGetUrlPostgres(t *testing.T) {
-	type args struct {
-		id string
-	}
-	tests := []struct {
-		name     string
-		args     args
-		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
-		want     string
-		wantErr  assert.ErrorAssertionFunc
-	}{
-		{
-			name: "OK",
-			args: args{id: common.TestShortID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				rows := sqlmock.NewRows([]string{"url", "deleted"}).AddRow(common.TestURL, false)
-				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
-				return mock
-			},
-			want:    common.TestURL,
-			wantErr: assert.NoError,
-		},
-		{
-			name: "Error. Deleted",
-			args: args{id: common.TestShortID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				rows := sqlmock.NewRows([]string{"url", "deleted"}).AddRow(common.TestURL, true)
-				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnRows(rows)
-				return mock
-			},
-			want:    "",
-			wantErr: assert.Error,
-		},
-		{
-			name: "Query error",
-			args: args{id: common.TestShortID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(common.TestShortID).WillReturnError(errors.New("test error"))
-				return mock
-			},
-			want:    "",
-			wantErr: assert.Error,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			testDB, mock := setupTestDatabase(t)
-			defer testDB.PGConn.Close()
-
-			tt.initMock(mock)
-
-			got, err := testDB.Get(tt.args.id)
-
-			if !tt.wantErr(t, err, fmt.Sprintf("Get(%v)", tt.args.id)) {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "Get(%v, %v)", tt.args.id)
-
-			// we make sure that all expectations were met
-			if err = mock.ExpectationsWereMet(); err != nil {
-				t.Errorf("there were unfulfilled expectations: %s", err)
-			}
-
-		})
-	}
-
+func TestAliasForExistingURL(t *testing.T) {
+	expect := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLSuccessful))
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, "spring-sale", testUserID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(common.TestShortID, PostgresSQLDuplicate))
+
+		mock.ExpectBegin()
+		prepare := mock.ExpectPrepare(regexp.QuoteMeta(batchInsert))
+		prepare.ExpectQuery().WithArgs("spring-sale", common.TestURL, common.TestURL, testUserID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+		mock.ExpectQuery(regexp.QuoteMeta(getShortURLStmt)).WithArgs(testUserID, common.TestURL).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+		mock.ExpectRollback()
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectQuery().WithArgs(common.TestShortID, common.TestURL, common.TestURL, testUserID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+		mock.ExpectQuery(regexp.QuoteMeta(getShortURLStmt)).WithArgs(testUserID, common.TestURL).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+		mock.ExpectCommit()
+
+		mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs("spring-sale").WillReturnRows(sqlmock.NewRows([]string{"original_url"}))
+	}
+
+	forEachBackend(t, expect, func(t *testing.T, repo Repository) {
+		first, err := repo.Insert(common.TestURL, testUserID, InsertOptions{})
+		require.NoError(t, err)
+
+		got, err := repo.Insert(common.TestURL, testUserID, InsertOptions{Alias: "spring-sale"})
+		assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "alias is not created for existing url")
+		assert.Equal(t, first, got)
+
+		items := []BatchItemRequest{{CorrelationID: testItemID, OriginalURL: common.TestURL, Alias: "spring-sale"}}
+		_, err = repo.CreateItems(items, testUserID)
+		assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "batch alias is not created for existing url")
+
+		items[0].Alias = first
+		res, err := repo.CreateItems(items, testUserID)
+		assert.NoError(t, err, "existing link is the requested alias")
+		assert.Equal(t, []BatchItemResponse{{CorrelationID: testItemID, ShortURL: config.Cfg.BaseURL + "/" + first}}, res)
+
+		_, err = repo.Get("spring-sale")
+		assert.ErrorIs(t, err, common.ErrUnableToFindURL)
+	})
 }
 
-func TestGetUserUrlPostgres(t *testing.T) {
-	type args struct {
-		userID string
-	}
-	tests := []struct {
-		name     string
-		args     args
-		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
-		want     []*UserItem
-		wantErr  assert.ErrorAssertionFunc
-	}{
-		{
-			name: "OK",
-			args: args{userID: testUserID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				rows := sqlmock.NewRows([]string{"OriginalURL", "ShortURL"}).AddRow(common.TestURL, common.TestShortID)
-				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
-				return mock
-			},
-			want: []*UserItem{{
-				ShortURL:    common.TestShortID,
-				OriginalURL: common.TestURL,
-			}},
-			wantErr: assert.NoError,
-		},
-		{
-			name: "Error. Rows scan error",
-			args: args{userID: testUserID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				rows := sqlmock.NewRows([]string{"test"}).AddRow("test")
-				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-		{
-			name: "Error. Query error",
-			args: args{userID: testUserID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnError(fmt.Errorf("some error"))
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-		{
-			name: "Row error",
-			args: args{userID: testUserID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				rows := sqlmock.NewRows([]string{"OriginalURL", "ShortURL"}).AddRow(common.TestURL, common.TestShortID)
-				rows.RowError(0, errors.New("test error"))
-				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-		{
-			name: "Row error",
-			args: args{userID: testUserID},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				rows := sqlmock.NewRows([]string{"OriginalURL", "ShortURL"}).AddRow(common.TestURL, common.TestShortID)
-				rows.RowError(0, errors.New("test error"))
-				mock.ExpectQuery(regexp.QuoteMeta(getUserURL)).WithArgs(testUserID).WillReturnRows(rows)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
+func TestCreateItemsAllOrNothing(t *testing.T) {
+	firstURL, failedURL, aliasURL := common.TestURL+"/1", common.TestURL+"/2", common.TestURL+"/3"
+	firstID := testShortID(firstURL)
+	items := []BatchItemRequest{
+		{CorrelationID: testItemID, OriginalURL: firstURL},
+		{CorrelationID: testItemID + "2", OriginalURL: failedURL, Alias: "spring-sale"},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			testDB, mock := setupTestDatabase(t)
-			defer testDB.PGConn.Close()
-
-			tt.initMock(mock)
+	expect := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(aliasURL, aliasURL, "spring-sale", "other", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow("spring-sale", PostgresSQLSuccessful))
 
-			got, err := testDB.GetUserURL(tt.args.userID)
+		mock.ExpectBegin()
+		prepare := mock.ExpectPrepare(regexp.QuoteMeta(batchInsert))
+		prepare.ExpectQuery().WithArgs(firstID, firstURL, firstURL, testUserID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(firstID))
+		prepare.ExpectQuery().WithArgs("spring-sale", failedURL, failedURL, testUserID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+		mock.ExpectQuery(regexp.QuoteMeta(getShortURLStmt)).WithArgs(testUserID, failedURL).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+		mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, failedURL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
 
-			if !tt.wantErr(t, err, "GetUserURL()") {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "GetUserURL()")
-
-			// we make sure that all expectations were met
-			if err = mock.ExpectationsWereMet(); err != nil {
-				t.Errorf("there were unfulfilled expectations: %s", err)
-			}
-		})
+		mock.ExpectQuery(regexp.QuoteMeta(getOriginalURLStmt)).WithArgs(firstID).WillReturnRows(sqlmock.NewRows([]string{"original_url"}))
 	}
 
-}
+	forEachBackend(t, expect, func(t *testing.T, repo Repository) {
+		_, err := repo.Insert(aliasURL, "other", InsertOptions{Alias: "spring-sale"})
+		require.NoError(t, err)
 
-func TestCreateItemsPostgres(t *testing.T) {
-	type args struct {
-		items  []BatchItemRequest
-		userID string
-	}
-	tests := []struct {
-		name     string
-		args     args
-		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
-		want     []BatchItemResponse
-		wantErr  assert.ErrorAssertionFunc
-	}{
-		{
-			name: "OK",
-			args: args{userID: testUserID, items: []BatchItemRequest{{
-				CorrelationID: testItemID,
-				ShortURL:      common.TestShortID,
-				OriginalURL:   common.TestURL,
-			}}},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectBegin()
-				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectExec().WithArgs(testItemID, common.TestShortID, common.TestURL, testUserID).WillReturnResult(sqlmock.NewResult(1, 1))
-				mock.ExpectCommit()
-				return mock
-			},
-			want: []BatchItemResponse{{
-				CorrelationID: testItemID,
-				ShortURL:      "/" + common.TestShortID,
-			}},
-			wantErr: assert.NoError,
-		},
-		{
-			name: "Expect begin error",
-			args: args{userID: testUserID, items: []BatchItemRequest{{
-				CorrelationID: testItemID,
-				ShortURL:      common.TestShortID,
-				OriginalURL:   common.TestURL,
-			}}},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectBegin().WillReturnError(errTest)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-		{
-			name: "Expect prepare error",
-			args: args{userID: testUserID, items: []BatchItemRequest{{
-				CorrelationID: testItemID,
-				ShortURL:      common.TestShortID,
-				OriginalURL:   common.TestURL,
-			}}},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectBegin()
-				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).WillReturnError(errTest)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-		{
-			name: "Expect context error",
-			args: args{userID: testUserID, items: []BatchItemRequest{{
-				CorrelationID: testItemID,
-				ShortURL:      common.TestShortID,
-				OriginalURL:   common.TestURL,
-			}}},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectBegin()
-				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectExec().WithArgs(testItemID, common.TestShortID, common.TestURL, testUserID).WillReturnError(errTest)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-		{
-			name: "Commit error",
-			args: args{userID: testUserID, items: []BatchItemRequest{{
-				CorrelationID: testItemID,
-				ShortURL:      common.TestShortID,
-				OriginalURL:   common.TestURL,
-			}}},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectBegin()
-				mock.ExpectPrepare(regexp.QuoteMeta(batchInsert)).ExpectExec().WithArgs(testItemID, common.TestShortID, common.TestURL, testUserID).WillReturnResult(sqlmock.NewResult(1, 1))
-				mock.ExpectCommit().WillReturnError(errTest)
-				return mock
-			},
-			want:    nil,
-			wantErr: assert.Error,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			testDB, mock := setupTestDatabase(t)
-			defer testDB.PGConn.Close()
-
-			tt.initMock(mock)
+		_, err = repo.CreateItems(items, testUserID)
+		assert.ErrorIs(t, err, common.ErrAliasConflict)
 
-			got, err := testDB.CreateItems(tt.args.items, tt.args.userID)
-
-			if !tt.wantErr(t, err, fmt.Sprintf("Create items(%v, %v)", tt.args.userID, tt.args.items)) {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "Create items(%v, %v)", tt.args.userID, tt.args.items)
-
-			// we make sure that all expectations were met
-			if err = mock.ExpectationsWereMet(); err != nil {
-				t.Errorf("there were unfulfilled expectations: %s", err)
-			}
-
-		})
-	}
+		_, err = repo.Get(firstID)
+		assert.ErrorIs(t, err, common.ErrUnableToFindURL, "item saved before failed one is not kept")
+	})
 }
 
-func BenchmarkSaveURL_Arr(b *testing.B) {
-
-	var db = &Database{
-		Items:       map[string]string{},
-		UserItems:   map[string][]*UserItem{},
-		ArrayItems:  []*Item{},
-		PGConn:      nil,
-		Filename:    "",
-		StoreInFile: false,
-		StoreInArr:  true,
-		Producer:    nil,
-		Consumer:    nil,
-	} // now we execute our method
-
-	b.ReportAllocs()
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		db.Insert(common.TestURL, testUserID)
-	}
-}
-
-func BenchmarkSaveURL_Map(b *testing.B) {
-
-	var db = &Database{
-		Items:       map[string]string{},
-		UserItems:   map[string][]*UserItem{},
-		ArrayItems:  []*Item{},
-		PGConn:      nil,
-		Filename:    "",
-		StoreInFile: false,
-		StoreInArr:  false,
-		Producer:    nil,
-		Consumer:    nil,
-	} // now we execute our method
-
-	b.ReportAllocs()
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		db.Insert(common.TestURL, testUserID)
-	}
-}
-
-func TestUpdateItemsPostgres(t *testing.T) {
-	type args struct {
-		itemsIDs []string
-	}
-	tests := []struct {
-		name     string
-		args     args
-		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
-		wantErr  assert.ErrorAssertionFunc
-	}{
-		{
-			name: "OK",
-			args: args{itemsIDs: []string{testItemID}},
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectExec(
-					regexp.QuoteMeta(
-						"UPDATE short_url SET deleted = true FROM ( VALUES " + fmt.Sprintf("('%s')", testItemID) + ") AS update_values (shortURL) WHERE short_url.short_url = update_values.shortURL;")).WillReturnResult(sqlmock.NewResult(1, 1))
-				return mock
-			},
-			wantErr: assert.NoError,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			testDB, mock := setupTestDatabase(t)
-			defer testDB.PGConn.Close()
-
-			tt.initMock(mock)
-
-			err := testDB.UpdateItems(tt.args.itemsIDs)
-
-			if !tt.wantErr(t, err, "UpdateItems()") {
-				return
-			}
-
-			// we make sure that all expectations were met
-			if err = mock.ExpectationsWereMet(); err != nil {
-				t.Errorf("there were unfulfilled expectations: %s", err)
-			}
-		})
-	}
+func TestSingleLiveLinkPerURL(t *testing.T) {
+	otherURL := common.TestURL + "/b"
+	renewedID := testShortIDAttempt(common.TestURL, 1)
+	otherID := testShortID(otherURL)
+	transferredID := testShortIDAttempt(otherURL, 1)
+	changedAt := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	insertResult := func(id string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"shortURL", "insertResult"}).AddRow(id, PostgresSQLSuccessful)
+	}
+	expect := func(mock sqlmock.Sqlmock) {
+		shortURLTaken := &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: shortURLConstraint}
+		canonicalTaken := &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: canonicalURLConstraint}
+
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).
+			WillReturnRows(insertResult(common.TestShortID))
+		mock.ExpectQuery(regexp.QuoteMeta(deleteItemsStmt)).WithArgs(sqlmock.AnyArg(), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow(common.TestShortID))
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, common.TestShortID, testUserID, nil).
+			WillReturnError(shortURLTaken)
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(common.TestURL, common.TestURL, renewedID, testUserID, nil).
+			WillReturnRows(insertResult(renewedID))
+
+		mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateOfStmt)).WithArgs(common.TestShortID, testUserID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(restoreURLStmt)).WithArgs(common.TestShortID, testUserID).WillReturnError(canonicalTaken)
+
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(otherURL, otherURL, otherID, testUserID, nil).
+			WillReturnRows(insertResult(otherID))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(getOwnedURLStmt)).WithArgs(otherID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"original_url", "deleted"}).AddRow(otherURL, false))
+		mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateStmt)).WithArgs(testUserID, common.TestURL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(updateURLStmt)).WithArgs(otherID, common.TestURL, common.TestURL).WillReturnError(canonicalTaken)
+		mock.ExpectRollback()
+
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(otherURL, otherURL, otherID, "other", nil).
+			WillReturnError(shortURLTaken)
+		mock.ExpectQuery(regexp.QuoteMeta(insertStmt)).WithArgs(otherURL, otherURL, transferredID, "other", nil).
+			WillReturnRows(insertResult(transferredID))
+		mock.ExpectExec(regexp.QuoteMeta(purgeDuplicateOfStmt)).WithArgs(transferredID, testUserID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(transferURLStmt)).WithArgs(transferredID, "other", testUserID).WillReturnError(canonicalTaken)
+	}
+
+	forEachBackend(t, expect, func(t *testing.T, repo Repository) {
+		deleted, err := repo.Insert(common.TestURL, testUserID, InsertOptions{})
+		require.NoError(t, err)
+		_, err = repo.UpdateItems([]string{deleted}, testUserID)
+		require.NoError(t, err)
+		renewed, err := repo.Insert(common.TestURL, testUserID, InsertOptions{})
+		require.NoError(t, err)
+		require.Equal(t, renewedID, renewed)
+
+		err = repo.RestoreURL(deleted, testUserID)
+		assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "restore of url with other live link")
+
+		other, err := repo.Insert(otherURL, testUserID, InsertOptions{})
+		require.NoError(t, err)
+		_, err = repo.UpdateURL(URLChange{ShortURL: other, OriginalURL: common.TestURL, UserID: testUserID, ChangedAt: changedAt})
+		assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "update to url with other live link")
+
+		transferred, err := repo.Insert(otherURL, "other", InsertOptions{})
+		require.NoError(t, err)
+		err = repo.TransferURL(transferred, "other", testUserID)
+		assert.ErrorIs(t, err, common.ErrOriginalURLConflict, "transfer of url new owner already has")
+	})
 }
 
 func TestInitDB(t *testing.T) {
@@ -752,10 +210,12 @@ func TestInitDB(t *testing.T) {
 		name    string
 		wantErr assert.ErrorAssertionFunc
 		config  config.Config
+		want    Repository
 	}{
 		{
 			name:    "OK",
 			wantErr: assert.NoError,
+			want:    &MemoryStorage{},
 		},
 		{
 			name: "OK with file",
@@ -763,35 +223,20 @@ func TestInitDB(t *testing.T) {
 				FileStoragePath: testFilePath,
 			},
 			wantErr: assert.NoError,
+			want:    &FileStorage{},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			config.Cfg = tt.config
-			_, err := InitDB()
-			if !tt.wantErr(t, err, "InitDB()") {
-				return
-			}
-		})
-	}
-}
-
-func TestInitDB_Postgres(t *testing.T) {
-	tests := []struct {
-		name     string
-		initMock func(sqlmock.Sqlmock) sqlmock.Sqlmock
-		wantErr  assert.ErrorAssertionFunc
-		config   config.Config
-	}{
 		{
-			name: "DSN error",
-			initMock: func(mock sqlmock.Sqlmock) sqlmock.Sqlmock {
-				mock.ExpectExec(
-					regexp.QuoteMeta(initStmt)).WillReturnResult(sqlmock.NewResult(1, 1))
-				return mock
+			name: "Unknown short ID mode",
+			config: config.Config{
+				ShortIDMode: "md5",
 			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "DSN error",
 			config: config.Config{
-				DatabaseDSN: "sqlmock_db_0",
+				DatabaseDSN:         "sqlmock_db_0",
+				DatabaseAutoMigrate: true,
 			},
 			wantErr: assert.Error,
 		},
@@ -799,116 +244,78 @@ func TestInitDB_Postgres(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			config.Cfg = tt.config
-			testDB, mock := setupTestDatabase(t)
-			defer testDB.PGConn.Close()
-
-			tt.initMock(mock)
-
-			_, err := InitDB()
+			if config.Cfg.FileStoragePath != "" {
+				config.Cfg.FileStoragePath = filepath.Join(t.TempDir(), config.Cfg.FileStoragePath)
+			}
+			got, err := InitDB()
 			if !tt.wantErr(t, err, "InitDB()") {
 				return
 			}
+			if tt.want != nil {
+				assert.IsType(t, tt.want, got)
+				assert.NoError(t, got.Close())
+			}
 		})
 	}
 }
 
-func TestDatabase_GetUserURL_Arr(t *testing.T) {
-	type fields struct {
-		Items       map[string]string
-		UserItems   map[string][]*UserItem
-		ArrayItems  []*Item
-		PGConn      *sql.DB
-		Filename    string
-		StoreInFile bool
-		StoreInPg   bool
-		StoreInArr  bool
-		Producer    *producer
-		Consumer    *consumer
-	}
-	type args struct {
-		userID string
-	}
+func TestExpiration_Deadline(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []*UserItem
-		wantErr assert.ErrorAssertionFunc
+		name       string
+		expiration Expiration
+		want       time.Time
+		wantErr    error
 	}{
-		{
-			name: "OK",
-			fields: fields{
-				Items:     map[string]string{},
-				UserItems: map[string][]*UserItem{},
-				ArrayItems: []*Item{{
-					ShortURL:    common.TestShortID,
-					OriginalURL: common.TestURL,
-					Deleted:     false,
-					User:        testUserID,
-				}},
-				PGConn:      nil,
-				Filename:    "",
-				StoreInFile: false,
-				StoreInArr:  true,
-				Producer:    nil,
-				Consumer:    nil,
-			},
-			args: args{testUserID},
-			want: []*UserItem{{
-				ShortURL:    common.TestShortID,
-				OriginalURL: common.TestURL,
-			}},
-			wantErr: assert.NoError,
-		},
+		{name: "Never", expiration: Expiration{}, want: time.Time{}},
+		{name: "TTL", expiration: Expiration{TTL: 60}, want: now.Add(time.Minute)},
+		{name: "Expires at", expiration: Expiration{ExpiresAt: &future}, want: future},
+		{name: "Negative TTL", expiration: Expiration{TTL: -1}, wantErr: common.ErrInvalidExpiry},
+		{name: "Expires at in past", expiration: Expiration{ExpiresAt: &past}, wantErr: common.ErrInvalidExpiry},
+		{name: "Both set", expiration: Expiration{TTL: 60, ExpiresAt: &future}, wantErr: common.ErrInvalidExpiry},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db := &Database{
-				Items:       tt.fields.Items,
-				UserItems:   tt.fields.UserItems,
-				ArrayItems:  tt.fields.ArrayItems,
-				PGConn:      tt.fields.PGConn,
-				Filename:    tt.fields.Filename,
-				StoreInFile: tt.fields.StoreInFile,
-				StoreInPg:   tt.fields.StoreInPg,
-				StoreInArr:  tt.fields.StoreInArr,
-				Producer:    tt.fields.Producer,
-				Consumer:    tt.fields.Consumer,
-			}
-			got, err := db.GetUserURL(tt.args.userID)
-			if !tt.wantErr(t, err, fmt.Sprintf("GetUserURL(%v)", tt.args.userID)) {
+			got, err := tt.expiration.Deadline(now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 				return
 			}
-			assert.Equalf(t, tt.want, got, "GetUserURL(%v)", tt.args.userID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
 
-func TestDatabase_Ping(t *testing.T) {
-	tests := []struct {
-		name    string
-		wantErr assert.ErrorAssertionFunc
-		config  config.Config
-	}{
-		{
-			name: "OK",
-			config: config.Config{
-				DatabaseDSN: "sqlmock_db_0",
-			},
-			wantErr: assert.NoError,
+func TestNewLinkStats(t *testing.T) {
+	day := time.Date(2022, 7, 1, 10, 15, 0, 0, time.UTC)
+	clicks := []Click{
+		{ShortURL: "id", Time: day.Add(time.Hour), IP: "10.0.0.0", UserAgent: "a"},
+		{ShortURL: "id", Time: day, IP: "10.0.0.0", UserAgent: "a"},
+		{ShortURL: "id", Time: day.Add(10 * time.Minute), IP: "10.0.0.0", UserAgent: "b"},
+		{ShortURL: "id", Time: day.Add(24 * time.Hour), IP: "10.0.1.0", UserAgent: "a"},
+	}
+
+	stats := NewLinkStats("id", clicks)
+	assert.Equal(t, &LinkStats{
+		ShortURL:       "id",
+		TotalClicks:    4,
+		UniqueVisitors: 3,
+		Hourly: []StatsPoint{
+			{Time: time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC), Clicks: 2},
+			{Time: time.Date(2022, 7, 1, 11, 0, 0, 0, time.UTC), Clicks: 1},
+			{Time: time.Date(2022, 7, 2, 10, 0, 0, 0, time.UTC), Clicks: 1},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			config.Cfg = tt.config
-			testDB, _ := setupTestDatabase(t)
-			defer testDB.PGConn.Close()
-
-			err := testDB.Ping()
-			if !tt.wantErr(t, err, "InitDB()") {
-				return
-			}
-		})
-	}
+		Daily: []StatsPoint{
+			{Time: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), Clicks: 3},
+			{Time: time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC), Clicks: 1},
+		},
+	}, stats)
 
+	empty := NewLinkStats("id", nil)
+	assert.Zero(t, empty.TotalClicks)
+	assert.Empty(t, empty.Hourly)
 }
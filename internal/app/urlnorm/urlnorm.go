@@ -0,0 +1,167 @@
+package urlnorm
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// defaultPorts ports dropped from canonical form of urls with their scheme
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize validate url. Returns url trimmed of surrounding whitespace, which is what gets stored,
+// and its canonical form used to find duplicates
+func Normalize(raw string) (string, string, error) {
+	original := strings.TrimSpace(raw)
+	canonical, err := canonicalize(original)
+	if err != nil {
+		return "", "", err
+	}
+
+	return original, canonical, nil
+}
+
+// Canonical canonical form of url. Urls which are not valid are their own canonical form,
+// so urls saved before validation was introduced are still told apart
+func Canonical(raw string) string {
+	canonical, err := canonicalize(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+
+	return canonical
+}
+
+// canonicalize check url is absolute http(s) url and build its canonical form: lower case scheme and host,
+// punycode host, no default port, no trailing slash, unreserved characters not percent-encoded
+func canonicalize(s string) (string, error) {
+	if s == "" || strings.IndexFunc(s, isSpaceOrControl) >= 0 {
+		return "", common.ErrInvalidURL
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.Opaque != "" {
+		return "", common.ErrInvalidURL
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[scheme]; !ok {
+		return "", common.ErrInvalidURL
+	}
+
+	host, err := canonicalHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(host)
+
+	path := normalizeEscapes(u.EscapedPath())
+	if path == "" {
+		path = "/"
+	} else if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+	b.WriteString(path)
+
+	if query := normalizeEscapes(u.RawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+	if fragment := normalizeEscapes(u.EscapedFragment()); fragment != "" {
+		b.WriteByte('#')
+		b.WriteString(fragment)
+	}
+
+	return b.String(), nil
+}
+
+// canonicalHost lower case host name in punycode without trailing dot. IP addresses are kept as they are
+func canonicalHost(host string) (string, error) {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", common.ErrInvalidURL
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", common.ErrInvalidURL
+	}
+
+	return strings.ToLower(ascii), nil
+}
+
+// normalizeEscapes decode percent-encoded unreserved characters and upper case hex digits of the rest
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+
+	return b.String()
+}
+
+func isSpaceOrControl(r rune) bool {
+	return r <= ' ' || r == 0x7f
+}
+
+// isUnreserved characters which never need percent-encoding, RFC 3986 section 2.3
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+
+	return c - 'A' + 10
+}
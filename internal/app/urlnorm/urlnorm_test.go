@@ -0,0 +1,51 @@
+package urlnorm
+
+import (
+	"github.com/fd239/go_url_shortener/internal/app/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		wantOriginal  string
+		wantCanonical string
+		wantErr       error
+	}{
+		{name: "Canonical already", raw: common.TestURL, wantOriginal: common.TestURL, wantCanonical: common.TestURL},
+		{name: "Surrounding whitespace", raw: " \thttps://example.com/a\n", wantOriginal: "https://example.com/a", wantCanonical: "https://example.com/a"},
+		{name: "Case", raw: "HTTPS://Example.COM/Path", wantOriginal: "HTTPS://Example.COM/Path", wantCanonical: "https://example.com/Path"},
+		{name: "Default port", raw: "http://example.com:80/a", wantOriginal: "http://example.com:80/a", wantCanonical: "http://example.com/a"},
+		{name: "Other port", raw: "https://example.com:8443/a", wantOriginal: "https://example.com:8443/a", wantCanonical: "https://example.com:8443/a"},
+		{name: "Empty path", raw: "https://example.com", wantOriginal: "https://example.com", wantCanonical: "https://example.com/"},
+		{name: "Trailing slash", raw: "https://example.com/a/b/", wantOriginal: "https://example.com/a/b/", wantCanonical: "https://example.com/a/b"},
+		{name: "Percent-encoding", raw: "https://example.com/%7euser/%2f?q=%41%2c#%62", wantOriginal: "https://example.com/%7euser/%2f?q=%41%2c#%62", wantCanonical: "https://example.com/~user/%2F?q=A%2C#b"},
+		{name: "Empty query", raw: "https://example.com/a?", wantOriginal: "https://example.com/a?", wantCanonical: "https://example.com/a"},
+		{name: "IDN host", raw: "https://Bücher.example/", wantOriginal: "https://Bücher.example/", wantCanonical: "https://xn--bcher-kva.example/"},
+		{name: "Trailing dot", raw: "https://example.com./a", wantOriginal: "https://example.com./a", wantCanonical: "https://example.com/a"},
+		{name: "IPv6", raw: "http://[::1]:80/a", wantOriginal: "http://[::1]:80/a", wantCanonical: "http://[::1]/a"},
+		{name: "Empty", raw: "  ", wantErr: common.ErrInvalidURL},
+		{name: "No scheme", raw: "example.com/a", wantErr: common.ErrInvalidURL},
+		{name: "Javascript", raw: "javascript:alert(1)", wantErr: common.ErrInvalidURL},
+		{name: "Other scheme", raw: "ftp://example.com/a", wantErr: common.ErrInvalidURL},
+		{name: "No host", raw: "https:///a", wantErr: common.ErrInvalidURL},
+		{name: "Inner whitespace", raw: "https://example.com/a b", wantErr: common.ErrInvalidURL},
+		{name: "Bad escape", raw: "https://example.com/%zz", wantErr: common.ErrInvalidURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, canonical, err := Normalize(tt.raw)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantOriginal, original)
+			assert.Equal(t, tt.wantCanonical, canonical)
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, "https://example.com/a", Canonical("HTTPS://example.com:443/a/"))
+	assert.Equal(t, "not a url", Canonical("not a url"), "invalid urls saved earlier are kept as they are")
+}